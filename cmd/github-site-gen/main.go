@@ -2,92 +2,366 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-i2p/go-gh-page/pkg/generator"
+	"github.com/go-i2p/go-gh-page/pkg/ghapi"
 	"github.com/go-i2p/go-gh-page/pkg/git"
 	"github.com/go-i2p/go-gh-page/pkg/templates"
-	github "github.com/google/go-github/v45/github"
-	"golang.org/x/oauth2"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
 )
 
 func main() {
+	// Exiting only once run returns lets its deferred cleanup, such as
+	// removing the clone and the staging directory, happen first
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// errInvalidFlags is returned once the problem with the flags was printed
+var errInvalidFlags = errors.New("invalid flags")
+
+// run generates the site the flags describe. Errors are logged, or printed
+// for invalid flags, before they are returned.
+func run() error {
 	// Define command-line flags
 	repoFlag := flag.String("repo", "", "GitHub repository in format 'owner/repo-name'")
 	outputFlag := flag.String("output", "./output", "Output directory for generated site")
 	branchFlag := flag.String("branch", "main", "Branch to use (default: main)")
 	workDirFlag := flag.String("workdir", "", "Working directory for cloning (default: temporary directory)")
+	versionsFlag := flag.String("versions", "", "Comma-separated tag patterns (e.g. 'v*') to build as versioned docs in <output>/<tag>/, next to the branch tip in <output>/latest/")
+	refFlag := flag.String("ref", "", "Tag or commit hash to generate the site from, instead of the tip of -branch")
+	localPath := flag.String("local-path", "", "Generate from an existing working copy instead of cloning, including uncommitted changes; -repo defaults to its origin remote")
+	projectsFlag := flag.String("projects", "", "Comma-separated directories or glob patterns (e.g. 'services/*') of a monorepo to present as separate projects, or 'go.work' for the modules of the workspace")
+	submodulesFlag := flag.String("submodules", "", "Comma-separated submodule paths or patterns to initialize and include ('*' for all, '!path' to exclude); other submodules are skipped")
+	followSymlinks := flag.Bool("follow-symlinks", false, "Follow symlinks to files and directories outside the repository, publishing whatever they point at; links within it are skipped as duplicates")
+	forceFresh := flag.Bool("force-fresh", false, "Replace existing clones in the working directory instead of updating them")
 	githost := flag.String("githost", "github.com", "Git host (default: github.com)")
 	mainTemplateOverride := flag.String("main-template", "", "Path to custom main template")
 	docTemplateOverride := flag.String("doc-template", "", "Path to custom documentation template")
 	styleTemplateOverride := flag.String("style-template", "", "Path to custom style template")
-	setupYaml := flag.Bool("page-yaml", false, "Generate .github/workflows/page.yaml file")
+	injectHead := flag.String("inject-head", "", "HTML inserted into the <head> of every page, as a file path or an inline string")
+	injectFooter := flag.String("inject-footer", "", "HTML inserted at the end of the <body> of every page, as a file path or an inline string")
+	copyright := flag.String("copyright", "", "Copyright line of the footer, e.g. '© {year} The go-i2p Authors'; {year} is the year the site is built")
+	docsLicense := flag.String("docs-license", "", "License of the documentation shown in the footer, as an SPDX identifier such as CC-BY-SA-4.0 (linked to its text) or a name")
+	dateFormat := flag.String("date-format", generator.DefaultDateFormat, "Layout of the dates shown on the site: iso, us (January 2, 2006), eu (2 January 2006) or a Go time layout")
+	timezone := flag.String("timezone", "UTC", "Time zone the dates on the site are shown in, e.g. Europe/Berlin, or Local for the zone of the machine")
+	hideGeneratedOn := flag.Bool("hide-generated-on", false, "Leave the date the site was generated out of the footer")
+	var footerLinks []generator.FooterLink
+	flag.Func("footer-link", "Link added to the footer of every page, as 'Title=URL'; URLs without a scheme are relative to the site root (repeatable)", func(value string) error {
+		title, url, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(title) == "" || strings.TrimSpace(url) == "" {
+			return fmt.Errorf("expected Title=URL")
+		}
+		footerLinks = append(footerLinks, generator.FooterLink{Title: strings.TrimSpace(title), URL: strings.TrimSpace(url)})
+		return nil
+	})
+	analytics := flag.String("analytics", "", "Analytics provider to add to every page: plausible, goatcounter or matomo (disabled with -offline)")
+	analyticsSiteID := flag.String("analytics-site-id", "", "Plausible domain, GoatCounter code or Matomo site ID")
+	analyticsURL := flag.String("analytics-url", "", "Analytics script URL, or the base URL of the Matomo instance")
+	exclude := flag.String("exclude", "", "Comma-separated glob patterns of documents to leave out of the site (a trailing / matches a directory)")
+	noindex := flag.String("noindex", "", "Comma-separated glob patterns of documents marked noindex for search engines (a trailing / matches a directory)")
+	languages := flag.String("languages", "", "Comma-separated content languages, default first (e.g. en,es,de); translations in docs/<lang>/ or name.<lang>.md get their own tree under <lang>/")
+	lang := flag.String("lang", "", "Language of the site's page chrome (e.g. es); selects the message catalog of the default language (default en)")
+	printTemplateData := flag.Bool("print-template-data", false, "Print an example of the data available to templates as JSON and exit")
+	templateDir := flag.String("template-dir", "", "Directory of templates overriding the built-in pages or individual partials (layout, head, nav, nav-section, header, footer)")
+	setupYaml := flag.Bool("page-yaml", false, "Generate the .github/workflows/page.yml workflow that builds and deploys the site")
+	workflowBranch := flag.String("page-yaml-branch", templates.DefaultWorkflow.Branch, "Branch whose pushes rebuild the site in the -page-yaml workflow")
+	workflowSchedule := flag.String("page-yaml-schedule", templates.DefaultWorkflow.Schedule, "Cron schedule of the -page-yaml workflow (empty for none)")
+	workflowGo := flag.String("page-yaml-go", templates.DefaultWorkflow.GoVersion, "Go version the -page-yaml workflow builds the generator with")
+	workflowFlags := flag.String("page-yaml-flags", "", "Extra github-site-gen flags for the -page-yaml workflow, e.g. '-optimize -cname docs.example.com'")
+	workflowMode := flag.String("page-yaml-mode", templates.DefaultWorkflow.Mode, "How the -page-yaml workflow deploys the site: branch (commit to gh-pages) or artifact (actions/deploy-pages)")
+	push := flag.Bool("push", false, "With -page-yaml, commit the workflow and push it to the current branch")
 	setupPage := flag.Bool("setup-page", false, "Setup GitHub Pages to build from gh-pages branch")
+	pagesTimeout := flag.Duration("pages-timeout", 10*time.Minute, "How long -setup-page waits for the Pages build to finish")
+	verbose := flag.Bool("v", false, "Verbose output, including every file found")
+	quiet := flag.Bool("q", false, "Quiet mode: only print warnings and errors")
+	jsonLogs := flag.Bool("json-logs", false, "Write logs as JSON")
+	reportFormat := flag.String("report", "", "Write a machine-readable generation report (format: json)")
+	reportFile := flag.String("report-file", "", "File to write the report to (default: stdout)")
+	sourceTree := flag.Bool("source-tree", false, "Also generate a browsable file tree (tree.html) with read-only views of source files")
+	sourceExt := flag.String("source-ext", strings.Join(generator.DefaultSourceExtensions, ","), "Comma-separated extensions of the files -source-tree shows on the site; other files link to GitHub")
+	singlePage := flag.Bool("single-page", false, "Also generate all.html with every documentation page in navigation order and a table of contents")
+	cname := flag.String("cname", "", "Custom domain of the site, e.g. docs.example.org: writes the CNAME file, canonical URLs and sitemap.xml, and is configured with -setup-page")
+	goImport := flag.String("go-import", "", "Vanity import path the site is served at, e.g. go.example.org/project: adds go-import and go-source meta tags pointing at the repository")
+	staticDir := flag.String("static", "", "Directory of the repository whose files are copied into the site root as they are (.well-known/ is always copied)")
+	securityContact := flag.String("security-contact", "", "Comma-separated email addresses or URLs for reporting vulnerabilities, published in .well-known/security.txt")
+	securityEncryption := flag.String("security-encryption", "", "URL of the key to encrypt vulnerability reports with, for security.txt")
+	cspMode := flag.String("csp", "", "Publish a content security policy and add integrity hashes to scripts and stylesheets: meta or headers")
+	strictCSP := flag.Bool("strict-csp", false, "Allow no inline scripts in the content security policy, and fail if a page has any (implies -csp meta)")
+	sanitize := flag.Bool("sanitize", false, "Strip scripts, event handlers, embeds and unsafe URLs from the repository's HTML, for repositories that aren't trusted")
+	mathEngine := flag.String("math", "", "Render $...$ and $$...$$ TeX math with katex or mathjax (vendored into the site with -offline)")
+	taskProgress := flag.Bool("task-progress", false, "Show a completion summary, e.g. \"3/7 done\", above every task list")
+	pdf := flag.Bool("pdf", false, "Also export the README and all documentation pages, in navigation order, as <repo>.pdf")
+	pdfCommand := flag.String("pdf-command", "", "Command printing HTML to PDF, with {input} and {output} placeholders (default: chromium, google-chrome or wkhtmltopdf, whichever is installed)")
+	checkAlt := flag.Bool("check-alt", false, "Report images without alt text in the generated site")
+	strictLinks := flag.Bool("strict-links", false, "Exit with an error, without publishing the site, if it contains broken internal links")
+	jobs := flag.Int("jobs", runtime.NumCPU(), "Number of pages to render concurrently")
+	optimize := flag.Bool("optimize", false, "Minify HTML/CSS and fingerprint assets with content hashes")
+	clean := flag.Bool("clean", false, "Remove files an earlier run generated that this run didn't, as listed in the manifest of the output directory")
+	historyLimit := flag.Int("history-limit", 0, "Only read the latest N commits for statistics and page history (0 for all)")
+	sinceFlag := flag.String("since", "", "Only read commits made after a date (YYYY-MM-DD) for statistics and page history")
+	noStats := flag.Bool("no-stats", false, "Don't read the commit history: no commit count, contributors or page history")
+	excludeBots := flag.Bool("exclude-bots", false, "Leave bot accounts such as dependabot[bot] out of the contributors and commit count")
+	excludeMerges := flag.Bool("exclude-merges", false, "Leave merge commits out of the contributors and commit count")
+	excludeAuthors := flag.String("exclude-authors", "", "Comma-separated glob patterns of author names or emails to leave out of the contributors and commit count")
+	topContributors := flag.Int("top-contributors", 5, "Number of top contributors listed on the main page (0 for all)")
+	reproducible := flag.Bool("reproducible", false, "Produce byte-identical output for the same commit, using the last commit date instead of the current time")
+	optimizeImages := flag.Bool("optimize-images", false, "Re-encode large PNG and JPEG images")
+	imageWidths := flag.String("image-widths", "", "Comma-separated responsive image widths to generate, e.g. 480,960")
+	imageFormats := flag.String("image-formats", "", "Comma-separated extra image formats to generate (webp, avif)")
+	logoFlag := flag.String("logo", "", "Path to the logo or favicon, relative to the repository root (default: auto-detect)")
+	includeWiki := flag.Bool("include-wiki", false, "Clone the repository's wiki and render it into a wiki/ section")
+	offline := flag.Bool("offline", false, "Download external images so the site works on mirrors without clearnet access (badges are replaced with their alt text)")
+	jsonAPI := flag.String("json-api", "", "Write a machine-readable description of the site: index (pages.json listing every page) or pages (also a .json document with the content of every page)")
+	missingReadme := flag.String("missing-readme", generator.MissingReadmeOverview, "Home page of a repository without a README: overview (built from its description, files, Go module and contributors), placeholder or fail")
+	rewriteSelfLinks := flag.Bool("rewrite-self-links", true, "Point absolute links to files of the repository on GitHub, e.g. https://github.com/owner/repo/blob/main/docs/foo.md, at their pages on the site, and /raw/ image URLs at the local copies")
+	relatedFlag := flag.String("related", "", "YAML file listing related repositories to show as cards on the home page, relative to the repository (default: "+git.DefaultRelatedFile+" if it exists)")
+	docsDir := flag.String("docs-dir", generator.DefaultLayout.DocsDir, "Directory of the generated site holding the documentation pages")
+	imagesDir := flag.String("images-dir", generator.DefaultLayout.ImagesDir, "Directory of the generated site holding the images")
+	assetsDir := flag.String("assets-dir", generator.DefaultLayout.AssetsDir, "Directory of the generated site holding vendored third-party files, such as the math engine")
+	readmePage := flag.String("readme-page", generator.DefaultLayout.ReadmePage, "Page of the README; another page than index.html, e.g. readme.html, leaves index.html to an overview of the repository")
+	prettyURLs := flag.Bool("pretty-urls", false, "Generate each page as the index.html of its own directory and link to pages by directory, e.g. docs/guide/ instead of docs/guide.html")
+	redirects := flag.String("redirects", "", "File mapping old paths of the site to new ones, one 'old -> new' per line, relative to the repository (default: "+generator.DefaultRedirectsFile+" if it exists)")
+	blog := flag.String("blog", "", "Directory of dated posts, e.g. blog or news, generated as a blog with a newest-first listing and an RSS feed instead of documentation pages")
+	search := flag.Bool("search", false, "Add client-side search of the documentation, with an OpenSearch description so browsers can add the site as a search engine")
+	baseURL := flag.String("base-url", "", "Public URL of the site, for links that must be absolute, when it isn't served from -cname or GitHub Pages")
+	llmsTxt := flag.Bool("llms-txt", false, "Write llms.txt, an index of the documentation for language models, and llms-full.txt with the text of every page")
+	rawMarkdown := flag.Bool("raw-markdown", false, "Also copy the markdown source of every page into the site, next to the page with a .md extension, and link it")
+	repoBadges := flag.Bool("repo-badges", false, "Write build date, license, version and docs page count badges to badges/ of the site, for the README to use")
+	badges := flag.String("badges", generator.BadgesKeep, "How to render status badges: keep, fetch (download as local images), static (generate local equivalents) or strip")
+	includeIssues := flag.Bool("include-issues", false, "Generate snapshot pages of open issues and discussions (requires GITHUB_TOKEN)")
+	wrapHTML := flag.Bool("wrap-html", false, "Render hand-written HTML pages under docs/ inside the site layout instead of copying them unchanged")
+	docFormats := flag.String("doc-formats", strings.Join(generator.DefaultDocFormats, ","), "Comma-separated document extensions to render besides markdown (e.g. add .txt for plain text)")
+	var converterFormats []string
+	flag.Func("converter", "Render documents with an extension through a command reading stdin and writing HTML, as 'ext=command args' (repeatable)", func(value string) error {
+		ext, command, ok := strings.Cut(value, "=")
+		if !ok || ext == "" || strings.TrimSpace(command) == "" {
+			return fmt.Errorf("expected ext=command")
+		}
+		generator.RegisterConverter(ext, generator.CommandConverter{Command: strings.Fields(command)})
+		converterFormats = append(converterFormats, ext)
+		return nil
+	})
+	githubAPI := flag.Bool("github-api", true, "Use the GitHub API to enrich repository data and contributor avatars (uses GITHUB_TOKEN if set)")
 
 	flag.Parse()
 
+	logger := newLogger(*verbose, *quiet, *jsonLogs)
+	git.SetLogger(logger)
+	ghapi.SetLogger(logger)
+	// Progress would be mixed into machine-readable logs
+	if !*quiet && !*jsonLogs {
+		utils.SetProgressOutput(os.Stderr, isTerminal(os.Stderr))
+	}
+
+	if *printTemplateData {
+		example, err := generator.TemplateDataExample()
+		if err != nil {
+			return fail(logger, "Failed to describe the template data", err)
+		}
+		fmt.Println(string(example))
+		return nil
+	}
+
+	if !slices.Contains(templates.WorkflowModes, *workflowMode) {
+		fmt.Printf("Error: -page-yaml-mode must be one of %s\n", strings.Join(templates.WorkflowModes, ", "))
+		return errInvalidFlags
+	}
+
 	if *setupYaml {
-		if err := os.MkdirAll(".github/workflows", 0o755); err != nil {
-			log.Fatalf("Failed to create .github/workflows directory: %v", err)
+		workflow := templates.Workflow{
+			Branch:    *workflowBranch,
+			Schedule:  strings.TrimSpace(*workflowSchedule),
+			GoVersion: *workflowGo,
+			Flags:     strings.Fields(*workflowFlags),
+			Mode:      *workflowMode,
 		}
-		// Generate the page.yaml file
-		if err := os.WriteFile(".github/workflows/page.yml", []byte(templates.CITemplate), 0o644); err != nil {
-			log.Fatalf("Failed to generate page.yml: %v", err)
+		if err := writeWorkflow(logger, workflow, *push); err != nil {
+			return fail(logger, "Failed to set up the workflow", err)
+		}
+		return nil
+	}
+
+	if !slices.Contains(generator.BadgeModes, *badges) {
+		fmt.Printf("Error: -badges must be one of %s\n", strings.Join(generator.BadgeModes, ", "))
+		return errInvalidFlags
+	}
+
+	if !slices.Contains(generator.MissingReadmeModes, *missingReadme) {
+		fmt.Printf("Error: -missing-readme must be one of %s\n", strings.Join(generator.MissingReadmeModes, ", "))
+		return errInvalidFlags
+	}
+
+	if *jsonAPI != "" && !slices.Contains(generator.JSONAPIModes, *jsonAPI) {
+		fmt.Printf("Error: -json-api must be one of %s\n", strings.Join(generator.JSONAPIModes, ", "))
+		return errInvalidFlags
+	}
+
+	if *mathEngine != "" && !slices.Contains(generator.MathEngines, *mathEngine) {
+		fmt.Printf("Error: -math must be one of %s\n", strings.Join(generator.MathEngines, ", "))
+		return errInvalidFlags
+	}
+
+	if *strictCSP && *cspMode == "" {
+		*cspMode = generator.CSPMeta
+	}
+	if *cspMode != "" && !slices.Contains(generator.CSPModes, *cspMode) {
+		fmt.Printf("Error: -csp must be one of %s\n", strings.Join(generator.CSPModes, ", "))
+		return errInvalidFlags
+	}
+
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		since, err = time.Parse(time.DateOnly, *sinceFlag)
+		if err != nil {
+			fmt.Println("Error: -since must be a date like 2024-01-31")
+			return errInvalidFlags
 		}
-		fmt.Printf("Generated .github/workflows/page.yaml in %s\n", *outputFlag)
-		if err := exec.Command("git", "add", ".github/workflows/page.yml").Run(); err != nil {
-			log.Fatalf("Failed to add page.yml to git: %v", err)
+	}
+	if *topContributors < 0 {
+		fmt.Println("Error: -top-contributors can't be negative")
+		return errInvalidFlags
+	}
+	if *historyLimit < 0 {
+		fmt.Println("Error: -history-limit can't be negative")
+		return errInvalidFlags
+	}
+
+	siteLanguages := splitList(*languages)
+	if *lang != "" {
+		if len(siteLanguages) == 0 {
+			siteLanguages = []string{*lang}
+		} else if !strings.EqualFold(siteLanguages[0], *lang) {
+			fmt.Println("Error: -lang must match the first of -languages")
+			return errInvalidFlags
 		}
-		if err := exec.Command("git", "commit", "-m", "Add GitHub Actions workflow for page generation").Run(); err != nil {
-			log.Fatalf("Failed to commit page.yml: %v", err)
+	}
+
+	if *refFlag != "" && *localPath != "" {
+		fmt.Println("Error: -ref can't be used with -local-path; check out the ref in the working copy instead")
+		return errInvalidFlags
+	}
+	if *versionsFlag != "" && (*refFlag != "" || *localPath != "") {
+		fmt.Println("Error: -versions can't be used with -ref or -local-path")
+		return errInvalidFlags
+	}
+
+	// A local working copy is used as-is, and names the repository through its origin remote
+	var localRepo *gogit.Repository
+	var localDir string
+	if *localPath != "" {
+		var err error
+		localRepo, localDir, err = git.OpenLocal(*localPath)
+		if err != nil {
+			return fail(logger, "Failed to open local working copy", err)
 		}
-		if err := exec.Command("git", "push").Run(); err != nil {
-			log.Fatalf("Failed to push page.yml: %v", err)
+		if *repoFlag == "" {
+			owner, name, err := git.RemoteRepository(localRepo)
+			if err != nil {
+				fmt.Printf("Error: %v; set the repository with -repo\n", err)
+				return errInvalidFlags
+			}
+			*repoFlag = owner + "/" + name
 		}
-		fmt.Println("Added .github/workflows/page.yml to git staging area.")
-		fmt.Println("You can now commit and push this file to your repository.")
-		os.Exit(0)
 	}
 
 	// Validate repository flag
 	if *repoFlag == "" {
 		fmt.Println("Error: -repo flag is required (format: owner/repo-name)")
 		flag.Usage()
-		os.Exit(1)
+		return errInvalidFlags
 	}
 
 	repoParts := strings.Split(*repoFlag, "/")
 	if len(repoParts) != 2 {
 		fmt.Println("Error: -repo flag must be in format 'owner/repo-name'")
 		flag.Usage()
-		os.Exit(1)
+		return errInvalidFlags
+	}
+
+	// Accept the domain as a URL too
+	*cname = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(*cname, "https://"), "http://"), "/"))
+	if strings.ContainsAny(*cname, "/: ") {
+		fmt.Println("Error: -cname must be a domain name, e.g. docs.example.org")
+		return errInvalidFlags
+	}
+
+	if *blog != "" && (filepath.IsAbs(*blog) || slices.Contains(strings.Split(filepath.ToSlash(filepath.Clean(*blog)), "/"), "..")) {
+		fmt.Println("Error: -blog must be a directory inside the repository, e.g. blog")
+		return errInvalidFlags
+	}
+
+	for name, dir := range map[string]string{"-docs-dir": *docsDir, "-images-dir": *imagesDir, "-assets-dir": *assetsDir} {
+		if dir == "" || filepath.IsAbs(dir) || slices.Contains(strings.Split(filepath.ToSlash(filepath.Clean(dir)), "/"), "..") {
+			fmt.Printf("Error: %s must be a directory inside the output directory\n", name)
+			return errInvalidFlags
+		}
+	}
+	if path := filepath.ToSlash(filepath.Clean(*readmePage)); filepath.IsAbs(*readmePage) || strings.Contains(path, "/") || filepath.Ext(path) != ".html" {
+		fmt.Println("Error: -readme-page must be an .html page at the site root, e.g. readme.html")
+		return errInvalidFlags
+	}
+
+	if *baseURL != "" {
+		if u, err := url.Parse(*baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fmt.Println("Error: -base-url must be an http or https URL, e.g. http://example.i2p/docs/")
+			return errInvalidFlags
+		}
+	}
+
+	*goImport = strings.TrimSuffix(strings.TrimPrefix(*goImport, "https://"), "/")
+	if strings.ContainsAny(*goImport, ": ") {
+		fmt.Println("Error: -go-import must be an import path, e.g. go.example.org/project")
+		return errInvalidFlags
 	}
+
 	if *setupPage {
-		if err := enableGithubPage(repoParts[0], repoParts[1]); err != nil {
-			log.Fatalf("Failed to enable GitHub Pages: %v", err)
+		if err := enableGithubPage(repoParts[0], repoParts[1], *cname, *pagesTimeout); err != nil {
+			return fail(logger, "Failed to enable GitHub Pages", err)
+		}
+		return nil
+	}
+	// Apply the template directory first, so the single-template flags take precedence
+	if *templateDir != "" {
+		loaded, err := templates.LoadDir(*templateDir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return errInvalidFlags
+		}
+		logger.Info("Using custom templates", "dir", *templateDir, "files", loaded)
+	}
+	for _, l := range siteLanguages {
+		if !templates.HasCatalog(l) {
+			logger.Warn("No message catalog for language, using English page chrome", "lang", l)
 		}
-		fmt.Printf("Enabled GitHub Pages for %s/%s\n", strings.Split(*repoFlag, "/")[0], strings.Split(*repoFlag, "/")[1])
-		os.Exit(0)
 	}
 	// if mainTemplateOverride is not empty, check if a file exists
 	if *mainTemplateOverride != "" {
 		if _, err := os.Stat(*mainTemplateOverride); os.IsNotExist(err) {
 			fmt.Printf("Error: main template file %s does not exist\n", *mainTemplateOverride)
-			os.Exit(1)
+			return errInvalidFlags
 		} else {
-			fmt.Printf("Using custom main template: %s\n", *mainTemplateOverride)
+			logger.Info("Using custom main template", "path", *mainTemplateOverride)
 			// read the file in and override templates.MainTemplate
 			data, err := os.ReadFile(*mainTemplateOverride)
 			if err != nil {
 				fmt.Printf("Error: failed to read main template file %s: %v\n", *mainTemplateOverride, err)
-				os.Exit(1)
+				return errInvalidFlags
 			}
 			templates.MainTemplate = string(data)
 		}
@@ -96,14 +370,14 @@ func main() {
 	if *docTemplateOverride != "" {
 		if _, err := os.Stat(*docTemplateOverride); os.IsNotExist(err) {
 			fmt.Printf("Error: doc template file %s does not exist\n", *docTemplateOverride)
-			os.Exit(1)
+			return errInvalidFlags
 		} else {
-			fmt.Printf("Using custom docs template: %s\n", *docTemplateOverride)
+			logger.Info("Using custom docs template", "path", *docTemplateOverride)
 			// read the file in and override templates.MainTemplate
 			data, err := os.ReadFile(*docTemplateOverride)
 			if err != nil {
 				fmt.Printf("Error: failed to read docs template file %s: %v\n", *docTemplateOverride, err)
-				os.Exit(1)
+				return errInvalidFlags
 			}
 			templates.DocTemplate = string(data)
 		}
@@ -112,26 +386,118 @@ func main() {
 	if *styleTemplateOverride != "" {
 		if _, err := os.Stat(*styleTemplateOverride); os.IsNotExist(err) {
 			fmt.Printf("Error: style template file %s does not exist\n", *styleTemplateOverride)
-			os.Exit(1)
+			return errInvalidFlags
 		} else {
-			fmt.Printf("Using custom style template: %s\n", *styleTemplateOverride)
+			logger.Info("Using custom style template", "path", *styleTemplateOverride)
 			// read the file in and override templates.MainTemplate
 			data, err := os.ReadFile(*styleTemplateOverride)
 			if err != nil {
 				fmt.Printf("Error: failed to read style template file %s: %v\n", *styleTemplateOverride, err)
-				os.Exit(1)
+				return errInvalidFlags
 			}
 			templates.StyleTemplate = string(data)
 		}
 	}
+	// Report mistakes in custom templates before spending time on the repository
+	if err := generator.ValidateTemplates(); err != nil {
+		fmt.Printf("Error: invalid template:\n%v\n", err)
+		return errInvalidFlags
+	}
+
+	headSnippet, err := readSnippet(*injectHead)
+	if err != nil {
+		fmt.Printf("Error: failed to read -inject-head: %v\n", err)
+		return errInvalidFlags
+	}
+	footerSnippet, err := readSnippet(*injectFooter)
+	if err != nil {
+		fmt.Printf("Error: failed to read -inject-footer: %v\n", err)
+		return errInvalidFlags
+	}
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		fmt.Printf("Error: invalid -timezone: %v\n", err)
+		return errInvalidFlags
+	}
+
+	// Analytics are left out of offline builds, which are meant for mirrors without clearnet access
+	if *analytics != "" && !*offline {
+		snippet, err := generator.AnalyticsSnippet(generator.AnalyticsOptions{
+			Provider: *analytics,
+			SiteID:   *analyticsSiteID,
+			URL:      *analyticsURL,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return errInvalidFlags
+		}
+		if headSnippet != "" {
+			headSnippet += "\n  "
+		}
+		headSnippet += snippet
+	}
+
+	imageOpts := generator.ImageOptions{Optimize: *optimizeImages}
+	for _, w := range splitList(*imageWidths) {
+		width, err := strconv.Atoi(w)
+		if err != nil || width <= 0 {
+			fmt.Printf("Error: invalid -image-widths value %q\n", w)
+			return errInvalidFlags
+		}
+		imageOpts.Widths = append(imageOpts.Widths, width)
+	}
+	for _, format := range splitList(*imageFormats) {
+		if format != "webp" && format != "avif" {
+			fmt.Printf("Error: unsupported -image-formats value %q (supported: webp, avif)\n", format)
+			return errInvalidFlags
+		}
+		imageOpts.Formats = append(imageOpts.Formats, format)
+	}
+
+	var formats []string
+	for _, ext := range append(splitList(*docFormats), converterFormats...) {
+		ext = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+		if !generator.HasConverter(ext) {
+			fmt.Printf("Error: unsupported -doc-formats value %q (supported: %s)\n", ext, strings.Join(generator.ConverterExtensions(), ", "))
+			return errInvalidFlags
+		}
+		formats = append(formats, ext)
+	}
+	git.SetDocumentExtensions(formats)
+
+	if *reportFormat != "" && *reportFormat != "json" {
+		fmt.Printf("Error: unsupported -report format %q (supported: json)\n", *reportFormat)
+		return errInvalidFlags
+	}
 
 	owner, repo := repoParts[0], repoParts[1]
 	repoURL := fmt.Sprintf("https://%s/%s/%s.git", *githost, owner, repo)
 
+	report := newReport(owner+"/"+repo, *outputFlag)
+	warn := func(msg string, err error) {
+		logger.Warn(msg, "error", err)
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", msg, err))
+	}
+
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(*outputFlag, 0o755); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+		return fail(logger, "Failed to create output directory", err)
+	}
+	if *clean {
+		if err := generator.CheckClean(*outputFlag); err != nil {
+			return fail(logger, "Failed to clean output directory", err)
+		}
+	}
+
+	// The site is generated next to the output directory and only replaces it
+	// once it is complete
+	siteDir, err := generator.NewStagingDir(*outputFlag)
+	if err != nil {
+		return fail(logger, "Failed to create staging directory", err)
 	}
+	// Publishing moves the staging directory away, so this only removes it
+	// when the run fails
+	defer os.RemoveAll(siteDir)
 
 	// Determine working directory
 	workDir := *workDirFlag
@@ -139,43 +505,341 @@ func main() {
 		// Create temporary directory
 		tempDir, err := os.MkdirTemp("", "github-site-gen-*")
 		if err != nil {
-			log.Fatalf("Failed to create temporary directory: %v", err)
+			return fail(logger, "Failed to create temporary directory", err)
 		}
 		workDir = tempDir
 		defer os.RemoveAll(tempDir) // Clean up when done
 	} else {
 		// Ensure the specified work directory exists
 		if err := os.MkdirAll(workDir, 0o755); err != nil {
-			log.Fatalf("Failed to create working directory: %v", err)
+			return fail(logger, "Failed to create working directory", err)
 		}
 	}
 
 	cloneDir := filepath.Join(workDir, repo)
 
-	// Clone the repository
-	fmt.Printf("Cloning %s/%s into %s...\n", owner, repo, cloneDir)
+	// Clone the repository, unless generating from a local working copy
 	startTime := time.Now()
-	gitRepo, err := git.CloneRepository(repoURL, cloneDir, *branchFlag)
+	gitRepo := localRepo
+	if localRepo != nil {
+		cloneDir = localDir
+		logger.Info("Using local working copy", "repo", owner+"/"+repo, "dir", cloneDir)
+	} else {
+		logger.Info("Cloning repository", "repo", owner+"/"+repo, "dir", cloneDir)
+		var err error
+		gitRepo, err = git.CloneRepository(repoURL, cloneDir, *branchFlag, *forceFresh)
+		if err != nil {
+			return fail(logger, "Failed to clone repository (use -force-fresh to replace an existing clone)", err)
+		}
+		logger.Info("Repository cloned", "seconds", fmt.Sprintf("%.2f", time.Since(startTime).Seconds()))
+
+		if *refFlag != "" {
+			hash, err := git.CheckoutRef(gitRepo, *refFlag)
+			if err != nil {
+				return fail(logger, "Failed to check out ref", err)
+			}
+			logger.Info("Checked out ref", "ref", *refFlag, "commit", hash)
+		}
+	}
+
+	// Initialize the selected submodules and leave out the rest, including
+	// any already checked out in a local working copy
+	submodules, skippedSubmodules, err := git.Submodules(gitRepo, splitList(*submodulesFlag))
 	if err != nil {
-		log.Fatalf("Failed to clone repository: %v", err)
+		return fail(logger, "Failed to read submodules", err)
+	}
+	if len(submodules) > 0 && localRepo == nil {
+		logger.Info("Updating submodules", "paths", strings.Join(submodules, ", "))
+		if err := git.UpdateSubmodules(gitRepo, submodules); err != nil {
+			return fail(logger, "Failed to update submodules", err)
+		}
 	}
-	fmt.Printf("Repository cloned in %.2f seconds\n", time.Since(startTime).Seconds())
+
+	// The output directory may be inside the working copy
+	skipPaths := []string{*outputFlag, siteDir}
+	for _, path := range skippedSubmodules {
+		skipPaths = append(skipPaths, filepath.Join(cloneDir, filepath.FromSlash(path)))
+	}
+	git.SetSkipPaths(skipPaths...)
+	git.SetFollowSymlinks(*followSymlinks)
+	git.SetHistoryLimit(*historyLimit, since)
+	git.SetStats(!*noStats)
+	git.SetContributorOptions(git.ContributorOptions{
+		ExcludeBots:    *excludeBots,
+		ExcludeAuthors: splitList(*excludeAuthors),
+		ExcludeMerges:  *excludeMerges,
+		Top:            *topContributors,
+	})
+	report.addPhase("clone", time.Since(startTime))
 
 	// Get repository data
+	startPhase := time.Now()
 	repoData, err := git.GetRepositoryData(gitRepo, owner, repo, cloneDir)
 	if err != nil {
-		log.Fatalf("Failed to gather repository data: %v", err)
+		return fail(logger, "Failed to gather repository data", err)
+	}
+	if repoData.Empty {
+		report.Warnings = append(report.Warnings, "Repository has no commits")
+	}
+	if repoData.Related, err = git.ReadRelated(cloneDir, *relatedFlag); err != nil {
+		return fail(logger, "Failed to read related repositories", err)
+	}
+	report.addPhase("analyze", time.Since(startPhase))
+
+	if *includeWiki {
+		wikiURL := fmt.Sprintf("https://%s/%s/%s.wiki.git", *githost, owner, repo)
+		wikiDir := filepath.Join(workDir, repo+".wiki")
+		logger.Info("Cloning wiki", "repo", owner+"/"+repo, "dir", wikiDir)
+		if _, err := git.CloneRepository(wikiURL, wikiDir, "master", *forceFresh); err != nil {
+			warn("Failed to clone wiki", err)
+		} else if repoData.WikiPages, err = git.ReadWikiPages(wikiDir); err != nil {
+			warn("Failed to read wiki", err)
+		}
+	}
+
+	if *logoFlag != "" {
+		logoPath := filepath.Join(cloneDir, *logoFlag)
+		if _, err := os.Stat(logoPath); err != nil {
+			warn("Logo not found", err)
+		} else {
+			repoData.LogoFile = logoPath
+		}
 	}
 
-	// Create generator
-	gen := generator.NewGenerator(repoData, *outputFlag)
+	// Enrich repository data from the GitHub API, falling back to what the clone provides
+	if *githubAPI && *githost == "github.com" {
+		startPhase = time.Now()
+		ctx := context.Background()
+		client := ghapi.NewClient(ctx)
+		if err := ghapi.EnrichRepository(ctx, client, repoData); err != nil {
+			warn("GitHub API lookup failed", err)
+		}
+		if err := ghapi.ResolveContributors(ctx, client, owner, repo, repoData.Contributors); err != nil {
+			warn("GitHub API lookup failed", err)
+		}
+		if err := ghapi.FetchRelated(ctx, client, repoData.Related); err != nil {
+			warn("GitHub API lookup failed", err)
+		}
+		// Release notes are only fetched with a token, as large histories quickly exhaust the anonymous rate limit
+		if os.Getenv("GITHUB_TOKEN") != "" {
+			if err := ghapi.FetchReleases(ctx, client, repoData); err != nil {
+				warn("GitHub API lookup failed", err)
+			}
+			if *includeIssues {
+				if err := ghapi.FetchIssues(ctx, client, repoData); err != nil {
+					warn("GitHub API lookup failed", err)
+				}
+				if err := ghapi.FetchDiscussions(ctx, client, repoData); err != nil {
+					warn("GitHub API lookup failed", err)
+				}
+			}
+		} else if *includeIssues {
+			logger.Warn("Skipping issues and discussions, GITHUB_TOKEN is not set")
+		}
+		report.addPhase("github_api", time.Since(startPhase))
+	}
+
+	// Versioned sites build every matching tag next to the branch tip
+	outputDir := siteDir
+	var versions []string
+	var versionTags []git.Tag
+	if *versionsFlag != "" {
+		versionTags, err = git.MatchingTags(gitRepo, splitList(*versionsFlag))
+		if err != nil {
+			return fail(logger, "Failed to list version tags", err)
+		}
+		versions = []string{"latest"}
+		for _, tag := range versionTags {
+			versions = append(versions, tag.Name)
+		}
+		outputDir = filepath.Join(siteDir, "latest")
+		logger.Info("Building versioned documentation", "versions", versions)
+	}
+
+	// Create a generator for the site of one version, or of the whole repository
+	newGenerator := func(data *git.RepositoryData, dir, version string) (*generator.Generator, error) {
+		gen := generator.NewGenerator(data, dir)
+		gen.SetLogger(logger)
+		gen.SetJobs(*jobs)
+		gen.SetOptimize(*optimize)
+		gen.SetReproducible(*reproducible)
+		gen.SetOffline(*offline)
+		gen.SetBadgeMode(*badges)
+		gen.SetMissingReadme(*missingReadme)
+		gen.SetRewriteSelfLinks(*rewriteSelfLinks)
+		gen.SetRepoBadges(*repoBadges)
+		gen.SetRawMarkdown(*rawMarkdown)
+		gen.SetJSONAPI(*jsonAPI)
+		gen.SetLLMsTxt(*llmsTxt)
+		gen.SetSearch(*search)
+		gen.SetBlog(*blog)
+		gen.SetRedirects(*redirects)
+		gen.SetPrettyURLs(*prettyURLs)
+		gen.SetLayout(generator.Layout{
+			DocsDir:    *docsDir,
+			ImagesDir:  *imagesDir,
+			AssetsDir:  *assetsDir,
+			ReadmePage: *readmePage,
+		})
+		gen.SetBaseURL(*baseURL)
+		gen.SetWrapHTML(*wrapHTML)
+		gen.SetInjections(headSnippet, footerSnippet)
+		gen.SetDateFormat(*dateFormat, location)
+		gen.SetFooter(generator.Footer{
+			Copyright:       *copyright,
+			DocsLicense:     *docsLicense,
+			Links:           footerLinks,
+			HideGeneratedOn: *hideGeneratedOn,
+		})
+		gen.SetLanguages(siteLanguages)
+		gen.SetCheckAlt(*checkAlt)
+		gen.SetSinglePage(*singlePage)
+		gen.SetSourceTree(*sourceTree, splitList(*sourceExt))
+		gen.SetTaskProgress(*taskProgress)
+		gen.SetMath(*mathEngine)
+		gen.SetSanitize(*sanitize)
+		gen.SetCSP(*cspMode, *strictCSP)
+		gen.SetStaticDir(*staticDir)
+		gen.SetCNAME(*cname)
+		gen.SetGoImport(*goImport)
+		gen.SetSecurityTxt(generator.SecurityTxt{
+			Contact:    splitList(*securityContact),
+			Encryption: *securityEncryption,
+		})
+		if *pdf {
+			gen.SetPDF(repo+".pdf", strings.Fields(*pdfCommand))
+		}
+		gen.SetPathRules(splitList(*exclude), splitList(*noindex))
+		gen.SetImageOptions(imageOpts)
+		gen.SetVersions(versions, version)
+		if *projectsFlag != "" {
+			dirs, err := projectDirs(cloneDir, splitList(*projectsFlag))
+			if err != nil {
+				return nil, fail(logger, "Failed to find projects", err)
+			}
+			gen.SetProjects(dirs)
+		}
+		return gen, nil
+	}
+
+	// Documents renamed since the last build redirect from their old pages
+	previous, err := generator.ReadManifest(*outputFlag)
+	if err != nil {
+		warn("Failed to read the manifest of the last build", err)
+	}
+	var renames map[string]string
+	if previous.Commit != "" && repoData.Commit != "" && previous.Commit != repoData.Commit {
+		if renames, err = git.Renames(gitRepo, previous.Commit); err != nil {
+			warn("Failed to detect moved documents", err)
+		}
+	}
 
 	// Generate site
-	fmt.Println("Generating static site...")
+	logger.Info("Generating static site")
 	startGenTime := time.Now()
+	gen, err := newGenerator(repoData, outputDir, "latest")
+	if err != nil {
+		return err
+	}
+	gen.SetMoved(previous.Moved, renames)
 	result, err := gen.GenerateSite()
 	if err != nil {
-		log.Fatalf("Failed to generate site: %v", err)
+		return fail(logger, "Failed to generate site", err)
+	}
+	brokenLinks := len(result.BrokenLinks)
+	if versions != nil {
+		report.addResult(result, "latest")
+	} else {
+		report.addResult(result, "")
+	}
+
+	// Generate the site of each version from its tag
+	for _, tag := range versionTags {
+		logger.Info("Generating version", "version", tag.Name)
+		if _, err := git.CheckoutRef(gitRepo, tag.Name); err != nil {
+			return fail(logger, "Failed to check out version", err)
+		}
+		if len(submodules) > 0 {
+			if err := git.UpdateSubmodules(gitRepo, submodules); err != nil {
+				return fail(logger, "Failed to update submodules", err)
+			}
+		}
+		tagData, err := git.GetRepositoryData(gitRepo, owner, repo, cloneDir)
+		if err != nil {
+			return fail(logger, "Failed to gather repository data", err)
+		}
+		inheritMetadata(tagData, repoData)
+		if *logoFlag != "" {
+			logoPath := filepath.Join(cloneDir, *logoFlag)
+			if _, err := os.Stat(logoPath); err == nil {
+				tagData.LogoFile = logoPath
+			}
+		}
+
+		tagGen, err := newGenerator(tagData, filepath.Join(siteDir, tag.Name), tag.Name)
+		if err != nil {
+			return err
+		}
+		tagResult, err := tagGen.GenerateSite()
+		if err != nil {
+			return fail(logger, "Failed to generate version "+tag.Name, err)
+		}
+		brokenLinks += len(tagResult.BrokenLinks)
+		report.addResult(tagResult, tag.Name)
+	}
+	if versions != nil {
+		if err := generator.WriteVersionRedirect(siteDir, "latest"); err != nil {
+			return fail(logger, "Failed to write version redirect", err)
+		}
+		if *cname != "" {
+			if err := generator.WriteCNAME(siteDir, *cname); err != nil {
+				return fail(logger, "Failed to write CNAME", err)
+			}
+		}
+	}
+
+	// A site with broken links isn't published, leaving the previous site in
+	// place; the report still lists the links
+	if *strictLinks && brokenLinks > 0 {
+		if *reportFormat != "" {
+			if err := report.write(*reportFormat, *reportFile); err != nil {
+				warn("Failed to write report", err)
+			}
+		}
+		return fail(logger, "Generated site contains broken links", fmt.Errorf("%d broken internal links", brokenLinks))
+	}
+
+	// Record the generated files, so the next run can remove stale ones
+	files, err := generator.SiteFiles(siteDir)
+	if err != nil {
+		return fail(logger, "Failed to write manifest", err)
+	}
+	manifest := generator.Manifest{Files: files, Generated: result.GeneratedAt, Commit: repoData.Commit, Moved: result.Moved}
+	if err := generator.WriteManifest(siteDir, manifest); err != nil {
+		return fail(logger, "Failed to write manifest", err)
+	}
+	removed, err := generator.PublishSite(siteDir, *outputFlag, *clean)
+	if err != nil {
+		return fail(logger, "Failed to publish site", err)
+	}
+	if *clean {
+		for _, file := range removed {
+			logger.Debug("Removed stale file", "path", file)
+		}
+		logger.Info("Removed stale files", "count", len(removed))
+	}
+	report.addPhase("generate", time.Since(startGenTime))
+	report.addPhase("total", time.Since(startTime))
+
+	if *reportFormat != "" {
+		if err := report.write(*reportFormat, *reportFile); err != nil {
+			return fail(logger, "Failed to write report", err)
+		}
+	}
+
+	// The human-readable summary would corrupt a report written to stdout
+	if *quiet || (*reportFormat != "" && *reportFile == "") {
+		return nil
 	}
 
 	// Print summary
@@ -183,40 +847,182 @@ func main() {
 		owner, repo, time.Since(startGenTime).Seconds())
 	fmt.Printf("- Main page: %s\n", filepath.Join(*outputFlag, "index.html"))
 	fmt.Printf("- Documentation pages: %d markdown files converted\n", result.DocsCount)
+	if len(versions) > 0 {
+		fmt.Printf("- Versions: %s\n", strings.Join(versions, ", "))
+	}
+	if len(result.BrokenLinks) > 0 {
+		fmt.Printf("- Broken internal links: %d\n", len(result.BrokenLinks))
+	}
+	if len(result.MissingAlt) > 0 {
+		fmt.Printf("- Images without alt text: %d\n", len(result.MissingAlt))
+	}
 
 	if result.ImagesCount > 0 {
-		fmt.Printf("- Images directory: %s/images/\n", *outputFlag)
+		siteRel, _ := filepath.Rel(siteDir, outputDir)
+		fmt.Printf("- Images directory: %s/\n", filepath.Join(*outputFlag, siteRel, *imagesDir))
 	}
 
-	fmt.Printf("\nSite structure:\n%s\n", result.SiteStructure)
+	fmt.Printf("\nSite structure:\n%s\n", strings.Replace(result.SiteStructure, siteDir, filepath.Clean(*outputFlag), 1))
 	fmt.Printf("\nYou can open index.html directly in your browser\n")
 	fmt.Printf("or deploy the entire directory to any static web host.\n")
 
 	fmt.Printf("\nTotal time: %.2f seconds\n", time.Since(startTime).Seconds())
+
+	return nil
+}
+
+// readSnippet returns the contents of a snippet flag: the file it names if
+// one exists, or the value itself as inline HTML
+func readSnippet(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return value, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// projectDirs expands the -projects patterns into directories relative to
+// the repository root. "go.work" stands for the modules of the workspace.
+func projectDirs(root string, patterns []string) ([]string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern == "go.work" {
+			content, err := os.ReadFile(filepath.Join(root, "go.work"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read go.work: %w", err)
+			}
+			for _, dir := range utils.GoWorkModules(string(content)) {
+				add(dir)
+			}
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid project pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+			rel, err := filepath.Rel(root, match)
+			if err != nil {
+				return nil, err
+			}
+			add(filepath.ToSlash(rel))
+		}
+	}
+	return dirs, nil
+}
+
+// inheritMetadata copies what the GitHub API and flags added to the data of the
+// branch tip to the data of an older version, so it isn't looked up again
+func inheritMetadata(dst, src *git.RepositoryData) {
+	dst.Description = src.Description
+	dst.Topics = src.Topics
+	dst.Stars = src.Stars
+	dst.Forks = src.Forks
+	dst.DefaultBranch = src.DefaultBranch
+	dst.Homepage = src.Homepage
+	dst.Releases = src.Releases
+	dst.Issues = src.Issues
+	dst.Discussions = src.Discussions
+	dst.Related = src.Related
+
+	// Contributors of the older version resolved to GitHub accounts for the tip
+	accounts := make(map[string]git.Contributor)
+	for _, c := range src.Contributors {
+		accounts[strings.ToLower(c.Email)] = c
+	}
+	for i, c := range dst.Contributors {
+		if account, ok := accounts[strings.ToLower(c.Email)]; ok {
+			dst.Contributors[i].Login = account.Login
+			dst.Contributors[i].ProfileURL = account.ProfileURL
+			dst.Contributors[i].AvatarURL = account.AvatarURL
+		}
+	}
 }
 
-func enableGithubPage(userName, repoName string) error {
+// newLogger creates the logger for the given verbosity flags
+func newLogger(verbose, quiet, jsonLogs bool) *slog.Logger {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	} else if quiet {
+		level = slog.LevelWarn
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if jsonLogs {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// isTerminal reports whether a file is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// fail logs an error and returns it, for run to return to main
+func fail(logger *slog.Logger, msg string, err error) error {
+	logger.Error(msg, "error", err)
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func enableGithubPage(userName, repoName, cname string, timeout time.Duration) error {
 	branch := "gh-pages"
 	token := os.Getenv("GITHUB_TOKEN")
 	if len(token) == 0 {
 		return fmt.Errorf("GITHUB_TOKEN not set")
 	}
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-	path := "/"
-	_, _, err := client.Repositories.EnablePages(ctx, userName, repoName, &github.Pages{
-		Source: &github.PagesSource{
-			Branch: github.String(branch),
-			Path:   github.String(path),
-		},
-		Public: github.Bool(true),
-	})
+	client := ghapi.NewClient(ctx)
+	if err := ghapi.EnablePages(ctx, client, userName, repoName, branch, cname); err != nil {
+		return err
+	}
+	fmt.Printf("Enabled GitHub Pages for %s/%s, waiting for the site to build...\n", userName, repoName)
+
+	pages, err := ghapi.WaitForPages(ctx, client, userName, repoName, timeout)
 	if err != nil {
-		return fmt.Errorf("could not enable github pages: %v", err)
+		return err
+	}
+
+	fmt.Printf("Site published at %s\n", pages.GetHTMLURL())
+	switch {
+	case pages.GetHTTPSEnforced():
+		fmt.Println("HTTPS is enforced")
+	case pages.GetHTTPSCertificate().GetState() != "" && pages.GetHTTPSCertificate().GetState() != "approved":
+		fmt.Printf("HTTPS is not enforced yet: the certificate is %s\n", pages.GetHTTPSCertificate().GetState())
+	default:
+		fmt.Println("HTTPS is not enforced; enable it in the repository's Pages settings")
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/generator"
+)
+
+// Report is the machine-readable summary of a generation run
+type Report struct {
+	Repository string `json:"repository"`
+	OutputDir  string `json:"output_dir"`
+
+	Pages       []string `json:"pages"`
+	PagesCount  int      `json:"pages_count"`
+	DocsCount   int      `json:"docs_count"`
+	Assets      []string `json:"assets"`
+	AssetsCount int      `json:"assets_count"`
+
+	BrokenLinks []generator.BrokenLink `json:"broken_links"`
+	MissingAlt  []generator.MissingAlt `json:"missing_alt"`
+	Warnings    []string               `json:"warnings"`
+
+	// Durations of each phase in seconds
+	Durations map[string]float64 `json:"durations"`
+}
+
+// newReport creates an empty report for a repository
+func newReport(repository, outputDir string) *Report {
+	return &Report{
+		Repository: repository,
+		OutputDir:  outputDir,
+		Pages:      []string{},
+		Assets:     []string{},
+
+		BrokenLinks: []generator.BrokenLink{},
+		MissingAlt:  []generator.MissingAlt{},
+		Warnings:    []string{},
+		Durations:   make(map[string]float64),
+	}
+}
+
+// addPhase records how long a phase took
+func (r *Report) addPhase(name string, d time.Duration) {
+	r.Durations[name] = d.Seconds()
+}
+
+// addResult records the outcome of site generation. For a versioned site,
+// dir is the directory of the version, which prefixes its paths.
+func (r *Report) addResult(result *generator.GenerationResult, dir string) {
+	prefix := ""
+	if dir != "" {
+		prefix = filepath.ToSlash(dir) + "/"
+	}
+
+	for _, page := range result.Pages {
+		r.Pages = append(r.Pages, prefix+page)
+	}
+	r.PagesCount = len(r.Pages)
+	r.DocsCount += result.DocsCount
+	for _, asset := range result.Assets {
+		r.Assets = append(r.Assets, prefix+asset)
+	}
+	r.AssetsCount = len(r.Assets)
+	for _, link := range result.BrokenLinks {
+		link.Page = prefix + link.Page
+		r.BrokenLinks = append(r.BrokenLinks, link)
+	}
+	for _, img := range result.MissingAlt {
+		img.Page = prefix + img.Page
+		r.MissingAlt = append(r.MissingAlt, img)
+	}
+	for _, warning := range result.Warnings {
+		if dir != "" {
+			warning = dir + ": " + warning
+		}
+		r.Warnings = append(r.Warnings, warning)
+	}
+}
+
+// write writes the report in the given format to path, or to stdout if path is empty
+func (r *Report) write(format, path string) error {
+	if format != "json" {
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
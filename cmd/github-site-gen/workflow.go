@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/templates"
+)
+
+// workflowPath is where -page-yaml writes the workflow
+const workflowPath = ".github/workflows/page.yml"
+
+// writeWorkflow writes the GitHub Actions workflow into the current
+// directory, and commits and pushes it if push is set
+func writeWorkflow(logger *slog.Logger, workflow templates.Workflow, push bool) error {
+	content, err := workflow.Render()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(workflowPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(workflowPath), err)
+	}
+	if err := os.WriteFile(workflowPath, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", workflowPath, err)
+	}
+	logger.Info("Generated " + workflowPath)
+
+	if !push {
+		fmt.Printf("Wrote %s. Commit and push it to enable the workflow, or rerun with -push.\n", workflowPath)
+		return nil
+	}
+
+	// Pushing from a detached HEAD (as in most CI checkouts) has no upstream
+	if err := runGit("symbolic-ref", "-q", "HEAD"); err != nil {
+		return fmt.Errorf("HEAD is detached; check out a branch to push the workflow from")
+	}
+	if err := runGit("add", workflowPath); err != nil {
+		return err
+	}
+	// git diff --quiet exits with 1 when the workflow has staged changes
+	if err := runGit("diff", "--cached", "--quiet", "--", workflowPath); err == nil {
+		logger.Info("Workflow is already committed")
+	} else if err := runGit("commit", "-m", "Add GitHub Actions workflow for page generation", "--", workflowPath); err != nil {
+		return err
+	}
+	if err := runGit("push"); err != nil {
+		return err
+	}
+	fmt.Printf("Committed and pushed %s.\n", workflowPath)
+	return nil
+}
+
+// runGit runs a git command in the current directory and includes its output
+// in the error if it fails
+func runGit(args ...string) error {
+	var out bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(out.String())
+		if msg == "" {
+			return fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, msg)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// GitHub alerts: > [!NOTE]
+	alertRe = regexp.MustCompile(`(?i)^>\s*\[!(note|tip|important|warning|caution)\]\s*$`)
+	// Classic admonitions: !!! note "Optional title"
+	admonitionRe = regexp.MustCompile(`^!!!\s+([\w-]+)(?:\s+"([^"]*)")?\s*$`)
+)
+
+// admonitionKinds maps the admonition types of other tools to the five
+// kinds of GitHub alerts
+var admonitionKinds = map[string]string{
+	"note":      "note",
+	"info":      "note",
+	"abstract":  "note",
+	"tip":       "tip",
+	"hint":      "tip",
+	"success":   "tip",
+	"important": "important",
+	"warning":   "warning",
+	"attention": "warning",
+	"caution":   "caution",
+	"danger":    "caution",
+	"error":     "caution",
+	"bug":       "caution",
+}
+
+// convertAdmonitions turns GitHub alerts and classic !!! admonitions outside
+// fenced code blocks into callout boxes, like the note shortcode
+func (g *Generator) convertAdmonitions(path, content string) string {
+	if !strings.Contains(content, "[!") && !strings.Contains(content, "!!!") {
+		return content
+	}
+
+	T := g.messages(g.pageLanguage(path))
+	lines := strings.SplitAfter(content, "\n")
+
+	var out strings.Builder
+	inFence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := shortcodeCodeFenceRe.FindStringSubmatch(line); m != nil {
+			switch inFence {
+			case "":
+				inFence = m[1]
+			case m[1]:
+				inFence = ""
+			}
+		}
+		if inFence != "" {
+			out.WriteString(line)
+			continue
+		}
+
+		trimmed := strings.TrimRight(line, "\r\n")
+		if m := alertRe.FindStringSubmatch(trimmed); m != nil {
+			// The alert runs to the end of the blockquote
+			var body []string
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], ">") {
+				i++
+				body = append(body, strings.TrimPrefix(strings.TrimPrefix(lines[i], ">"), " "))
+			}
+			kind := strings.ToLower(m[1])
+			out.WriteString(g.admonition(kind, T[titleKey(kind)], strings.Join(body, "")))
+			continue
+		}
+
+		if m := admonitionRe.FindStringSubmatch(trimmed); m != nil {
+			// The admonition runs while lines are indented, or blank
+			var body []string
+			for i+1 < len(lines) {
+				next := lines[i+1]
+				if strings.TrimSpace(next) != "" && !strings.HasPrefix(next, "    ") && !strings.HasPrefix(next, "\t") {
+					break
+				}
+				i++
+				if strings.HasPrefix(next, "\t") {
+					body = append(body, next[1:])
+				} else {
+					body = append(body, strings.TrimPrefix(next, "    "))
+				}
+			}
+
+			name := strings.ToLower(m[1])
+			kind, known := admonitionKinds[name]
+			if !known {
+				kind = name
+			}
+			title := m[2]
+			if title == "" {
+				if known {
+					title = T[titleKey(kind)]
+				} else {
+					title = strings.ToUpper(name[:1]) + name[1:]
+				}
+			}
+			out.WriteString(g.admonition(kind, title, strings.Join(body, "")))
+			continue
+		}
+
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// admonition renders a callout box around markdown content, as a block of its own
+func (g *Generator) admonition(kind, title, body string) string {
+	return g.placeShortcodeHTML(admonitionHTML(kind, title, markdownStart+body+markdownEnd)) + "\n"
+}
+
+// titleKey returns the message catalog key of an admonition kind's title
+func titleKey(kind string) string {
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MissingAlt is an image in the generated site without alternative text
+type MissingAlt struct {
+	Page string `json:"page"`
+	Src  string `json:"src"`
+}
+
+var altRe = regexp.MustCompile(`(?i)\salt\s*=`)
+
+// SetCheckAlt enables reporting images that have no alt attribute after generation
+func (g *Generator) SetCheckAlt(enabled bool) {
+	g.checkAlt = enabled
+}
+
+// CheckImageAlt scans the HTML files in the output directory for images
+// without an alt attribute. An empty alt="" marks a decorative image and is
+// not reported.
+func CheckImageAlt(outputDir string) ([]MissingAlt, error) {
+	var missing []MissingAlt
+
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".html") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		page, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+
+		for _, tag := range imgTagRe.FindAllString(string(content), -1) {
+			if altRe.MatchString(tag) {
+				continue
+			}
+			var src string
+			if m := linkAttrRe.FindStringSubmatch(tag); m != nil {
+				src = m[1] + m[2]
+			}
+			missing = append(missing, MissingAlt{Page: filepath.ToSlash(page), Src: src})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return missing, nil
+}
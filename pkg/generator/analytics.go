@@ -0,0 +1,78 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"text/template"
+)
+
+// AnalyticsProviders lists the supported analytics services
+var AnalyticsProviders = []string{"plausible", "goatcounter", "matomo"}
+
+// Default scripts of the analytics services, which are updated in place
+const (
+	plausibleScript   = "https://plausible.io/js/script.js"
+	goatcounterScript = "https://gc.zgo.at/count.js"
+)
+
+// AnalyticsOptions configures the analytics snippet added to every page
+type AnalyticsOptions struct {
+	// Provider is one of AnalyticsProviders
+	Provider string
+	// SiteID is the Plausible domain, GoatCounter code or Matomo site ID
+	SiteID string
+	// URL overrides the script URL for Plausible and GoatCounter, and is the
+	// base URL of the Matomo instance
+	URL string
+}
+
+// AnalyticsSnippet returns the script tags for an analytics provider
+func AnalyticsSnippet(opts AnalyticsOptions) (string, error) {
+	if opts.SiteID == "" {
+		return "", fmt.Errorf("%s analytics require a site ID", opts.Provider)
+	}
+
+	switch opts.Provider {
+	case "plausible":
+		script := opts.URL
+		if script == "" {
+			script = plausibleScript
+		}
+		return fmt.Sprintf(`<script defer data-domain="%s" src="%s"></script>`,
+			html.EscapeString(opts.SiteID), html.EscapeString(script)), nil
+
+	case "goatcounter":
+		endpoint := opts.SiteID
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint + ".goatcounter.com/count"
+		}
+		script := opts.URL
+		if script == "" {
+			script = goatcounterScript
+		}
+		return fmt.Sprintf(`<script data-goatcounter="%s" async src="%s"></script>`,
+			html.EscapeString(endpoint), html.EscapeString(script)), nil
+
+	case "matomo":
+		if opts.URL == "" {
+			return "", fmt.Errorf("matomo analytics require the URL of the Matomo instance")
+		}
+		base := template.JSEscapeString(strings.TrimSuffix(opts.URL, "/") + "/")
+		return fmt.Sprintf(`<script>
+  var _paq = window._paq = window._paq || [];
+  _paq.push(['trackPageView']);
+  _paq.push(['enableLinkTracking']);
+  (function() {
+    var u = '%s';
+    _paq.push(['setTrackerUrl', u + 'matomo.php']);
+    _paq.push(['setSiteId', '%s']);
+    var d = document, g = d.createElement('script'), s = d.getElementsByTagName('script')[0];
+    g.async = true; g.src = u + 'matomo.js'; s.parentNode.insertBefore(g, s);
+  })();
+  </script>`, base, template.JSEscapeString(opts.SiteID)), nil
+
+	default:
+		return "", fmt.Errorf("unsupported analytics provider %q (supported: %s)", opts.Provider, strings.Join(AnalyticsProviders, ", "))
+	}
+}
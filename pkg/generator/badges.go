@@ -0,0 +1,332 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// Badge modes control how status badge images are rendered
+const (
+	// BadgesKeep leaves badges pointing at their remote service
+	BadgesKeep = "keep"
+	// BadgesFetch downloads badges as local images
+	BadgesFetch = "fetch"
+	// BadgesStatic replaces badges with locally generated SVGs
+	BadgesStatic = "static"
+	// BadgesStrip removes badges from the pages
+	BadgesStrip = "strip"
+)
+
+// BadgeModes lists the supported badge modes
+var BadgeModes = []string{BadgesKeep, BadgesFetch, BadgesStatic, BadgesStrip}
+
+// badgeHosts are services whose images are status badges rather than content
+var badgeHosts = []string{
+	"img.shields.io",
+	"shields.io",
+	"badge.fury.io",
+	"badgen.net",
+	"goreportcard.com",
+	"codecov.io",
+	"coveralls.io",
+	"travis-ci.org",
+	"travis-ci.com",
+	"circleci.com",
+	"pkg.go.dev",
+	"godoc.org",
+}
+
+// badgeColors maps shields.io color names to hex colors
+var badgeColors = map[string]string{
+	"brightgreen":   "#4c1",
+	"green":         "#97ca00",
+	"yellowgreen":   "#a4a61d",
+	"yellow":        "#dfb317",
+	"orange":        "#fe7d37",
+	"red":           "#e05d44",
+	"blue":          "#007ec6",
+	"lightgrey":     "#9f9f9f",
+	"lightgray":     "#9f9f9f",
+	"grey":          "#555",
+	"gray":          "#555",
+	"success":       "#4c1",
+	"important":     "#fe7d37",
+	"critical":      "#e05d44",
+	"informational": "#007ec6",
+	"inactive":      "#9f9f9f",
+}
+
+// badgeTagRe matches a badge image, optionally wrapped in a link
+var badgeTagRe = regexp.MustCompile(`(?i)<a\s[^>]*>\s*(<img\s[^>]*>)\s*</a>|<img\s[^>]*>`)
+
+// isBadgeURL reports whether an image URL points at a status badge
+func isBadgeURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, badgeHost := range badgeHosts {
+		if host == badgeHost || strings.HasSuffix(host, "."+badgeHost) {
+			return true
+		}
+	}
+
+	// GitHub Actions workflow badges
+	return host == "github.com" && strings.Contains(u.Path, "/workflows/") && strings.HasSuffix(u.Path, "/badge.svg")
+}
+
+// SetBadgeMode sets how status badges are rendered, one of BadgeModes
+func (g *Generator) SetBadgeMode(mode string) {
+	g.badgeMode = mode
+}
+
+// processBadges fetches, replaces or strips the badge images in the
+// generated pages according to the badge mode
+func (g *Generator) processBadges(result *GenerationResult) error {
+	if g.badgeMode == "" || g.badgeMode == BadgesKeep {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	badges := make(map[string]string)
+
+	for _, page := range result.Pages {
+		pagePath := filepath.Join(g.outputDir, page)
+		content, err := os.ReadFile(pagePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page, err)
+		}
+		rootPath := utils.RelativeRoot(page)
+
+		var writeErr error
+		rewritten := badgeTagRe.ReplaceAllStringFunc(string(content), func(match string) string {
+			tag := match
+			if sub := badgeTagRe.FindStringSubmatch(match); sub[1] != "" {
+				tag = sub[1]
+			}
+			m := linkAttrRe.FindStringSubmatch(tag)
+			if m == nil {
+				return match
+			}
+			src := m[1] + m[2]
+			if !isBadgeURL(src) {
+				return match
+			}
+
+			if g.badgeMode == BadgesStrip {
+				return ""
+			}
+
+			local, ok := badges[src]
+			if !ok {
+				if g.badgeMode == BadgesFetch {
+					local, err = downloadImage(client, src, g.outputDir, g.imagesPath("badges"))
+					if err != nil {
+						g.logger.Warn("Failed to fetch badge, using a static badge", "url", src, "error", err)
+					}
+				}
+				if local == "" {
+					label, message, color := g.staticBadge(src, altText(tag))
+					local, err = writeBadgeSVG(g.outputDir, g.imagesPath("badges"), label, message, color)
+					if err != nil {
+						writeErr = err
+						return match
+					}
+				}
+				badges[src] = local
+				result.Assets = append(result.Assets, local)
+			}
+
+			return strings.Replace(match, m[0], `src="`+rootPath+local+`"`, 1)
+		})
+		if writeErr != nil {
+			return writeErr
+		}
+
+		if err := os.WriteFile(pagePath, []byte(rewritten), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", page, err)
+		}
+	}
+
+	return nil
+}
+
+// staticBadge computes the label, message and color of a local equivalent
+// of a badge, using repository data for license and version badges
+func (g *Generator) staticBadge(rawURL, alt string) (string, string, string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return alt, "", badgeColors["grey"]
+	}
+
+	// Static shields.io badges carry their text in the path
+	if strings.HasSuffix(u.Hostname(), "shields.io") && strings.HasPrefix(u.Path, "/badge/") {
+		if label, message, color, ok := parseShieldsBadge(strings.TrimPrefix(u.Path, "/badge/")); ok {
+			return label, message, color
+		}
+	}
+
+	hint := strings.ToLower(u.Path + " " + alt)
+	switch {
+	case strings.Contains(hint, "license") && g.repoData.License != "":
+		return "license", g.repoData.License, badgeColors["blue"]
+	case (strings.Contains(hint, "release") || strings.Contains(hint, "version") ||
+		strings.Contains(hint, "tag")) && len(g.repoData.Tags) > 0:
+		// Tags are sorted newest first
+		return "version", g.repoData.Tags[0].Name, badgeColors["blue"]
+	}
+
+	if alt == "" {
+		alt = u.Hostname()
+	}
+	return alt, "", badgeColors["grey"]
+}
+
+// parseShieldsBadge parses a static shields.io badge path of the form
+// label-message-color, where "--" is a literal dash and "_" a space
+func parseShieldsBadge(path string) (string, string, string, bool) {
+	path = strings.TrimSuffix(path, filepath.Ext(path))
+	path = strings.ReplaceAll(path, "--", "\x00")
+	parts := strings.Split(path, "-")
+	if len(parts) == 2 {
+		parts = append([]string{""}, parts...)
+	}
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "__", "\x01")
+		part = strings.ReplaceAll(part, "_", " ")
+		part = strings.ReplaceAll(part, "\x01", "_")
+		part = strings.ReplaceAll(part, "\x00", "-")
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		parts[i] = part
+	}
+
+	color, ok := badgeColors[strings.ToLower(parts[2])]
+	if !ok {
+		color = "#" + strings.TrimPrefix(parts[2], "#")
+	}
+	return parts[0], parts[1], color, true
+}
+
+// altText returns the alt attribute of an <img> tag
+func altText(tag string) string {
+	if alt := altAttrRe.FindStringSubmatch(tag); alt != nil {
+		return html.UnescapeString(alt[1])
+	}
+	return ""
+}
+
+// writeBadgeSVG renders a flat badge with a grey label and colored message
+// into dir, e.g. images/badges, and returns its path relative to the output
+// directory
+func writeBadgeSVG(outputDir, dir, label, message, color string) (string, error) {
+	svg := badgeSVG(label, message, color)
+	sum := sha256.Sum256([]byte(svg))
+	path := dir + "/" + hex.EncodeToString(sum[:])[:16] + ".svg"
+
+	if err := os.MkdirAll(filepath.Join(outputDir, filepath.FromSlash(dir)), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create badges directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, path), []byte(svg), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write badge: %w", err)
+	}
+
+	return path, nil
+}
+
+// badgeSVG renders a flat badge with a grey label and colored message
+func badgeSVG(label, message, color string) string {
+	// Approximate Verdana 11px text widths
+	textWidth := func(s string) int { return len([]rune(s))*7 + 10 }
+
+	labelWidth, messageWidth := textWidth(label), 0
+	if message != "" {
+		messageWidth = textWidth(message)
+	}
+	if label == "" {
+		labelWidth = 0
+	}
+	labelColor := badgeColors["grey"]
+	if message == "" {
+		// Single-part badges show the label on the badge color
+		labelColor = color
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`,
+		labelWidth+messageWidth, html.EscapeString(strings.TrimSpace(label+" "+message)))
+	fmt.Fprintf(&svg, `<rect width="%d" height="20" rx="3" fill="%s"/>`, labelWidth+messageWidth, html.EscapeString(labelColor))
+	if message != "" {
+		fmt.Fprintf(&svg, `<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, messageWidth, html.EscapeString(color))
+	}
+	svg.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	if label != "" {
+		fmt.Fprintf(&svg, `<text x="%d" y="14">%s</text>`, labelWidth/2, html.EscapeString(label))
+	}
+	if message != "" {
+		fmt.Fprintf(&svg, `<text x="%d" y="14">%s</text>`, labelWidth+messageWidth/2, html.EscapeString(message))
+	}
+	svg.WriteString(`</g></svg>`)
+	return svg.String()
+}
+
+// SetRepoBadges writes badges computed from the repository into badges/ of
+// the site, for READMEs to show without depending on a badge service:
+// build.svg with the date the site was generated, license.svg, version.svg
+// with the latest tag and docs.svg with the number of documentation pages
+func (g *Generator) SetRepoBadges(enabled bool) {
+	g.repoBadges = enabled
+}
+
+// writeRepoBadges writes the repository badges. License and version badges
+// are only written when the repository has a license and tags.
+func (g *Generator) writeRepoBadges(result *GenerationResult, docCount int) error {
+	type badge struct {
+		name, label, message, color string
+	}
+	badges := []badge{
+		{"build", "docs built", g.now().Format("2006-01-02"), badgeColors["brightgreen"]},
+		{"docs", "docs", fmt.Sprintf("%d pages", docCount), badgeColors["informational"]},
+	}
+	license := g.repoData.LicenseSPDX
+	if license == "" {
+		license = g.repoData.License
+	}
+	if license != "" {
+		badges = append(badges, badge{"license", "license", license, badgeColors["blue"]})
+	}
+	if len(g.repoData.Tags) > 0 {
+		// Tags are sorted newest first
+		badges = append(badges, badge{"version", "version", g.repoData.Tags[0].Name, badgeColors["blue"]})
+	}
+
+	if err := os.MkdirAll(filepath.Join(g.outputDir, "badges"), 0o755); err != nil {
+		return fmt.Errorf("failed to create badges directory: %w", err)
+	}
+	for _, b := range badges {
+		path := "badges/" + b.name + ".svg"
+		if err := os.WriteFile(filepath.Join(g.outputDir, path), []byte(badgeSVG(b.label, b.message, b.color)), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		result.Assets = append(result.Assets, path)
+	}
+	return nil
+}
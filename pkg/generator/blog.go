@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// postsPerPage is the number of posts on a page of the blog listing
+const postsPerPage = 10
+
+// feedItems is the number of latest posts in the RSS feed
+const feedItems = 20
+
+// postDateRe matches the date prefix of a post's filename, e.g.
+// 2024-05-01-release.md
+var postDateRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-`)
+
+// postDateLayouts are the accepted formats of a front matter date
+var postDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// PostEntry is a post listed on a page of the blog
+type PostEntry struct {
+	Title   string
+	URL     string
+	Date    string
+	Summary string
+}
+
+// post is a document of the blog directory
+type post struct {
+	source string
+	date   time.Time
+	// outputPath is the path of the post's page
+	outputPath string
+}
+
+// SetBlog generates the markdown files of a directory, such as blog or news,
+// as dated posts instead of documentation pages: each post gets a page
+// under the directory, which also gets a newest-first listing split into
+// pages and an RSS feed, feed.xml. Posts are dated by a front matter date,
+// a YYYY-MM-DD- filename prefix, or else their last commit.
+func (g *Generator) SetBlog(dir string) {
+	g.blogDir = strings.Trim(filepath.ToSlash(dir), "/")
+}
+
+// isPost reports whether a document is a post of the blog. READMEs of the
+// blog directory stay documentation pages.
+func (g *Generator) isPost(path string) bool {
+	return g.blogDir != "" && !g.wiki[path] && !isReadmeFile(filepath.Base(path)) &&
+		strings.HasPrefix(filepath.ToSlash(path), g.blogDir+"/")
+}
+
+// blogListingPath returns the output path of a page of the blog listing,
+// counting from 1
+func (g *Generator) blogListingPath(page int) string {
+	if page == 1 {
+		return g.blogDir + "/index.html"
+	}
+	return g.blogDir + "/page/" + strconv.Itoa(page) + ".html"
+}
+
+// postDate returns the date of a post
+func (g *Generator) postDate(source string) time.Time {
+	if date := strings.TrimSpace(g.frontMatter[source].Date); date != "" {
+		for _, layout := range postDateLayouts {
+			if t, err := time.Parse(layout, date); err == nil {
+				return t
+			}
+		}
+		g.logger.Warn("Ignoring front matter date in an unknown format", "path", source, "date", date)
+	}
+	if m := postDateRe.FindStringSubmatch(filepath.Base(source)); m != nil {
+		if t, err := time.Parse("2006-01-02", m[1]); err == nil {
+			return t
+		}
+	}
+	return g.repoData.FileHistory[source].LastModified
+}
+
+// loadPosts collects the posts of the blog, newest first
+func (g *Generator) loadPosts() {
+	g.posts = nil
+	for source := range g.markdown {
+		if !g.isPost(source) || g.frontMatter[source].Draft || matchPath(g.exclude, source) {
+			continue
+		}
+		name := g.frontMatter[source].Slug
+		if name == "" {
+			name = postDateRe.ReplaceAllString(strings.TrimSuffix(filepath.Base(source), filepath.Ext(source)), "")
+		}
+		dir := path.Dir(strings.TrimPrefix(filepath.ToSlash(source), g.blogDir+"/"))
+		outputPath := path.Join(g.blogDir, dir, name+".html")
+		g.posts = append(g.posts, post{source: source, date: g.postDate(source), outputPath: outputPath})
+	}
+	sort.Slice(g.posts, func(i, j int) bool {
+		if !g.posts[i].date.Equal(g.posts[j].date) {
+			return g.posts[i].date.After(g.posts[j].date)
+		}
+		return g.posts[i].source < g.posts[j].source
+	})
+}
+
+// blogTitle returns the name of the blog, after its directory
+func (g *Generator) blogTitle() string {
+	return utils.PrettifyFilename(path.Base(g.blogDir))
+}
+
+// generateBlog creates the page of every post, the pages of the listing and
+// the RSS feed, and returns the paths of the pages
+func (g *Generator) generateBlog(docsPages []utils.DocPage, result *GenerationResult) ([]string, error) {
+	lang := g.defaultLang()
+	title := g.blogTitle()
+
+	var generated []string
+	entries := make([]PostEntry, len(g.posts))
+	summaries := make([]string, len(g.posts))
+	for i, p := range g.posts {
+		page := g.newPage("doc", p.outputPath, lang, docsPages)
+		page.Source = p.source
+		data := page.Data
+		postTitle := g.pageTitle(p.source)
+		data.PageTitle = postTitle + " - " + title + " - " + g.repoData.Owner + "/" + g.repoData.Name
+		data.PageHeading = postTitle
+		data.Breadcrumbs = []utils.Breadcrumb{{Title: title, Path: g.blogListingPath(1)}, {Title: postTitle}}
+		data.PostDate = g.formatDate(p.date)
+		data.PageContent = g.renderDocContent(p.source, g.markdown[p.source], data.RootPath)
+		data.PageTags = g.pageTags(p.source, data.RootPath)
+		data.NoIndex = g.frontMatter[p.source].NoIndex || matchPath(g.noindex, p.source)
+		if description := g.frontMatter[p.source].Description; description != "" {
+			data.Description = description
+			summaries[i] = description
+		} else {
+			summaries[i] = contentSummary(data.PageContent)
+		}
+		if err := g.renderPage(page); err != nil {
+			return nil, fmt.Errorf("failed to generate post %s: %w", p.source, err)
+		}
+		generated = append(generated, p.outputPath)
+		entries[i] = PostEntry{Title: postTitle, URL: p.outputPath, Date: g.formatDate(p.date), Summary: summaries[i]}
+	}
+
+	pageCount := max(1, (len(entries)+postsPerPage-1)/postsPerPage)
+	for n := 1; n <= pageCount; n++ {
+		outputPath := g.blogListingPath(n)
+		page := g.newPage("blog", outputPath, lang, docsPages)
+		data := page.Data
+		for _, entry := range entries[(n-1)*postsPerPage : min(n*postsPerPage, len(entries))] {
+			entry.URL = data.RootPath + entry.URL
+			data.Posts = append(data.Posts, entry)
+		}
+		if n > 1 {
+			data.NewerPostsURL = data.RootPath + g.blogListingPath(n-1)
+		}
+		if n < pageCount {
+			data.OlderPostsURL = data.RootPath + g.blogListingPath(n+1)
+		}
+		data.PageTitle = title + " - " + g.repoData.Owner + "/" + g.repoData.Name
+		data.PageHeading = title
+		if err := g.renderPage(page); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", outputPath, err)
+		}
+		generated = append(generated, outputPath)
+	}
+
+	if err := g.writeFeed(result, summaries); err != nil {
+		return nil, err
+	}
+	return generated, nil
+}
+
+// writeFeed writes the RSS feed of the latest posts, with the summary of
+// each post
+func (g *Generator) writeFeed(result *GenerationResult, summaries []string) error {
+	siteURL := g.publicURL()
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">` + "\n<channel>\n")
+	b.WriteString("  <title>" + html.EscapeString(g.repoData.Name+" "+g.blogTitle()) + "</title>\n")
+	b.WriteString("  <link>" + html.EscapeString(siteURL+g.blogListingPath(1)) + "</link>\n")
+	b.WriteString(`  <atom:link href="` + html.EscapeString(siteURL+g.feedPath()) + `" rel="self" type="application/rss+xml"/>` + "\n")
+	description := g.repoData.Description
+	if description == "" {
+		description = g.repoData.Owner + "/" + g.repoData.Name
+	}
+	b.WriteString("  <description>" + html.EscapeString(description) + "</description>\n")
+	b.WriteString("  <language>" + html.EscapeString(g.defaultLang()) + "</language>\n")
+	for i, p := range g.posts {
+		if i == feedItems {
+			break
+		}
+		link := html.EscapeString(siteURL + p.outputPath)
+		b.WriteString("  <item>\n")
+		b.WriteString("    <title>" + html.EscapeString(g.pageTitle(p.source)) + "</title>\n")
+		b.WriteString("    <link>" + link + "</link>\n")
+		b.WriteString("    <guid>" + link + "</guid>\n")
+		if !p.date.IsZero() {
+			b.WriteString("    <pubDate>" + p.date.Format(time.RFC1123Z) + "</pubDate>\n")
+		}
+		if summaries[i] != "" {
+			b.WriteString("    <description>" + html.EscapeString(summaries[i]) + "</description>\n")
+		}
+		b.WriteString("  </item>\n")
+	}
+	b.WriteString("</channel>\n</rss>\n")
+
+	if err := os.WriteFile(filepath.Join(g.outputDir, filepath.FromSlash(g.feedPath())), []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", g.feedPath(), err)
+	}
+	result.Assets = append(result.Assets, g.feedPath())
+	return nil
+}
+
+// feedPath returns the output path of the RSS feed
+func (g *Generator) feedPath() string {
+	return g.blogDir + "/feed.xml"
+}
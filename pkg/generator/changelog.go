@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// ChangelogEntry is a single version shown on the changelog page
+type ChangelogEntry struct {
+	Version     string
+	Date        string
+	Anchor      string
+	URL         string
+	SummaryHTML string
+	Sections    []ChangelogEntrySection
+}
+
+// ChangelogEntrySection is a rendered group of changes, e.g. "Added"
+type ChangelogEntrySection struct {
+	Title string
+	Class string
+	HTML  string
+}
+
+// findChangelog returns the path of the repository's root changelog, if any
+func (g *Generator) findChangelog() string {
+	found := ""
+	for path := range g.markdown {
+		if filepath.Dir(path) != "." || !isChangelogFile(path) {
+			continue
+		}
+		// Prefer CHANGELOG over HISTORY/CHANGES when several exist
+		if found == "" || strings.HasPrefix(strings.ToLower(path), "changelog") {
+			found = path
+		}
+	}
+	return found
+}
+
+// generateChangelogPage creates changelog.html with a timeline of versions
+func (g *Generator) generateChangelogPage(docsPages []utils.DocPage) error {
+	cl := utils.ParseChangelog(g.markdown[g.changelogPath])
+
+	var entries []ChangelogEntry
+	for _, v := range cl.Versions {
+		entry := ChangelogEntry{
+			Version:     v.Version,
+			Date:        v.Date,
+			Anchor:      v.Anchor,
+			URL:         v.URL,
+			SummaryHTML: g.sanitizeContent(renderMarkdown(v.Summary)),
+		}
+		for _, s := range v.Sections {
+			entry.Sections = append(entry.Sections, ChangelogEntrySection{
+				Title: s.Title,
+				Class: "changelog-" + strings.ToLower(strings.Fields(s.Title + " other")[0]),
+				HTML:  g.sanitizeContent(renderMarkdown(s.Body)),
+			})
+		}
+		entries = append(entries, entry)
+	}
+
+	// Without recognizable versions, show the file as-is
+	content := cl.Preamble
+	if len(entries) == 0 {
+		content = g.markdown[g.changelogPath]
+	}
+
+	title := cl.Title
+	if title == "" {
+		title = g.message(g.defaultLang(), "Changelog")
+	}
+
+	page := g.newPage("changelog", "changelog.html", g.defaultLang(), docsPages)
+	page.Source = g.changelogPath
+	page.Data.Changelog = entries
+	page.Data.PageTitle = title + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = g.message(g.defaultLang(), "Changelog")
+	page.Data.PageContent = g.sanitizeContent(renderMarkdown(content))
+
+	return g.renderPage(page)
+}
+
+// isChangelogFile checks if a file is a CHANGELOG, HISTORY or CHANGES file
+func isChangelogFile(filename string) bool {
+	name := strings.ToLower(filepath.Base(filename))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return name == "changelog" || name == "history" || name == "changes"
+}
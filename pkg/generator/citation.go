@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// citationFile is the citation of the repository, at its root
+const citationFile = "CITATION.cff"
+
+// citationBibFile is the BibTeX export of the citation, at the site root
+const citationBibFile = "citation.bib"
+
+// CitationInfo is the "Cite this project" box of the home page
+type CitationInfo struct {
+	// Message asks users to cite the project, e.g. "If you use this
+	// software, please cite it as below."
+	Message string
+	// Text is the citation formatted in APA style
+	Text string
+	// BibTeX is the citation as a BibTeX entry, also written to BibPath
+	BibTeX  string
+	BibPath string
+}
+
+// loadCitation reads the repository's CITATION.cff, if it has one, and
+// writes its BibTeX export. A file that can't be parsed is logged and left
+// out, so it doesn't stop the site from being built.
+func (g *Generator) loadCitation(result *GenerationResult) error {
+	g.citation = nil
+	content, err := git.ReadFileWithin(g.repoData.Path, citationFile)
+	if err != nil {
+		return nil
+	}
+	c, err := utils.ParseCitation(string(content))
+	if err != nil {
+		g.logger.Warn("Ignoring invalid citation file", "path", citationFile, "error", err)
+		return nil
+	}
+
+	info := &CitationInfo{
+		Message: c.Message,
+		Text:    formatCitation(c),
+		BibTeX:  bibTeXEntry(c, g.repoData.Name),
+		BibPath: citationBibFile,
+	}
+	if err := os.WriteFile(filepath.Join(g.outputDir, citationBibFile), []byte(info.BibTeX), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", citationBibFile, err)
+	}
+	result.Assets = append(result.Assets, citationBibFile)
+	g.citation = info
+	return nil
+}
+
+// formatCitation formats a citation in APA style: Authors (Year). Title
+// (Version) [Computer software]. Journal. Link
+func formatCitation(c utils.Citation) string {
+	var names []string
+	for _, a := range c.Authors {
+		name := a.FamilyName()
+		if a.FamilyNames != "" {
+			if initials := nameInitials(a.GivenNames); initials != "" {
+				name += ", " + initials
+			}
+			if a.NameSuffix != "" {
+				name += ", " + a.NameSuffix
+			}
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	var b strings.Builder
+	switch len(names) {
+	case 0:
+	case 1:
+		b.WriteString(names[0] + " ")
+	case 2:
+		b.WriteString(names[0] + ", & " + names[1] + " ")
+	default:
+		b.WriteString(strings.Join(names[:len(names)-1], ", ") + ", & " + names[len(names)-1] + " ")
+	}
+	year := c.Year
+	if year == "" {
+		year = "n.d."
+	}
+	b.WriteString("(" + year + "). " + c.Title)
+
+	if c.Version != "" {
+		b.WriteString(" (Version " + c.Version + ")")
+	}
+	if c.Type == "software" {
+		b.WriteString(" [Computer software]")
+	}
+	b.WriteString(".")
+	if c.Journal != "" {
+		b.WriteString(" " + c.Journal + ".")
+	}
+	if c.DOI != "" {
+		b.WriteString(" https://doi.org/" + c.DOI)
+	} else if c.URL != "" {
+		b.WriteString(" " + c.URL)
+	}
+	return b.String()
+}
+
+// nameInitials returns the initials of given names, e.g. "J. R." for
+// "John Ronald"
+func nameInitials(given string) string {
+	var initials []string
+	for _, name := range strings.Fields(given) {
+		for _, r := range name {
+			initials = append(initials, string(r)+".")
+			break
+		}
+	}
+	return strings.Join(initials, " ")
+}
+
+// bibTeXEntry returns the citation as a BibTeX entry, keyed by the family
+// name of the first author and the year, or by the repository name
+func bibTeXEntry(c utils.Citation, repoName string) string {
+	entryType := "software"
+	if c.Type == "article" {
+		entryType = "article"
+	} else if c.Type != "software" {
+		entryType = "misc"
+	}
+
+	key := ""
+	if len(c.Authors) > 0 {
+		key = c.Authors[0].FamilyName()
+	}
+	if key == "" {
+		key = repoName
+	}
+	key = bibTeXKey(key + c.Year)
+
+	var authors []string
+	for _, a := range c.Authors {
+		switch {
+		case a.FamilyNames == "" && a.Name != "":
+			// Braces keep an organization's name from being split
+			authors = append(authors, "{"+bibTeXEscape(a.Name)+"}")
+		case a.FamilyNames != "":
+			name := bibTeXEscape(a.FamilyName())
+			if a.NameSuffix != "" {
+				name += ", " + bibTeXEscape(a.NameSuffix)
+			}
+			if a.GivenNames != "" {
+				name += ", " + bibTeXEscape(a.GivenNames)
+			}
+			authors = append(authors, name)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("@" + entryType + "{" + key + ",\n")
+	field := func(name, value string) {
+		if value != "" {
+			b.WriteString("  " + name + " = {" + value + "},\n")
+		}
+	}
+	field("author", strings.Join(authors, " and "))
+	field("title", "{"+bibTeXEscape(c.Title)+"}")
+	field("journal", bibTeXEscape(c.Journal))
+	field("year", c.Year)
+	field("version", bibTeXEscape(c.Version))
+	field("doi", c.DOI)
+	field("url", c.URL)
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// bibTeXKey keeps the letters and digits of a citation key
+func bibTeXKey(s string) string {
+	key := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+	if key == "" {
+		key = "citation"
+	}
+	return key
+}
+
+// bibTeXEscape escapes the characters with a special meaning in BibTeX
+var bibTeXEscape = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"&", `\&`,
+	"%", `\%`,
+	"$", `\$`,
+	"#", `\#`,
+	"_", `\_`,
+).Replace
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// CommunityLink is a link to the page of a community health file
+type CommunityLink struct {
+	Title string
+	URL   string
+}
+
+// communityPages are the pages generated for community health files, in the
+// order GitHub lists them, with the message key of their title
+var communityPages = []struct {
+	page    string
+	message string
+}{
+	{"code-of-conduct", "CodeOfConduct"},
+	{"contributing", "Contributing"},
+	{"security", "SecurityPolicy"},
+	{"support", "Support"},
+}
+
+// isCommunityFile reports whether a markdown file is a community health
+// file, which gets its own page instead of a documentation page
+func (g *Generator) isCommunityFile(path string) bool {
+	for _, p := range g.repoData.CommunityFiles {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// generateCommunityPages creates a page for each community health file,
+// such as contributing.html for CONTRIBUTING.md, and returns their paths
+func (g *Generator) generateCommunityPages(docsPages []utils.DocPage) ([]string, error) {
+	var generated []string
+	for _, c := range communityPages {
+		path, ok := g.repoData.CommunityFiles[c.page]
+		if !ok {
+			continue
+		}
+
+		outputPath := c.page + ".html"
+		heading := g.message(g.defaultLang(), c.message)
+		page := g.newPage("doc", outputPath, g.defaultLang(), docsPages)
+		page.Source = path
+		page.Data.LastModified = g.formatDate(g.repoData.FileHistory[path].LastModified)
+		page.Data.LastModifiedBy = g.repoData.FileHistory[path].LastAuthor
+		page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+		page.Data.PageHeading = heading
+		page.Data.PageContent = g.renderDocContent(path, g.markdown[path], page.Data.RootPath)
+
+		if err := g.renderPage(page); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", outputPath, err)
+		}
+		generated = append(generated, outputPath)
+	}
+	return generated, nil
+}
+
+// communityLinks returns the links to the community health file pages, for
+// the footer of a page at rootPath
+func (g *Generator) communityLinks(lang, rootPath string) []CommunityLink {
+	var links []CommunityLink
+	for _, c := range communityPages {
+		if _, ok := g.repoData.CommunityFiles[c.page]; ok {
+			links = append(links, CommunityLink{
+				Title: g.message(lang, c.message),
+				URL:   rootPath + c.page + ".html",
+			})
+		}
+	}
+	return links
+}
@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// Content API modes control the machine-readable description of the site
+const (
+	// JSONAPIIndex writes pages.json, listing every page
+	JSONAPIIndex = "index"
+	// JSONAPIPages also writes a .json document next to every page, with
+	// its content
+	JSONAPIPages = "pages"
+)
+
+// JSONAPIModes lists the supported content API modes
+var JSONAPIModes = []string{JSONAPIIndex, JSONAPIPages}
+
+// maxSummaryLength is the length in characters above which the summary of a
+// page is cut
+const maxSummaryLength = 300
+
+var (
+	paragraphRe     = regexp.MustCompile(`(?s)<p>(.*?)</p>`)
+	headingAnchorRe = regexp.MustCompile(` <a class="heading-anchor"[^>]*>#</a>`)
+)
+
+// ContentPage describes a page in pages.json
+type ContentPage struct {
+	Title string `json:"title"`
+	// Path is the path of the page relative to the site root
+	Path     string           `json:"path"`
+	Lang     string           `json:"lang,omitempty"`
+	Headings []ContentHeading `json:"headings"`
+	Summary  string           `json:"summary,omitempty"`
+	// LastModified is the time of the last commit to the page's source
+	LastModified string `json:"lastModified,omitempty"`
+	// Source is the path of the document in the repository, Raw the path of
+	// its markdown source on the site, if it is published
+	Source string `json:"source,omitempty"`
+	Raw    string `json:"raw,omitempty"`
+	// JSON is the path of the page's own JSON document
+	JSON string `json:"json,omitempty"`
+}
+
+// ContentHeading is a heading of a page, linked by its id
+type ContentHeading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// contentIndex is the content of pages.json
+type contentIndex struct {
+	Repository string        `json:"repository"`
+	URL        string        `json:"url"`
+	Generated  string        `json:"generated"`
+	Pages      []ContentPage `json:"pages"`
+}
+
+// contentDocument is the JSON document of a page, with its rendered content
+type contentDocument struct {
+	ContentPage
+	HTML string `json:"html"`
+}
+
+// SetJSONAPI writes a machine-readable description of the site for other
+// tools to consume, one of JSONAPIModes, or nothing if empty
+func (g *Generator) SetJSONAPI(mode string) {
+	g.jsonAPI = mode
+}
+
+// writeContentAPI writes pages.json describing the home pages and the
+// documentation pages, and with JSONAPIPages a JSON document next to each
+// of them. sources maps the output path of every page to its document.
+func (g *Generator) writeContentAPI(result *GenerationResult, sources map[string]string) error {
+	paths := make([]string, 0, len(sources))
+	for pagePath := range sources {
+		paths = append(paths, pagePath)
+	}
+	sort.Strings(paths)
+
+	index := contentIndex{
+		Repository: g.repoData.Owner + "/" + g.repoData.Name,
+		URL:        g.repoData.URL,
+		Generated:  g.now().Format(time.RFC3339),
+		Pages:      []ContentPage{},
+	}
+	for _, pagePath := range paths {
+		source := sources[pagePath]
+		content := g.renderDocContent(source, g.markdown[source], utils.RelativeRoot(pagePath))
+
+		page := ContentPage{
+			Title:    g.pageTitle(source),
+			Path:     pagePath,
+			Lang:     g.pageLanguage(source),
+			Headings: contentHeadings(content),
+			Summary:  g.frontMatter[source].Description,
+			Source:   filepath.ToSlash(source),
+		}
+		if strings.HasSuffix(pagePath, "index.html") && source == g.languageReadme(page.Lang) {
+			page.Title = g.repoData.Name
+		}
+		if page.Summary == "" {
+			page.Summary = contentSummary(content)
+		}
+		if modified := g.repoData.FileHistory[source].LastModified; !modified.IsZero() {
+			page.LastModified = modified.UTC().Format(time.RFC3339)
+		}
+		if g.hasRawMarkdown(source) {
+			page.Raw = rawOutputPath(pagePath)
+		}
+
+		if g.jsonAPI == JSONAPIPages {
+			page.JSON = strings.TrimSuffix(pagePath, ".html") + ".json"
+			if err := g.writeJSON(page.JSON, contentDocument{ContentPage: page, HTML: content}); err != nil {
+				return err
+			}
+			result.Assets = append(result.Assets, page.JSON)
+		}
+		index.Pages = append(index.Pages, page)
+	}
+
+	if err := g.writeJSON("pages.json", index); err != nil {
+		return err
+	}
+	result.Assets = append(result.Assets, "pages.json")
+	return nil
+}
+
+// writeJSON writes a value as indented JSON to a path of the output
+func (g *Generator) writeJSON(outputPath string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", outputPath, err)
+	}
+	fullPath := filepath.Join(g.outputDir, filepath.FromSlash(outputPath))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+	}
+	if err := os.WriteFile(fullPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+// contentHeadings returns the headings of rendered content that have an id
+func contentHeadings(content string) []ContentHeading {
+	headings := []ContentHeading{}
+	for _, m := range headingRe.FindAllStringSubmatch(content, -1) {
+		headings = append(headings, ContentHeading{
+			Level: int(m[1][0] - '0'),
+			ID:    m[2],
+			Text:  plainText(headingAnchorRe.ReplaceAllString(m[3], "")),
+		})
+	}
+	return headings
+}
+
+// contentSummary returns the text of the first paragraph of rendered
+// content, cut at a word boundary if it is long
+func contentSummary(content string) string {
+	for _, m := range paragraphRe.FindAllStringSubmatch(content, -1) {
+		text := plainText(m[1])
+		if text == "" {
+			continue
+		}
+		runes := []rune(text)
+		if len(runes) <= maxSummaryLength {
+			return text
+		}
+		cut := string(runes[:maxSummaryLength])
+		if i := strings.LastIndexByte(cut, ' '); i > 0 {
+			cut = cut[:i]
+		}
+		return cut + "…"
+	}
+	return ""
+}
+
+// plainText returns the text of an HTML fragment, with whitespace collapsed
+func plainText(fragment string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagRe.ReplaceAllString(fragment, ""))), " ")
+}
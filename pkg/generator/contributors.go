@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"regexp"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// ContributorEntry is a contributor listed on the contributors page
+type ContributorEntry struct {
+	Name      string
+	Login     string
+	AvatarURL string
+	// URL is the contributor's GitHub profile, if known
+	URL     string
+	Commits int
+	First   string
+	Last    string
+}
+
+// noreplyEmailRe matches GitHub's private commit emails, which contain the
+// account name: 12345+login@users.noreply.github.com
+var noreplyEmailRe = regexp.MustCompile(`^(?:\d+\+)?([A-Za-z0-9-]+)@users\.noreply\.github\.com$`)
+
+// generateContributorsPage creates a page listing every contributor with
+// their commit count and the dates of their first and latest commit
+func (g *Generator) generateContributorsPage(docsPages []utils.DocPage) error {
+	var entries []ContributorEntry
+	for _, c := range g.withAccounts(g.repoData.AllContributors) {
+		entry := ContributorEntry{
+			Name:      c.Name,
+			Login:     c.Login,
+			AvatarURL: c.AvatarURL,
+			URL:       c.ProfileURL,
+			Commits:   c.Commits,
+			First:     g.formatDate(c.FirstCommit),
+			Last:      g.formatDate(c.LastCommit),
+		}
+		// Contributors outside the top ones are only resolved from their email
+		if entry.URL == "" {
+			if m := noreplyEmailRe.FindStringSubmatch(c.Email); m != nil {
+				entry.Login = m[1]
+				entry.URL = "https://github.com/" + m[1]
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	heading := g.message(g.defaultLang(), "AllContributors")
+	page := g.newPage("contributors", "contributors.html", g.defaultLang(), docsPages)
+	page.Data.AllContributors = entries
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+
+	return g.renderPage(page)
+}
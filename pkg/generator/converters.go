@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Converter renders a documentation format other than markdown to an HTML fragment
+type Converter interface {
+	Convert(content string) (string, error)
+}
+
+// CommandConverter converts documents by piping them through an external
+// tool that reads the source on stdin and writes HTML to stdout
+type CommandConverter struct {
+	Command []string
+}
+
+// Convert runs the command over the document content
+func (c CommandConverter) Convert(content string) (string, error) {
+	if len(c.Command) == 0 {
+		return "", fmt.Errorf("no converter command configured")
+	}
+
+	cmd := exec.Command(c.Command[0], c.Command[1:]...)
+	if cmd.Err != nil {
+		return "", fmt.Errorf("converter not installed: %w", cmd.Err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", c.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// TextConverter renders plain text as preformatted HTML
+type TextConverter struct{}
+
+// Convert escapes the text and wraps it in a <pre> block
+func (TextConverter) Convert(content string) (string, error) {
+	return `<pre class="plain-text">` + html.EscapeString(content) + "</pre>", nil
+}
+
+// converters maps document extensions to the converter that renders them
+var converters = map[string]Converter{
+	".adoc":     CommandConverter{Command: []string{"asciidoctor", "-s", "-a", "showtitle", "-o", "-", "-"}},
+	".asciidoc": CommandConverter{Command: []string{"asciidoctor", "-s", "-a", "showtitle", "-o", "-", "-"}},
+	".rst":      CommandConverter{Command: []string{"pandoc", "--from", "rst", "--to", "html"}},
+	".org":      CommandConverter{Command: []string{"pandoc", "--from", "org", "--to", "html"}},
+	".txt":      TextConverter{},
+}
+
+// DefaultDocFormats are the document extensions, besides markdown, rendered by default.
+// Plain text is opt-in, as repositories contain many .txt files that are not documentation.
+var DefaultDocFormats = []string{".adoc", ".asciidoc", ".rst", ".org"}
+
+// RegisterConverter sets the converter for documents with the given extension,
+// replacing any existing one
+func RegisterConverter(ext string, c Converter) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	converters[ext] = c
+}
+
+// ConverterExtensions returns the sorted extensions that have a converter
+func ConverterExtensions() []string {
+	var exts []string
+	for ext := range converters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// HasConverter reports whether documents with the given extension can be rendered
+func HasConverter(ext string) bool {
+	_, ok := converters[strings.ToLower(ext)]
+	return ok
+}
+
+var (
+	htmlTitleRe = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	htmlTagRe   = regexp.MustCompile(`<[^>]+>`)
+	hrefAttrRe  = regexp.MustCompile(`(?i)\bhref\s*=\s*"([^"]*)"`)
+	srcAttrRe   = regexp.MustCompile(`(?i)\bsrc\s*=\s*"([^"]*)"`)
+)
+
+// convertDocument renders a non-markdown document with its registered
+// converter. If the converter fails, the source is shown as preformatted text.
+func (g *Generator) convertDocument(path, content string) string {
+	converter, ok := converters[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "<pre>" + html.EscapeString(content) + "</pre>"
+	}
+
+	converted, err := converter.Convert(content)
+	if err != nil {
+		g.logger.Warn("Failed to convert document, showing it as plain text", "path", path, "error", err)
+		return "<pre>" + html.EscapeString(content) + "</pre>"
+	}
+
+	return converted
+}
+
+// rewriteHTMLLinks passes the href of every link of an HTML fragment through
+// rewrite
+func rewriteHTMLLinks(content string, rewrite func(dest string) string) string {
+	return hrefAttrRe.ReplaceAllStringFunc(content, func(attr string) string {
+		target := html.UnescapeString(hrefAttrRe.FindStringSubmatch(attr)[1])
+		link := rewrite(target)
+		if link == target {
+			return attr
+		}
+		return `href="` + html.EscapeString(link) + `"`
+	})
+}
+
+// processConvertedLinks points links to other documents at their generated
+// pages and relative images at the local images directory, at imagesURL
+func processConvertedLinks(content, imagesURL string) string {
+	content = hrefAttrRe.ReplaceAllStringFunc(content, func(attr string) string {
+		target := hrefAttrRe.FindStringSubmatch(attr)[1]
+		if strings.Contains(target, ":") || strings.HasPrefix(target, "#") {
+			return attr
+		}
+
+		file, anchor, hasAnchor := strings.Cut(target, "#")
+		ext := strings.ToLower(path.Ext(file))
+		if _, ok := converters[ext]; !ok && ext != ".md" && ext != ".markdown" {
+			return attr
+		}
+
+		link := strings.TrimSuffix(file, path.Ext(file)) + ".html"
+		if hasAnchor {
+			link += "#" + anchor
+		}
+		return `href="` + link + `"`
+	})
+
+	return rewriteHTMLImages(content, func(src string) string {
+		if strings.Contains(src, ":") {
+			return src
+		}
+		return imagesURL + path.Base(src)
+	})
+}
+
+// titleFromHTML returns the text of the first <h1> in an HTML fragment
+func titleFromHTML(content string) string {
+	m := htmlTitleRe.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(m[1], "")))
+}
@@ -0,0 +1,317 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CSPMeta adds the policy to every page in a <meta http-equiv> tag
+	CSPMeta = "meta"
+	// CSPHeaders writes the policy to a _headers file, read by Netlify,
+	// Cloudflare Pages and similar hosts
+	CSPHeaders = "headers"
+)
+
+// CSPModes lists the supported ways of publishing the content security policy
+var CSPModes = []string{CSPMeta, CSPHeaders}
+
+// maxSubresourceSize is the largest script or stylesheet downloaded to compute its hash
+const maxSubresourceSize = 5 * 1024 * 1024
+
+// unpinnedScripts are updated in place by their provider, so an integrity
+// hash would break them on the next update
+var unpinnedScripts = map[string]bool{plausibleScript: true, goatcounterScript: true}
+
+// cspDirectives are the fetch directives of the policy, in the order they are written
+var cspDirectives = []string{"default-src", "script-src", "style-src", "img-src", "font-src", "media-src", "frame-src", "connect-src"}
+
+var (
+	scriptTagRe    = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+	stylesheetRe   = regexp.MustCompile(`(?i)<link\s[^>]*\brel="stylesheet"[^>]*>`)
+	frameTagRe     = regexp.MustCompile(`(?i)<iframe\s[^>]*>`)
+	mediaTagRe     = regexp.MustCompile(`(?i)<(?:video|audio)\s[^>]*>`)
+	integrityRe    = regexp.MustCompile(`(?i)\sintegrity\s*=`)
+	eventHandlerRe = regexp.MustCompile(`(?i)<[a-z][^>]*\son[a-z]+\s*=`)
+	scriptURLRe    = regexp.MustCompile(`(?i)\bhref\s*=\s*["']?\s*javascript:`)
+	inlineStyleRe  = regexp.MustCompile(`(?i)<[a-z][^>]*\sstyle\s*=|<style[\s>]|class="math `)
+	headTagRe      = regexp.MustCompile(`(?i)<head(?:\s[^>]*)?>(\s*<meta charset="[^"]*">)?`)
+)
+
+// SetCSP enables a content security policy, published as one of CSPModes,
+// and subresource integrity hashes for scripts and stylesheets. In strict
+// mode, the policy allows no inline scripts, and pages with inline scripts,
+// event handlers or javascript: links fail the generation; otherwise inline
+// scripts are allowed by their hash.
+func (g *Generator) SetCSP(mode string, strict bool) {
+	g.cspMode = mode
+	g.strictCSP = strict
+}
+
+// cspPolicy collects the sources allowed by each directive
+type cspPolicy map[string]map[string]bool
+
+func (p cspPolicy) add(directive string, sources ...string) {
+	if p[directive] == nil {
+		p[directive] = make(map[string]bool)
+	}
+	for _, source := range sources {
+		p[directive][source] = true
+	}
+}
+
+// String formats the policy, with extra directives that have no sources to collect
+func (p cspPolicy) String(extra ...string) string {
+	var parts []string
+	for _, directive := range cspDirectives {
+		var sources []string
+		for source := range p[directive] {
+			sources = append(sources, source)
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		// Keywords and hashes first, then schemes and hosts
+		sort.Slice(sources, func(i, j int) bool {
+			qi, qj := strings.HasPrefix(sources[i], "'"), strings.HasPrefix(sources[j], "'")
+			if qi != qj {
+				return qi
+			}
+			return sources[i] < sources[j]
+		})
+		parts = append(parts, directive+" "+strings.Join(sources, " "))
+	}
+	parts = append(parts, "object-src 'none'", "base-uri 'self'", "form-action 'self'")
+	parts = append(parts, extra...)
+	return strings.Join(parts, "; ")
+}
+
+// applyCSP adds integrity hashes to the scripts and stylesheets of the
+// generated pages, builds a policy allowing what the pages load, and
+// publishes it in the pages or in a _headers file
+func (g *Generator) applyCSP(result *GenerationResult) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	integrity := make(map[string]string)
+	policy := cspPolicy{}
+	policy.add("default-src", "'self'")
+	policy.add("script-src", "'self'")
+	policy.add("style-src", "'self'")
+	policy.add("img-src", "'self'", "data:")
+	policy.add("connect-src", "'self'")
+
+	pages := make(map[string]string)
+	var violations []string
+	for _, page := range result.Pages {
+		content, err := os.ReadFile(filepath.Join(g.outputDir, page))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page, err)
+		}
+		html := g.addIntegrity(string(content), page, client, integrity)
+		pages[page] = html
+
+		// Scripts, and the services they report to
+		for _, m := range scriptTagRe.FindAllStringSubmatch(html, -1) {
+			if src := srcAttrRe.FindStringSubmatch(m[1]); src != nil {
+				if origin := sourceOrigin(src[1]); origin != "'self'" {
+					policy.add("script-src", origin)
+					policy.add("connect-src", origin)
+					policy.add("img-src", origin)
+				}
+				continue
+			}
+			if strings.TrimSpace(m[2]) == "" {
+				continue
+			}
+			if g.strictCSP {
+				violations = append(violations, page+" (inline script)")
+				continue
+			}
+			sum := sha256.Sum256([]byte(m[2]))
+			policy.add("script-src", "'sha256-"+base64.StdEncoding.EncodeToString(sum[:])+"'")
+		}
+		if eventHandlerRe.MatchString(html) || scriptURLRe.MatchString(html) {
+			if g.strictCSP {
+				violations = append(violations, page+" (inline event handler or javascript: link)")
+			} else {
+				g.logger.Warn("Inline event handlers and javascript: links are blocked by the content security policy", "page", page)
+			}
+		}
+
+		// Stylesheets, and the fonts they load
+		for _, tag := range stylesheetRe.FindAllString(html, -1) {
+			if href := hrefAttrRe.FindStringSubmatch(tag); href != nil {
+				origin := sourceOrigin(href[1])
+				policy.add("style-src", origin)
+				policy.add("font-src", origin)
+			}
+		}
+		if inlineStyleRe.MatchString(html) {
+			// Set by templates and by the math engines on the rendered formulas
+			policy.add("style-src", "'unsafe-inline'")
+		}
+
+		for _, tag := range imgTagRe.FindAllString(html, -1) {
+			if src := srcAttrRe.FindStringSubmatch(tag); src != nil {
+				policy.add("img-src", sourceOrigin(src[1]))
+			}
+			if srcset := srcsetRe.FindStringSubmatch(tag); srcset != nil {
+				for _, link := range srcsetURLs(srcset[1]) {
+					policy.add("img-src", sourceOrigin(link))
+				}
+			}
+		}
+		for _, tag := range frameTagRe.FindAllString(html, -1) {
+			if src := srcAttrRe.FindStringSubmatch(tag); src != nil {
+				policy.add("frame-src", sourceOrigin(src[1]))
+			}
+		}
+		for _, tag := range mediaTagRe.FindAllString(html, -1) {
+			if src := srcAttrRe.FindStringSubmatch(tag); src != nil {
+				policy.add("media-src", sourceOrigin(src[1]))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		if len(violations) > 5 {
+			violations = append(violations[:5], fmt.Sprintf("and %d more", len(violations)-5))
+		}
+		return fmt.Errorf("pages violate the strict content security policy: %s", strings.Join(violations, ", "))
+	}
+	if len(policy["frame-src"]) == 0 {
+		policy.add("frame-src", "'none'")
+	}
+
+	// frame-ancestors is ignored in <meta> tags, so it only goes in the header
+	switch g.cspMode {
+	case CSPMeta:
+		meta := `<meta http-equiv="Content-Security-Policy" content="` + policy.String() + `">`
+		for page, html := range pages {
+			if loc := headTagRe.FindStringIndex(html); loc != nil {
+				html = html[:loc[1]] + "\n  " + meta + html[loc[1]:]
+			}
+			pages[page] = html
+		}
+	case CSPHeaders:
+		headers := "/*\n  Content-Security-Policy: " + policy.String("frame-ancestors 'self'") + "\n"
+		if err := os.WriteFile(filepath.Join(g.outputDir, "_headers"), []byte(headers), 0o644); err != nil {
+			return fmt.Errorf("failed to write _headers: %w", err)
+		}
+		result.Assets = append(result.Assets, "_headers")
+	}
+
+	for page, html := range pages {
+		if err := os.WriteFile(filepath.Join(g.outputDir, page), []byte(html), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", page, err)
+		}
+	}
+	return nil
+}
+
+// addIntegrity adds integrity hashes to the scripts and stylesheets of a page,
+// except scripts updated in place by their provider. Hashes are cached by URL.
+func (g *Generator) addIntegrity(html, page string, client *http.Client, cache map[string]string) string {
+	tag := func(tag, link string) string {
+		if integrityRe.MatchString(tag) || unpinnedScripts[link] || strings.HasPrefix(link, "data:") {
+			return tag
+		}
+		hash, ok := cache[link]
+		if !ok {
+			data, err := g.subresource(link, page, client)
+			if err != nil {
+				g.logger.Warn("Failed to compute integrity hash", "url", link, "error", err)
+			} else {
+				sum := sha512.Sum384(data)
+				hash = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+			}
+			cache[link] = hash
+		}
+		if hash == "" {
+			return tag
+		}
+		attrs := ` integrity="` + hash + `"`
+		if sourceOrigin(link) != "'self'" {
+			attrs += ` crossorigin="anonymous"`
+		}
+		end := strings.LastIndex(tag, ">")
+		if strings.HasSuffix(tag[:end], "/") {
+			end--
+		}
+		return tag[:end] + attrs + tag[end:]
+	}
+
+	html = scriptTagRe.ReplaceAllStringFunc(html, func(script string) string {
+		open := script[:strings.Index(script, ">")+1]
+		src := srcAttrRe.FindStringSubmatch(open)
+		if src == nil {
+			return script
+		}
+		return tag(open, src[1]) + script[len(open):]
+	})
+	return stylesheetRe.ReplaceAllStringFunc(html, func(link string) string {
+		href := hrefAttrRe.FindStringSubmatch(link)
+		if href == nil {
+			return link
+		}
+		return tag(link, href[1])
+	})
+}
+
+// subresource returns the content of a script or stylesheet, downloading it
+// or reading it from the output directory
+func (g *Generator) subresource(link, page string, client *http.Client) ([]byte, error) {
+	if sourceOrigin(link) != "'self'" {
+		if strings.HasPrefix(link, "//") {
+			link = "https:" + link
+		}
+		resp, err := client.Get(link)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxSubresourceSize))
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	local := u.Path
+	if !strings.HasPrefix(local, "/") {
+		local = path.Join(path.Dir(page), local)
+	}
+	return os.ReadFile(filepath.Join(g.outputDir, filepath.FromSlash(strings.TrimPrefix(local, "/"))))
+}
+
+// sourceOrigin returns the CSP source of a URL: 'self' for relative URLs,
+// the scheme for data: URLs and the origin of absolute URLs
+func sourceOrigin(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		if err == nil && u.Scheme != "" {
+			return strings.ToLower(u.Scheme) + ":"
+		}
+		return "'self'"
+	}
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return strings.ToLower(scheme) + "://" + strings.ToLower(u.Host)
+}
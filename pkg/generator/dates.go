@@ -0,0 +1,46 @@
+package generator
+
+import "time"
+
+// DefaultDateFormat is the layout of dates on the site unless configured
+// otherwise, ISO 8601
+const DefaultDateFormat = "2006-01-02"
+
+// dateFormatNames are the names accepted for common date layouts
+var dateFormatNames = map[string]string{
+	"iso": "2006-01-02",
+	"us":  "January 2, 2006",
+	"eu":  "2 January 2006",
+}
+
+// SetDateFormat sets the Go layout of the dates shown on the site, or one of
+// iso, us and eu, and the time zone they are shown in. An empty layout or a
+// nil location keeps the default, ISO 8601 dates in UTC.
+func (g *Generator) SetDateFormat(layout string, loc *time.Location) {
+	if named, ok := dateFormatNames[layout]; ok {
+		layout = named
+	}
+	if layout == "" {
+		layout = DefaultDateFormat
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	g.dateLayout = layout
+	g.location = loc
+}
+
+// formatDate formats a date for display, or returns an empty string for the
+// zero time
+func (g *Generator) formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(g.location).Format(g.dateLayout)
+}
+
+// formatDateTime formats a date and time for display, such as the time the
+// site was generated
+func (g *Generator) formatDateTime(t time.Time) string {
+	return t.In(g.location).Format(g.dateLayout + " 15:04:05 MST")
+}
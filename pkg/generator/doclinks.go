@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// markdownExtensions are the extensions of markdown documents
+var markdownExtensions = []string{".md", ".markdown", ".mdown", ".mkdn"}
+
+// isDocumentExt reports whether an extension is that of a document, in
+// markdown or a format with a converter
+func isDocumentExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, e := range markdownExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return HasConverter(ext)
+}
+
+// indexDocuments indexes the documents by their path without extension, in
+// lower case, so links can be resolved whatever their case and extension
+func (g *Generator) indexDocuments() {
+	g.documentIndex = make(map[string]string)
+	for doc := range g.markdown {
+		key := strings.ToLower(stem(doc))
+		// Of documents differing only in case or extension, the first in
+		// sort order wins, so builds are reproducible
+		if other, ok := g.documentIndex[key]; !ok || filepath.ToSlash(doc) < filepath.ToSlash(other) {
+			g.documentIndex[key] = doc
+		}
+	}
+}
+
+// findDocument returns the document a path of the repository, with forward
+// slashes, points at. Paths differing in case, such as Docs/Setup.MD, or in
+// the markdown extension find the document as GitHub would on a
+// case-insensitive file system.
+func (g *Generator) findDocument(target string) (string, bool) {
+	doc := filepath.FromSlash(target)
+	if _, ok := g.markdown[doc]; ok {
+		return doc, true
+	}
+	if !isDocumentExt(path.Ext(target)) {
+		return "", false
+	}
+	doc, ok := g.documentIndex[strings.ToLower(stem(target))]
+	return doc, ok
+}
+
+// linkedDocument returns the document a relative link of a document points
+// at, if it is one of the documents
+func (g *Generator) linkedDocument(source, dest string) (string, bool) {
+	if strings.Contains(dest, ":") || strings.HasPrefix(dest, "#") {
+		return "", false
+	}
+	target, _, _ := strings.Cut(dest, "#")
+	return g.findDocument(resolveRepoPath(source, target))
+}
+
+// rootLink resolves a relative link of a document, which isn't to another
+// document, for a page at the site root
+func rootLink(source, dest string) string {
+	if dest == "" || strings.Contains(dest, ":") || strings.HasPrefix(dest, "#") {
+		return dest
+	}
+	if strings.HasPrefix(dest, "/") {
+		return strings.TrimPrefix(dest, "/")
+	}
+	return path.Join(path.Dir(filepath.ToSlash(source)), dest)
+}
+
+// checkDocumentLinks warns about links of the documents to other documents
+// that don't exist in the repository. Every page showing a document, on its
+// own or combined with others, resolves its links the same way.
+func (g *Generator) checkDocumentLinks(result *GenerationResult) {
+	g.indexDocuments()
+
+	var docs []string
+	for doc := range g.markdown {
+		if !g.wiki[doc] {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Strings(docs)
+
+	for _, doc := range docs {
+		check := func(dest string) string {
+			if strings.Contains(dest, ":") || strings.HasPrefix(dest, "#") {
+				return dest
+			}
+			target, _, _ := strings.Cut(dest, "#")
+			target, _, _ = strings.Cut(target, "?")
+			if !isDocumentExt(path.Ext(target)) {
+				return dest
+			}
+			if _, ok := g.findDocument(resolveRepoPath(doc, target)); !ok {
+				g.warn(result, fmt.Sprintf("Link to a missing document in %s: %s", filepath.ToSlash(doc), dest))
+			}
+			return dest
+		}
+
+		// Documents in other formats are checked in the HTML they were
+		// converted to
+		if converted, ok := g.converted[doc]; ok {
+			rewriteHTMLLinks(converted, check)
+			continue
+		}
+		p := parser.NewWithExtensions(parser.CommonExtensions &^ parser.MathJax)
+		utils.RewriteLinks(p.Parse([]byte(g.markdown[doc])), func(dest string, image bool) string {
+			if image {
+				return dest
+			}
+			return check(dest)
+		})
+	}
+}
+
+// resolveRepoPath returns the path in the repository, with forward slashes,
+// of a link target of a document: relative to the document, or with a
+// leading slash to the repository root
+func resolveRepoPath(doc, target string) string {
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(strings.TrimPrefix(target, "/"))
+	}
+	return path.Join(path.Dir(filepath.ToSlash(doc)), target)
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SetCNAME publishes the site on a custom domain: a CNAME file is written
+// for GitHub Pages, and pages get canonical URLs and Open Graph tags on the
+// domain and are listed in sitemap.xml
+func (g *Generator) SetCNAME(domain string) {
+	g.cname = domain
+}
+
+// siteURL returns the public URL of the site root on its custom domain. The
+// sites of a versioned documentation are in a directory per version.
+func (g *Generator) siteURL() string {
+	if len(g.versions) > 0 {
+		return "https://" + g.cname + "/" + g.version + "/"
+	}
+	return "https://" + g.cname + "/"
+}
+
+// SetBaseURL sets the public URL of the site, for links that must be
+// absolute when the site isn't served from its custom domain or GitHub Pages
+func (g *Generator) SetBaseURL(baseURL string) {
+	g.baseURL = baseURL
+}
+
+// publicURL returns the public URL of the site root: the base URL, the custom
+// domain, or else the GitHub Pages address of the repository
+func (g *Generator) publicURL() string {
+	if g.cname != "" && g.baseURL == "" {
+		return g.siteURL()
+	}
+	root := strings.TrimSuffix(g.baseURL, "/") + "/"
+	if g.baseURL == "" {
+		root = "https://" + strings.ToLower(g.repoData.Owner) + ".github.io/"
+		// User and organization sites are served from the root
+		if !strings.EqualFold(g.repoData.Name, g.repoData.Owner+".github.io") {
+			root += g.repoData.Name + "/"
+		}
+	}
+	if len(g.versions) > 0 {
+		root += g.version + "/"
+	}
+	return root
+}
+
+// WriteCNAME writes the CNAME file GitHub Pages reads the custom domain from
+func WriteCNAME(outputDir, domain string) error {
+	if err := os.WriteFile(filepath.Join(outputDir, "CNAME"), []byte(domain+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write CNAME: %w", err)
+	}
+	return nil
+}
+
+// writeDomainFiles writes the CNAME file, unless the site is one version of a
+// versioned documentation, and sitemap.xml
+func (g *Generator) writeDomainFiles(result *GenerationResult) error {
+	if len(g.versions) == 0 {
+		if err := WriteCNAME(g.outputDir, g.cname); err != nil {
+			return err
+		}
+		result.Assets = append(result.Assets, "CNAME")
+	}
+
+	// Pages kept out of search engines aren't listed
+	noindex := make(map[string]bool)
+	for path := range g.markdown {
+		if g.frontMatter[path].NoIndex || matchPath(g.noindex, path) {
+			noindex[g.outputPage(g.docOutputPath(path))] = true
+		}
+	}
+
+	var pages []string
+	for _, page := range result.Pages {
+		if !noindex[page] {
+			pages = append(pages, page)
+		}
+	}
+	sort.Strings(pages)
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, page := range pages {
+		loc := g.siteURL() + strings.TrimSuffix(page, "index.html")
+		b.WriteString("  <url><loc>" + html.EscapeString(loc) + "</loc></url>\n")
+	}
+	b.WriteString("</urlset>\n")
+
+	if err := os.WriteFile(filepath.Join(g.outputDir, "sitemap.xml"), []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write sitemap.xml: %w", err)
+	}
+	result.Assets = append(result.Assets, "sitemap.xml")
+	return nil
+}
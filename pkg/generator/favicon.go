@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Favicon is an icon referenced from the page head
+type Favicon struct {
+	Rel   string
+	Sizes string
+	Type  string
+	Path  string
+}
+
+// faviconSizes are the PNG icons generated from a raster logo
+var faviconSizes = []struct {
+	Rel  string
+	Size int
+	Name string
+}{
+	{"icon", 16, "favicon-16x16.png"},
+	{"icon", 32, "favicon-32x32.png"},
+	{"apple-touch-icon", 180, "apple-touch-icon.png"},
+}
+
+// generateLogo copies the repository logo to the output directory and
+// generates favicons from it
+func (g *Generator) generateLogo(result *GenerationResult) error {
+	if g.repoData.LogoFile == "" {
+		return nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(g.repoData.LogoFile))
+	g.logoPath = "logo" + ext
+	if err := copyFile(g.repoData.LogoFile, filepath.Join(g.outputDir, g.logoPath)); err != nil {
+		return fmt.Errorf("failed to copy logo: %w", err)
+	}
+	result.Assets = append(result.Assets, g.logoPath)
+
+	switch ext {
+	case ".svg":
+		g.favicons = append(g.favicons, Favicon{Rel: "icon", Type: "image/svg+xml", Path: g.logoPath})
+		return nil
+	case ".ico":
+		g.favicons = append(g.favicons, Favicon{Rel: "icon", Type: "image/x-icon", Path: g.logoPath})
+		return nil
+	}
+
+	img, err := decodeImage(g.repoData.LogoFile)
+	if err != nil {
+		// Formats we can't decode are still usable as an icon as-is
+		g.logger.Warn("Could not decode logo, using it as favicon without resizing", "path", g.repoData.LogoFile, "error", err)
+		g.favicons = append(g.favicons, Favicon{Rel: "icon", Path: g.logoPath})
+		return nil
+	}
+
+	for _, size := range faviconSizes {
+		if err := writeSquare(img, size.Size, filepath.Join(g.outputDir, size.Name)); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", size.Name, err)
+		}
+		g.favicons = append(g.favicons, Favicon{
+			Rel:   size.Rel,
+			Sizes: fmt.Sprintf("%dx%d", size.Size, size.Size),
+			Type:  "image/png",
+			Path:  size.Name,
+		})
+		result.Assets = append(result.Assets, size.Name)
+	}
+
+	return nil
+}
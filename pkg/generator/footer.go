@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Footer configures the footer of every page
+type Footer struct {
+	// Copyright is the copyright line, e.g. "© {year} The go-i2p Authors";
+	// {year} is replaced with the year the site is built
+	Copyright string
+	// DocsLicense is the license of the documentation, as an SPDX
+	// identifier such as CC-BY-SA-4.0 or a name
+	DocsLicense string
+	// Links are added to the links of the footer
+	Links []FooterLink
+	// HideGeneratedOn leaves out the date the site was generated
+	HideGeneratedOn bool
+}
+
+// FooterLink is a link in the footer. URLs without a scheme are relative to
+// the site root.
+type FooterLink struct {
+	Title string
+	URL   string
+}
+
+// DocsLicense is the license of the documentation, linked to its text when
+// it is known
+type DocsLicense struct {
+	Name string
+	URL  string
+}
+
+// SetFooter configures the copyright line, documentation license and links
+// of the footer
+func (g *Generator) SetFooter(footer Footer) {
+	g.footer = footer
+}
+
+// creativeCommonsRe matches the SPDX identifier of a Creative Commons
+// license, e.g. CC-BY-SA-4.0
+var creativeCommonsRe = regexp.MustCompile(`(?i)^CC-(BY(?:-NC)?(?:-SA|-ND)?)-(\d\.\d)$`)
+
+// docsLicense returns the license of the documentation, or nil without one
+func (g *Generator) docsLicense() *DocsLicense {
+	id := strings.TrimSpace(g.footer.DocsLicense)
+	switch {
+	case id == "":
+		return nil
+	case strings.EqualFold(id, "CC0-1.0"):
+		return &DocsLicense{Name: "CC0 1.0", URL: "https://creativecommons.org/publicdomain/zero/1.0/"}
+	case strings.HasPrefix(strings.ToUpper(id), "GFDL-1.3"):
+		return &DocsLicense{Name: "GNU FDL 1.3", URL: "https://www.gnu.org/licenses/fdl-1.3.html"}
+	}
+	if m := creativeCommonsRe.FindStringSubmatch(id); m != nil {
+		terms := strings.ToUpper(m[1])
+		return &DocsLicense{
+			Name: "CC " + terms + " " + m[2],
+			URL:  "https://creativecommons.org/licenses/" + strings.ToLower(terms) + "/" + m[2] + "/",
+		}
+	}
+	return &DocsLicense{Name: id}
+}
+
+// copyright returns the copyright line with the year the site is built
+func (g *Generator) copyright() string {
+	return strings.ReplaceAll(g.footer.Copyright, "{year}", strconv.Itoa(g.now().Year()))
+}
+
+// footerLinks returns the configured footer links for a page at rootPath
+func (g *Generator) footerLinks(rootPath string) []FooterLink {
+	var links []FooterLink
+	for _, link := range g.footer.Links {
+		if !strings.Contains(link.URL, ":") && !strings.HasPrefix(link.URL, "#") {
+			link.URL = relURL(rootPath, link.URL)
+		}
+		links = append(links, link)
+	}
+	return links
+}
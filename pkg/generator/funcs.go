@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// templateFuncs returns the helper functions available to all templates.
+// dateFormat also parses dates in the layout of the site, dateLayout, and url
+// drops unsafe URLs when sanitize is set.
+func templateFuncs(dateLayout string, sanitize bool) template.FuncMap {
+	formatDate := func(layout string, value interface{}) (string, error) {
+		return dateFormat(layout, value, dateLayout)
+	}
+	escapeURL := func(u string) string {
+		return templateURL(u, sanitize)
+	}
+	return template.FuncMap{
+		"url":         escapeURL,
+		"safeHTML":    func(s string) string { return s },
+		"markdownify": renderMarkdown,
+		"dateFormat":  formatDate,
+		"upper":       strings.ToUpper,
+		"lower":       strings.ToLower,
+		"trim":        strings.TrimSpace,
+		"join":        strings.Join,
+		"contains":    strings.Contains,
+		"replace":     strings.ReplaceAll,
+		"relURL":      relURL,
+		"first":       first,
+		"last":        last,
+		"after":       after,
+		"initial":     initial,
+	}
+}
+
+// dateFormat formats a time.Time, or a date string in the layout of the
+// site or one of the formats used by the templates, with a Go layout
+func dateFormat(layout string, value interface{}, siteLayout string) (string, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		for _, format := range []string{siteLayout, siteLayout + " 15:04:05 MST", time.RFC3339, "2006-01-02 15:04:05", "January 2, 2006", "2006-01-02"} {
+			if t, err := time.Parse(format, v); err == nil {
+				return t.Format(layout), nil
+			}
+		}
+		return "", fmt.Errorf("dateFormat: cannot parse date %q", v)
+	default:
+		return "", fmt.Errorf("dateFormat: unsupported value of type %T", value)
+	}
+}
+
+// templateURL escapes a URL for an attribute. When sanitizing, URLs that
+// aren't relative or http, https or mailto, such as javascript: URLs from the
+// repository's configuration, are dropped.
+func templateURL(u string, sanitize bool) string {
+	if sanitize && !safeURL(u) {
+		return ""
+	}
+	return html.EscapeString(u)
+}
+
+// relURL joins a page's root path and a site-relative path,
+// e.g. {{relURL .RootPath "images/logo.png"}}
+func relURL(rootPath, path string) string {
+	return rootPath + strings.TrimPrefix(path, "/")
+}
+
+// initial returns the first character of a string, e.g. for an avatar
+// placeholder
+func initial(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return ""
+	}
+	return s[:size]
+}
+
+// first returns the first n elements of a slice
+func first(n int, list interface{}) (interface{}, error) {
+	v, err := sliceValue("first", list)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > v.Len() {
+		n = v.Len()
+	}
+	return v.Slice(0, n).Interface(), nil
+}
+
+// last returns the last n elements of a slice
+func last(n int, list interface{}) (interface{}, error) {
+	v, err := sliceValue("last", list)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > v.Len() {
+		n = v.Len()
+	}
+	return v.Slice(v.Len()-n, v.Len()).Interface(), nil
+}
+
+// after returns the elements of a slice after the first n
+func after(n int, list interface{}) (interface{}, error) {
+	v, err := sliceValue("after", list)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > v.Len() {
+		n = v.Len()
+	}
+	return v.Slice(n, v.Len()).Interface(), nil
+}
+
+// sliceValue checks that a template argument is a slice
+func sliceValue(name string, list interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("%s: expected a slice, got %T", name, list)
+	}
+	return v, nil
+}
@@ -0,0 +1,22 @@
+package generator
+
+import "testing"
+
+func TestInitial(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"alice", "a"},
+		{"Ñandú", "Ñ"},
+		{"李雷", "李"},
+		{"😀 smile", "😀"},
+		{"", ""},
+		{"\xffbad", ""},
+	}
+	for _, tt := range tests {
+		if got := initial(tt.in); got != tt.want {
+			t.Errorf("initial(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
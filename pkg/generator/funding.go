@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"html"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// fundingPage is the page listing the sponsor links of FUNDING.yml
+const fundingPage = "sponsor.html"
+
+// generateFundingPage creates sponsor.html with the funding platforms,
+// custom links and addresses of the repository's funding file
+func (g *Generator) generateFundingPage(docsPages []utils.DocPage) error {
+	var b strings.Builder
+	b.WriteString(`<ul class="funding-list">`)
+	for _, link := range g.repoData.Funding {
+		b.WriteString(`<li class="funding-item">`)
+		if link.Platform != "" {
+			b.WriteString(`<span class="funding-platform">` + html.EscapeString(link.Platform) + `</span> `)
+		}
+		name := html.EscapeString(link.Name)
+		if link.Address {
+			name = `<code class="funding-address">` + name + `</code>`
+		}
+		if link.URL != "" {
+			name = `<a href="` + html.EscapeString(link.URL) + `" target="_blank" rel="noopener noreferrer">` + name + `</a>`
+		}
+		b.WriteString(name + "</li>")
+	}
+	b.WriteString("</ul>")
+
+	heading := g.message(g.defaultLang(), "Sponsor")
+	page := g.newPage("doc", fundingPage, g.defaultLang(), docsPages)
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+	page.Data.PageContent = b.String()
+
+	return g.renderPage(page)
+}
@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -24,6 +31,27 @@ type GenerationResult struct {
 	DocsCount     int
 	ImagesCount   int
 	SiteStructure string
+
+	// Files written, relative to the output directory
+	Pages  []string
+	Assets []string
+
+	// GeneratedAt is when the site was generated: the date of the last
+	// commit in reproducible builds
+	GeneratedAt time.Time
+
+	// Internal links pointing at files missing from the output
+	BrokenLinks []BrokenLink
+
+	// Images without alternative text, when checked
+	MissingAlt []MissingAlt
+
+	// Moved documents whose old pages redirect, by former path
+	Moved map[string]string
+
+	// Problems with the repository that didn't stop the site from being
+	// generated, such as a missing README
+	Warnings []string
 }
 
 // Generator handles the site generation
@@ -31,6 +59,157 @@ type Generator struct {
 	repoData      *git.RepositoryData
 	outputDir     string
 	templateCache map[string]*template.Template
+	logger        *slog.Logger
+	jobs          int
+	optimize      bool
+	offline       bool
+	checkAlt      bool
+	singlePage    bool
+	taskProgress  bool
+	math          string
+	sanitize      bool
+	cspMode       string
+	strictCSP     bool
+	staticDir     string
+	cname         string
+	repoBadges    bool
+	rawMarkdown   bool
+	jsonAPI       string
+	llmsTxt       bool
+	search        bool
+	baseURL       string
+	sourceTree    bool
+	// Extensions of the files shown in the file tree, with a leading dot
+	sourceExtensions []string
+	// Contents of the files with a source view, by slash-separated path
+	sources      map[string]string
+	goImport     string
+	securityTxt  SecurityTxt
+	reproducible bool
+	pdfFile      string
+	pdfCommand   []string
+	badgeMode    string
+	imageOptions ImageOptions
+
+	// Document content with front matter stripped, and the parsed front matter
+	markdown    map[string]string
+	frontMatter map[string]utils.FrontMatter
+
+	// HTML of documents in formats other than markdown
+	converted map[string]string
+
+	// READMEs of subdirectories, generated as the index page of their directory
+	indexPages map[string]bool
+
+	// Front matter tags of the documents, by the name of their page
+	tags map[string]*tag
+
+	// Names of the directories and of the README page
+	layout Layout
+
+	// Document of the landing page, if the home page isn't the README
+	landingPath string
+
+	// Pages are generated as directories, with their final paths by the
+	// path they were generated at
+	prettyURLs  bool
+	prettyPages map[string]string
+
+	// File of the redirect map, if not the default one
+	redirectsFile string
+
+	// Documents moved before the last build, and renamed since, by former path
+	moved, renames map[string]string
+
+	// Directory of the blog, and its posts, newest first
+	blogDir string
+	posts   []post
+
+	// Hand-written HTML pages copied through unchanged, unless wrapHTML
+	// renders them inside the doc template
+	passthrough map[string]string
+	wrapHTML    bool
+
+	// Content languages, the language and language-independent path of each
+	// document, and the documents of each language-independent path by language
+	languages    []string
+	pageLang     map[string]string
+	canonical    map[string]string
+	translations map[string]map[string]string
+
+	// Versions of a versioned documentation site, and the one being generated
+	versions []string
+	version  string
+
+	// Subdirectories of a monorepo presented as separate projects
+	projects []*project
+
+	// Citation of the repository, from CITATION.cff
+	citation *CitationInfo
+
+	// Copyright line, documentation license and links of the footer
+	footer Footer
+
+	// What the home page shows for a repository without a README
+	missingReadme string
+
+	// Whether absolute links to the repository on GitHub point at the site
+	rewriteSelfLinks bool
+
+	// Documents by lower-case path without extension, for resolving links
+	documentIndex map[string]string
+
+	// Layout and time zone of the dates shown on the site
+	dateLayout string
+	location   *time.Location
+
+	// Filters run on every page before it is rendered
+	filters []Filter
+
+	// Hooks run on the markdown of every document and the HTML of every page
+	preRenderHooks  []PreRenderHook
+	postRenderHooks []PostRenderHook
+
+	// Whether any document has math, and whether the math engine was
+	// vendored into the site
+	hasMath      bool
+	mathVendored bool
+
+	// Handlers of the shortcodes, by name
+	shortcodes map[string]Shortcode
+
+	// HTML output of shortcodes, restored in place of placeholders after
+	// rendering, and the number of shortcodes expanded so far
+	shortcodeHTML  []string
+	shortcodeCount int
+
+	// Message catalogs of the UI strings, cached by language. Pages rendered
+	// concurrently load them, so they are guarded by catalogsMu.
+	catalogs   map[string]map[string]string
+	catalogsMu sync.Mutex
+
+	// Path patterns of documents left out of the site, or kept out of search engines
+	exclude []string
+	noindex []string
+
+	// Snippets inserted into the <head> and at the end of the <body> of every page
+	headHTML   string
+	footerHTML string
+
+	// Release history merged from tags and GitHub Releases
+	releases []ReleaseEntry
+
+	// Path of the changelog rendered on its own page, if any
+	changelogPath string
+
+	// Site logo and icons, relative to the output directory
+	logoPath string
+	favicons []Favicon
+
+	// Wiki pages, keyed like markdown files under a wiki/ prefix, and the
+	// link targets of wiki pages by normalized name
+	wiki      map[string]bool
+	wikiLinks map[string]string
 }
 
 // PageData contains the data passed to HTML templates
@@ -40,40 +219,232 @@ type PageData struct {
 	RepoFullName string
 	Description  string
 	CommitCount  int
-	LastUpdate   string
-	License      string
-	RepoURL      string
-
-	ReadmeHTML   string
+	// HistoryTruncated is set when CommitCount leaves out older commits
+	HistoryTruncated bool
+	LastUpdate       string
+	License          string
+	// LicenseSPDX is the SPDX identifier of the license, if it was recognized
+	LicenseSPDX string
+	RepoURL     string
+
+	// Metadata from the GitHub API, when available
+	Topics        []string
+	Stars         int
+	Forks         int
+	DefaultBranch string
+	Homepage      string
+
+	ReadmeHTML string
+	// Placeholder stands in for the README on the home page of a
+	// repository without one
+	Placeholder  string
 	Contributors []git.Contributor
+	// Related repositories, shown as cards on the home page
+	Related []git.RelatedRepo
+	// Citation is the "Cite this project" box of the home page
+	Citation *CitationInfo
+	// ReadmePath is the page of the README, relative to the site root, when
+	// it isn't the home page
+	ReadmePath string
+
+	// Site logo and icons, relative to the site root
+	LogoPath string
+	Favicons []Favicon
 
 	// Navigation
-	DocsPages []utils.DocPage
+	DocsPages      []utils.DocPage
+	NavTree        *utils.NavSection
+	Breadcrumbs    []utils.Breadcrumb
+	HasReleases    bool
+	HasChangelog   bool
+	HasIssues      bool
+	HasDiscussions bool
+	HasSinglePage  bool
+	// HasSearch shows the search box and links the OpenSearch description
+	HasSearch bool
+	// HasTags links the index of front matter tags
+	HasTags bool
+	// Name of the blog and paths of its listing and RSS feed, relative to
+	// the site root, when the site has a blog
+	BlogTitle string
+	BlogPath  string
+	FeedPath  string
+	// HasContributors links the page listing every contributor
+	HasContributors bool
+	// HasLicense links the page with the full license text
+	HasLicense bool
+	// HasSourceTree links the file tree
+	HasSourceTree bool
+	// HasFunding links the page with the sponsor links of FUNDING.yml
+	HasFunding bool
+	// CommunityLinks link the pages of community health files such as
+	// CONTRIBUTING.md
+	CommunityLinks []CommunityLink
+
+	// Language of the page, the output directory of its language tree
+	// relative to the site root, and the language switcher
+	Lang       string
+	LangPrefix string
+	Languages  []LanguageLink
+
+	// UI strings in the language of the page, from its message catalog
+	T map[string]string
+
+	// Version of a versioned documentation site, and the version switcher
+	Version  string
+	Versions []VersionLink
+
+	// Projects of a monorepo, listed on the home page
+	Projects []ProjectLink
+
+	// GoModule is the module of a Go repository, shown on the home page
+	GoModule *GoModuleInfo
+	// CodeLanguages is the language breakdown shown on the home page
+	CodeLanguages []LanguageStat
+	// Content of the go-import and go-source meta tags of a vanity import path
+	GoImport string
+	GoSource string
 
 	// Current page info
-	CurrentPage string
-	PageTitle   string
-	PageContent string
-
-	// Generation info
-	GeneratedAt string
+	CurrentPage      string
+	RootPath         string
+	LastModified     string
+	LastModifiedBy   string
+	PageContributors []git.Contributor
+	PageTitle        string
+	PageHeading      string
+	PageContent      string
+	NoIndex          bool
+	// RawURL links the markdown source of the page, when it is copied into
+	// the site
+	RawURL string
+
+	// Public URL of the site and of the current page, when the site has a custom domain
+	SiteURL      string
+	CanonicalURL string
+
+	// Releases, changelog, issue snapshot and contributors pages
+	Releases        []ReleaseEntry
+	Changelog       []ChangelogEntry
+	Issues          []IssueEntry
+	AllContributors []ContributorEntry
+
+	// Files and subdirectories of a directory of the file tree
+	TreeEntries []TreeEntry
+
+	// Date of a blog post, and the posts of a page of the blog listing with
+	// the links to the pages of newer and older posts
+	PostDate      string
+	Posts         []PostEntry
+	NewerPostsURL string
+	OlderPostsURL string
+
+	// Tags of a doc page, every tag for the tag index, and the pages of a tag
+	PageTags    []TagLink
+	TagCloud    []TagLink
+	TaggedPages []TaggedPage
+
+	// Custom snippets injected into every page
+	HeadHTML   string
+	FooterHTML string
+
+	// Generation info, shown in the footer with ShowGeneratedOn
+	GeneratedAt     string
+	ShowGeneratedOn bool
+
+	// Copyright line, license of the documentation and extra links of the
+	// footer
+	Copyright   string
+	DocsLicense *DocsLicense
+	FooterLinks []FooterLink
+
+	// Repo is everything read from the repository, for data the fields above
+	// don't carry
+	Repo *git.RepositoryData
 }
 
 // NewGenerator creates a new site generator
 func NewGenerator(repoData *git.RepositoryData, outputDir string) *Generator {
 	return &Generator{
-		repoData:      repoData,
-		outputDir:     outputDir,
-		templateCache: make(map[string]*template.Template),
+		repoData:         repoData,
+		outputDir:        outputDir,
+		templateCache:    make(map[string]*template.Template),
+		logger:           slog.Default(),
+		jobs:             runtime.NumCPU(),
+		markdown:         make(map[string]string),
+		frontMatter:      make(map[string]utils.FrontMatter),
+		converted:        make(map[string]string),
+		passthrough:      make(map[string]string),
+		indexPages:       make(map[string]bool),
+		pageLang:         make(map[string]string),
+		catalogs:         make(map[string]map[string]string),
+		canonical:        make(map[string]string),
+		translations:     make(map[string]map[string]string),
+		wiki:             make(map[string]bool),
+		wikiLinks:        make(map[string]string),
+		shortcodes:       maps.Clone(builtinShortcodes),
+		layout:           DefaultLayout,
+		dateLayout:       DefaultDateFormat,
+		location:         time.UTC,
+		rewriteSelfLinks: true,
 	}
 }
 
+// SetLogger sets the logger used for progress and debug messages
+func (g *Generator) SetLogger(logger *slog.Logger) {
+	g.logger = logger
+}
+
+// warn logs a problem with the repository and records it in the result
+func (g *Generator) warn(result *GenerationResult, msg string) {
+	g.logger.Warn(msg)
+	result.Warnings = append(result.Warnings, msg)
+}
+
+// SetJobs sets how many pages are rendered concurrently
+func (g *Generator) SetJobs(jobs int) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	g.jobs = jobs
+}
+
+// SetOptimize enables minification of the output and fingerprinting of assets
+func (g *Generator) SetOptimize(optimize bool) {
+	g.optimize = optimize
+}
+
+// SetOffline enables downloading external images so the site works without
+// clearnet access
+func (g *Generator) SetOffline(offline bool) {
+	g.offline = offline
+}
+
+// SetInjections sets HTML snippets inserted into the <head> and at the end of
+// the <body> of every generated page
+func (g *Generator) SetInjections(head, footer string) {
+	g.headHTML = head
+	g.footerHTML = footer
+}
+
+// SetPathRules sets glob patterns of documents to leave out of the site and
+// of documents to mark noindex. A pattern ending in "/" matches a whole directory.
+func (g *Generator) SetPathRules(exclude, noindex []string) {
+	g.exclude = exclude
+	g.noindex = noindex
+}
+
+// SetImageOptions configures the image pipeline
+func (g *Generator) SetImageOptions(opts ImageOptions) {
+	g.imageOptions = opts
+}
+
 // GenerateSite generates the complete static site
 func (g *Generator) GenerateSite() (*GenerationResult, error) {
-	result := &GenerationResult{}
+	result := &GenerationResult{GeneratedAt: g.now()}
 
 	// Create docs directory
-	docsDir := filepath.Join(g.outputDir, "docs")
+	docsDir := filepath.Join(g.outputDir, filepath.FromSlash(g.layout.DocsDir))
 	if err := os.MkdirAll(docsDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create docs directory: %w", err)
 	}
@@ -82,97 +453,381 @@ func (g *Generator) GenerateSite() (*GenerationResult, error) {
 	if err := GenerateRootStyle(g.outputDir); err != nil {
 		return nil, fmt.Errorf("failed to write style.css: %w", err)
 	}
+	result.Assets = append(result.Assets, "style.css")
 
 	// Create image directory if needed
-	imagesDir := filepath.Join(g.outputDir, "images")
+	imagesDir := filepath.Join(g.outputDir, filepath.FromSlash(g.layout.ImagesDir))
 	if err := os.MkdirAll(imagesDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create images directory: %w", err)
 	}
 
+	// Copy the logo and generate favicons
+	if err := g.generateLogo(result); err != nil {
+		return nil, err
+	}
+
 	// Parse all templates first
 	if err := g.parseTemplates(); err != nil {
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
+	redirects, err := g.loadRedirects()
+	if err != nil {
+		return nil, err
+	}
 
 	// Copy image files to output directory
 	for relativePath, sourcePath := range g.repoData.ImageFiles {
-		destPath := filepath.Join(g.outputDir, "images", filepath.Base(relativePath))
-		if err := copyFile(sourcePath, destPath); err != nil {
+		image := g.imagesPath(filepath.Base(relativePath))
+		if err := copyFile(sourcePath, filepath.Join(g.outputDir, filepath.FromSlash(image))); err != nil {
 			return nil, fmt.Errorf("failed to copy image %s: %w", relativePath, err)
 		}
+		result.Assets = append(result.Assets, image)
 		result.ImagesCount++
 	}
 
+	// Split front matter from the document content
+	g.loadFrontMatter(result)
+	g.findLanding()
+	if err := g.checkReadme(result); err != nil {
+		return nil, err
+	}
+	if err := g.runPreRenderHooks(); err != nil {
+		return nil, err
+	}
+	g.loadHTMLPages()
+	g.assignLanguages()
+	g.expandShortcodes()
+	if g.hasMath {
+		if err := g.writeMathAssets(result); err != nil {
+			return nil, err
+		}
+	}
+	g.findIndexPages()
+	g.loadProjects()
+	g.changelogPath = g.findChangelog()
+	if g.sourceTree {
+		if err := g.loadSources(); err != nil {
+			return nil, err
+		}
+	}
+	g.checkDocumentLinks(result)
+
 	// Prepare the list of documentation pages for navigation
 	var docsPages []utils.DocPage
-	docsByDirectory := make(map[string][]utils.DocPage)
 
-	for path := range g.repoData.MarkdownFiles {
-		// Skip README as it's on the main page
-		if isReadmeFile(filepath.Base(path)) {
+	for path := range g.markdown {
+		// Skip the README as it's on the main page, the changelog, and drafts
+		if g.skipDocPage(path) {
 			continue
 		}
 
-		title := utils.GetTitleFromMarkdown(g.repoData.MarkdownFiles[path])
-		if title == "" {
-			title = utils.PrettifyFilename(filepath.Base(path))
+		docPage := utils.DocPage{
+			Title:         g.pageTitle(path),
+			Path:          g.docOutputPath(path),
+			Weight:        g.frontMatter[path].Weight,
+			Section:       g.frontMatter[path].Section,
+			SectionWeight: g.frontMatter[path].SectionWeight,
+			Lang:          g.pageLanguage(path),
+		}
+		if g.wiki[path] && docPage.Section == "" {
+			docPage.Section = g.message(docPage.Lang, "Wiki")
+		}
+
+		// Directory READMEs come first in their section
+		if g.indexPages[path] && docPage.Weight == 0 {
+			docPage.Weight = -1
 		}
 
-		outputPath := utils.GetOutputPath(path, "docs")
-		docPage := utils.DocPage{
-			Title: title,
-			Path:  outputPath,
+		// Pages of a project are grouped under it, starting with its README
+		if project := g.projectOf(path); project != nil {
+			if docPage.Section == "" {
+				docPage.Section = project.Title
+			}
+			if project.Readme == path {
+				docPage.Title = g.message(docPage.Lang, "Overview")
+			}
 		}
 
-		// Add to main list
 		docsPages = append(docsPages, docPage)
+	}
+	docsPages = append(docsPages, g.passthroughPages()...)
+
+	// Sort docsPages by weight and title for consistent navigation
+	utils.SortDocPages(docsPages)
+
+	g.loadPosts()
+	g.collectTags()
+
+	// Pages outside the language trees only list the default language
+	defaultPages := pagesForLang(docsPages, g.defaultLang())
+
+	// Generate releases page
+	g.releases = g.buildReleaseEntries()
+	if len(g.releases) > 0 {
+		if err := g.generateReleasesPage(defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate releases page: %w", err)
+		}
+		result.Pages = append(result.Pages, "releases.html")
+	}
+
+	// Generate changelog page
+	if g.changelogPath != "" {
+		if err := g.generateChangelogPage(defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate changelog page: %w", err)
+		}
+		result.Pages = append(result.Pages, "changelog.html")
+	}
+
+	// Generate the page with the full license text
+	if g.repoData.LicenseText != "" {
+		if err := g.generateLicensePage(defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate license page: %w", err)
+		}
+		result.Pages = append(result.Pages, "license.html")
+	}
+
+	// Generate the page with the sponsor links
+	if len(g.repoData.Funding) > 0 {
+		if err := g.generateFundingPage(defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate sponsor page: %w", err)
+		}
+		result.Pages = append(result.Pages, fundingPage)
+	}
 
-		// Add to directory-specific list for structured navigation
-		dirPath := filepath.Dir(path)
-		if dirPath == "." {
-			dirPath = "root"
+	// Generate the pages of community health files
+	community, err := g.generateCommunityPages(defaultPages)
+	if err != nil {
+		return nil, err
+	}
+	result.Pages = append(result.Pages, community...)
+
+	// Generate the blog
+	if len(g.posts) > 0 {
+		blog, err := g.generateBlog(defaultPages, result)
+		if err != nil {
+			return nil, err
 		}
-		docsByDirectory[dirPath] = append(docsByDirectory[dirPath], docPage)
+		result.Pages = append(result.Pages, blog...)
 	}
 
-	// Sort docsPages by path for consistent navigation
-	utils.SortDocPagesByTitle(docsPages)
+	// Generate the tag index and the page of each tag
+	if len(g.tags) > 0 {
+		tagPages, err := g.generateTagPages(defaultPages)
+		if err != nil {
+			return nil, err
+		}
+		result.Pages = append(result.Pages, tagPages...)
+	}
 
-	// Generate main index page
-	if err := g.generateMainPage(docsPages); err != nil {
-		return nil, fmt.Errorf("failed to generate main page: %w", err)
+	// Generate the file tree and source views
+	if g.sourceTree {
+		tree, err := g.generateSourceTree(defaultPages)
+		if err != nil {
+			return nil, err
+		}
+		result.Pages = append(result.Pages, tree...)
+	}
+
+	// Generate issue and discussion snapshot pages
+	if len(g.repoData.Issues) > 0 {
+		if err := g.generateIssuesPage("issues.html", g.message(g.defaultLang(), "OpenIssues"), g.repoData.Issues, defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate issues page: %w", err)
+		}
+		result.Pages = append(result.Pages, "issues.html")
+	}
+	// Generate the page listing every contributor
+	if len(g.repoData.AllContributors) > 0 {
+		if err := g.generateContributorsPage(defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate contributors page: %w", err)
+		}
+		result.Pages = append(result.Pages, "contributors.html")
+	}
+	if len(g.repoData.Discussions) > 0 {
+		if err := g.generateIssuesPage("discussions.html", g.message(g.defaultLang(), "Discussions"), g.repoData.Discussions, defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate discussions page: %w", err)
+		}
+		result.Pages = append(result.Pages, "discussions.html")
+	}
+
+	// Read the citation of the home page and write its BibTeX export
+	if err := g.loadCitation(result); err != nil {
+		return nil, err
+	}
+
+	if len(defaultPages) == 0 {
+		g.logger.Info("Repository has no documentation besides the README")
+	}
+
+	// Generate the main index page of each language
+	languages := g.languages
+	if len(languages) == 0 {
+		languages = []string{g.defaultLang()}
+	}
+	for _, lang := range languages {
+		if lang == g.defaultLang() && g.landingPath != "" {
+			if err := g.generateLandingPage(docsPages); err != nil {
+				return nil, err
+			}
+		} else if err := g.generateMainPage(docsPages, lang); err != nil {
+			return nil, fmt.Errorf("failed to generate main page: %w", err)
+		}
+		result.Pages = append(result.Pages, g.langPrefix(lang)+"index.html")
+
+		if g.separateReadme() && g.markdown[g.languageReadme(lang)] != "" {
+			if err := g.generateReadmePage(docsPages, lang); err != nil {
+				return nil, fmt.Errorf("failed to generate README page: %w", err)
+			}
+			result.Pages = append(result.Pages, g.readmePage(lang))
+		}
 	}
 
 	// Generate documentation pages
-	processedCount := 0
 	var processedFiles []string
+	for path := range g.markdown {
+		// Skip the README as it's on the main page, the changelog, and drafts
+		if !g.skipDocPage(path) {
+			processedFiles = append(processedFiles, path)
+		}
+	}
+	sort.Strings(processedFiles)
 
-	for path, content := range g.repoData.MarkdownFiles {
-		// Skip README as it's on the main page
-		if isReadmeFile(filepath.Base(path)) {
-			continue
+	if err := g.generateDocPages(processedFiles, docsPages); err != nil {
+		return nil, err
+	}
+
+	for _, file := range processedFiles {
+		result.Pages = append(result.Pages, g.docOutputPath(file))
+		g.logger.Debug("Processed markdown file", "path", file)
+	}
+
+	// Documents by the output path of their page
+	sources := make(map[string]string)
+	for _, lang := range languages {
+		if readme := g.languageReadme(lang); g.markdown[readme] != "" {
+			sources[g.readmePage(lang)] = readme
+		}
+	}
+	if g.landingPath != "" {
+		sources["index.html"] = g.landingPath
+	}
+	for _, file := range processedFiles {
+		sources[g.docOutputPath(file)] = file
+	}
+
+	// Copy the markdown sources next to their pages
+	if g.rawMarkdown || g.llmsTxt {
+		if err := g.writeRawMarkdown(result, sources); err != nil {
+			return nil, err
 		}
+	}
 
-		if err := g.generateDocPage(path, content, docsPages); err != nil {
-			return nil, fmt.Errorf("failed to generate doc page for %s: %w", path, err)
+	// Describe the pages for other tools
+	if g.jsonAPI != "" {
+		if err := g.writeContentAPI(result, sources); err != nil {
+			return nil, err
+		}
+	}
+	if g.llmsTxt {
+		if err := g.writeLLMsTxt(result, defaultPages, sources); err != nil {
+			return nil, err
 		}
+	}
 
-		processedFiles = append(processedFiles, path)
-		processedCount++
+	// Generate the search page and index
+	if g.search {
+		if err := g.generateSearch(result, defaultPages, sources); err != nil {
+			return nil, err
+		}
 	}
 
-	fmt.Printf("Processed %d markdown files:\n", processedCount)
-	for _, file := range processedFiles {
-		fmt.Printf("  - %s\n", file)
+	// Copy hand-written HTML pages through
+	if err := g.copyPassthroughPages(result); err != nil {
+		return nil, err
 	}
+	processedCount := len(processedFiles)
+	g.logger.Info("Processed markdown files", "count", processedCount)
 
 	result.DocsCount = processedCount
 
+	// Generate the single-page export of all documentation
+	if g.singlePage {
+		if err := g.generateSinglePage(defaultPages); err != nil {
+			return nil, fmt.Errorf("failed to generate single-page export: %w", err)
+		}
+		result.Pages = append(result.Pages, "all.html")
+	}
+
+	// Fetch, replace or strip status badges
+	if err := g.processBadges(result); err != nil {
+		return nil, fmt.Errorf("failed to process badges: %w", err)
+	}
+
+	// Write the badges computed from the repository
+	if g.repoBadges {
+		if err := g.writeRepoBadges(result, len(defaultPages)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Vendor external images for offline mirrors
+	if g.offline {
+		if err := g.vendorExternalImages(result); err != nil {
+			return nil, fmt.Errorf("failed to vendor external images: %w", err)
+		}
+	}
+
+	// Export the documentation as a single PDF
+	if g.pdfFile != "" {
+		if err := g.generatePDF(defaultPages, result); err != nil {
+			return nil, fmt.Errorf("failed to generate PDF: %w", err)
+		}
+	}
+
+	// Keep links to moved pages working
+	redirects = append(redirects, g.movedRedirects(result)...)
+	if len(redirects) > 0 {
+		if err := g.writeRedirects(result, redirects); err != nil {
+			return nil, err
+		}
+	}
+
+	// Serve pages from directories
+	if g.prettyURLs {
+		if err := g.applyPrettyURLs(result); err != nil {
+			return nil, fmt.Errorf("failed to apply pretty URLs: %w", err)
+		}
+	}
+
 	// Generate site structure summary
 	var buffer bytes.Buffer
 	buffer.WriteString(g.outputDir + "/\n")
 	buffer.WriteString("  ├── index.html\n")
-	buffer.WriteString("  ├── docs/\n")
+	if len(g.releases) > 0 {
+		buffer.WriteString("  ├── releases.html\n")
+	}
+	if g.changelogPath != "" {
+		buffer.WriteString("  ├── changelog.html\n")
+	}
+	if g.repoData.LicenseText != "" {
+		buffer.WriteString("  ├── license.html\n")
+	}
+	for _, c := range communityPages {
+		if _, ok := g.repoData.CommunityFiles[c.page]; ok {
+			buffer.WriteString("  ├── " + c.page + ".html\n")
+		}
+	}
+	if g.sourceTree {
+		buffer.WriteString("  ├── tree.html\n")
+		buffer.WriteString("  ├── tree/\n")
+		buffer.WriteString("  ├── source/\n")
+	}
+	if len(g.repoData.Issues) > 0 {
+		buffer.WriteString("  ├── issues.html\n")
+	}
+	if len(g.repoData.Discussions) > 0 {
+		buffer.WriteString("  ├── discussions.html\n")
+	}
+	buffer.WriteString("  ├── " + g.layout.DocsDir + "/\n")
 
 	if len(docsPages) > 0 {
 		for i, page := range docsPages {
@@ -187,145 +842,482 @@ func (g *Generator) GenerateSite() (*GenerationResult, error) {
 	}
 
 	if result.ImagesCount > 0 {
-		buffer.WriteString("  └── images/\n")
+		buffer.WriteString("  └── " + g.layout.ImagesDir + "/\n")
 		buffer.WriteString("      └── ... (" + fmt.Sprintf("%d", result.ImagesCount) + " files)\n")
 	} else {
-		buffer.WriteString("  └── images/\n")
+		buffer.WriteString("  └── " + g.layout.ImagesDir + "/\n")
 		buffer.WriteString("      └── (empty)\n")
 	}
 
 	result.SiteStructure = buffer.String()
 
+	// Optimize images and generate responsive variants
+	if err := g.processImages(result); err != nil {
+		return nil, fmt.Errorf("failed to process images: %w", err)
+	}
+
+	// Minify and fingerprint the output
+	if g.optimize {
+		if err := g.optimizeOutput(result); err != nil {
+			return nil, fmt.Errorf("failed to optimize output: %w", err)
+		}
+	}
+
+	// Lock down what the pages may load
+	if g.cspMode != "" {
+		if err := g.applyCSP(result); err != nil {
+			return nil, fmt.Errorf("failed to apply content security policy: %w", err)
+		}
+	}
+
+	// Point the custom domain at the site and list its pages for search engines
+	if g.cname != "" {
+		if err := g.writeDomainFiles(result); err != nil {
+			return nil, err
+		}
+	}
+
+	// Copy verification and other static files
+	if err := g.copyStaticFiles(result); err != nil {
+		return nil, err
+	}
+
+	// Give every file the same timestamp, so archives of the site are identical too
+	if g.reproducible {
+		if err := g.stampFiles(result); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate internal links in the generated pages
+	brokenLinks, err := CheckLinks(g.outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check links: %w", err)
+	}
+	for _, link := range brokenLinks {
+		g.logger.Warn("Broken internal link", "page", link.Page, "target", link.Target)
+	}
+	result.BrokenLinks = brokenLinks
+
+	// Flag images without alternative text
+	if g.checkAlt {
+		missingAlt, err := CheckImageAlt(g.outputDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check image alt text: %w", err)
+		}
+		for _, img := range missingAlt {
+			g.logger.Warn("Image without alt text", "page", img.Page, "src", img.Src)
+		}
+		result.MissingAlt = missingAlt
+	}
+
 	return result, nil
 }
 
-// parseTemplates parses all the HTML templates
+// parseTemplates parses all the HTML templates together with the shared partials
 func (g *Generator) parseTemplates() error {
-	// Parse main template
-	mainTmpl, err := template.New("main").Parse(templates.MainTemplate)
-	if err != nil {
-		return fmt.Errorf("failed to parse main template: %w", err)
+	pages := []struct {
+		name string
+		text string
+	}{
+		{"main", templates.MainTemplate},
+		{"doc", templates.DocTemplate},
+		{"releases", templates.ReleasesTemplate},
+		{"changelog", templates.ChangelogTemplate},
+		{"issues", templates.IssuesTemplate},
+		{"contributors", templates.ContributorsTemplate},
+		{"tree", templates.TreeTemplate},
+		{"search", templates.SearchTemplate},
+		{"tags", templates.TagsTemplate},
+		{"blog", templates.BlogTemplate},
+		{"landing", templates.LandingTemplate},
 	}
-	g.templateCache["main"] = mainTmpl
 
-	// Parse documentation template
-	docTmpl, err := template.New("doc").Parse(templates.DocTemplate)
-	if err != nil {
-		return fmt.Errorf("failed to parse doc template: %w", err)
+	for _, page := range pages {
+		tmpl := template.New(page.name).Funcs(templateFuncs(g.dateLayout, g.sanitize))
+
+		// Partials are parsed first, so a page template can still redefine them
+		for _, name := range templates.PartialNames() {
+			if _, err := tmpl.New(name).Parse(templates.Partials[name]); err != nil {
+				return fmt.Errorf("failed to parse %s partial: %w", name, err)
+			}
+		}
+
+		if _, err := tmpl.Parse(page.text); err != nil {
+			return fmt.Errorf("failed to parse %s template: %w", page.name, err)
+		}
+		g.templateCache[page.name] = tmpl
 	}
-	g.templateCache["doc"] = docTmpl
 
 	return nil
 }
 
-// generateMainPage creates the main index.html
-func (g *Generator) generateMainPage(docsPages []utils.DocPage) error {
-	// Prepare data for template
-	data := PageData{
-		RepoOwner:    g.repoData.Owner,
-		RepoName:     g.repoData.Name,
-		RepoFullName: g.repoData.Owner + "/" + g.repoData.Name,
-		Description:  g.repoData.Description,
-		CommitCount:  g.repoData.CommitCount,
-		License:      g.repoData.License,
-		RepoURL:      g.repoData.URL,
-		LastUpdate:   g.repoData.LastCommitDate.Format("January 2, 2006"),
+// generateMainPage creates the main index.html of a language
+func (g *Generator) generateMainPage(docsPages []utils.DocPage, lang string) error {
+	page := g.newPage("main", g.langPrefix(lang)+"index.html", lang, pagesForLang(docsPages, lang))
+	if !g.separateReadme() {
+		page.Source = g.languageReadme(lang)
+		page.Data.ReadmeHTML = g.addTaskProgress(page.Source, g.sanitizeContent(renderMarkdownLinks(g.markdown[page.Source], g.docLinks(page.Source, page.Data.RootPath, nil))))
+	}
+	// Without a README, the home page is an overview of the repository's
+	// files, or a placeholder
+	if !g.separateReadme() && page.Data.ReadmeHTML == "" {
+		switch {
+		case g.repoData.Empty || len(g.repoData.Files) == 0:
+			page.Data.Placeholder = g.message(lang, "EmptyRepository")
+		case g.missingReadme == MissingReadmePlaceholder:
+			page.Data.Placeholder = g.message(lang, "NoReadme")
+		default:
+			page.Data.TreeEntries = g.rootEntries(page.Data.RootPath)
+		}
+	}
+	page.Data.Contributors = g.repoData.Contributors
+	page.Data.Related = g.repoData.Related
+	page.Data.Citation = g.citation
+	page.Data.Languages = g.languageLinks("", lang)
+	page.Data.Projects = g.projectLinks()
+	page.Data.GoModule = g.goModule()
+	page.Data.CodeLanguages = g.languageStats(lang)
+	page.Data.GoImport, page.Data.GoSource = g.goImportMeta("")
+	if page.Source != "" && g.hasRawMarkdown(page.Source) {
+		page.Data.RawURL = filepath.Base(rawOutputPath(page.Path))
+	}
 
-		ReadmeHTML:   renderMarkdown(g.repoData.ReadmeContent),
-		Contributors: g.repoData.Contributors,
+	return g.renderPage(page)
+}
 
-		DocsPages:   docsPages,
-		CurrentPage: "index.html",
-		PageTitle:   g.repoData.Owner + "/" + g.repoData.Name,
+// generateReadmePage creates the page of the README of a language, when it
+// isn't the home page
+func (g *Generator) generateReadmePage(docsPages []utils.DocPage, lang string) error {
+	source := g.languageReadme(lang)
+	page := g.newPage("doc", g.readmePage(lang), lang, pagesForLang(docsPages, lang))
+	page.Source = source
+	data := page.Data
+	data.PageTitle = "README - " + g.repoData.Owner + "/" + g.repoData.Name
+	data.PageHeading = "README"
+	data.Breadcrumbs = []utils.Breadcrumb{{Title: g.message(lang, "Home"), Path: g.langPrefix(lang) + "index.html"}, {Title: "README"}}
+	data.Languages = g.languageLinks(source, lang)
+	data.LastModified = g.formatDate(g.repoData.FileHistory[source].LastModified)
+	data.LastModifiedBy = g.repoData.FileHistory[source].LastAuthor
+	data.PageContent = g.addTaskProgress(source, g.sanitizeContent(renderMarkdownLinks(g.markdown[source], g.docLinks(source, data.RootPath, nil))))
+	if g.hasRawMarkdown(source) {
+		data.RawURL = filepath.Base(rawOutputPath(page.Path))
+	}
+	return g.renderPage(page)
+}
 
-		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+// generateDocPages renders doc pages concurrently using a pool of g.jobs workers.
+// It returns the first error encountered, after all workers have finished.
+// The workers only read the documents and settings loaded before they
+// start; the message catalogs, the only cache they fill, are locked, and
+// every page gets its own copy of its messages for filters to change.
+func (g *Generator) generateDocPages(paths []string, docsPages []utils.DocPage) error {
+	jobs := g.jobs
+	if jobs > len(paths) {
+		jobs = len(paths)
 	}
 
-	// Render template
-	var buf bytes.Buffer
-	if err := g.templateCache["main"].Execute(&buf, data); err != nil {
-		return fmt.Errorf("failed to execute main template: %w", err)
+	work := make(chan string)
+	errs := make(chan error, len(paths))
+	var wg sync.WaitGroup
+	progress := utils.NewProgress("Rendering pages", len(paths))
+	defer progress.Finish()
+
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				if err := g.generateDocPage(path, g.markdown[path], docsPages); err != nil {
+					errs <- fmt.Errorf("failed to generate doc page for %s: %w", path, err)
+				}
+				progress.Add(1)
+			}
+		}()
 	}
 
-	// Write to file
-	outputPath := filepath.Join(g.outputDir, "index.html")
-	if err := os.WriteFile(outputPath, buf.Bytes(), 0o644); err != nil {
-		return fmt.Errorf("failed to write index.html: %w", err)
+	for _, path := range paths {
+		work <- path
 	}
+	close(work)
+	wg.Wait()
+	close(errs)
 
-	return nil
+	return <-errs
 }
 
 // generateDocPage creates an HTML page for a markdown file
 func (g *Generator) generateDocPage(path, content string, docsPages []utils.DocPage) error {
-	// Get the title from the markdown content
-	title := utils.GetTitleFromMarkdown(content)
-	if title == "" {
-		title = utils.PrettifyFilename(filepath.Base(path))
+	title := g.pageTitle(path)
+	lang := g.pageLanguage(path)
+	outputPath := g.docOutputPath(path)
+
+	page := g.newPage("doc", outputPath, lang, pagesForLang(docsPages, lang))
+	page.Source = path
+	data := page.Data
+
+	if fm := g.frontMatter[path]; fm.Description != "" {
+		data.Description = fm.Description
 	}
 
-	// Process relative links in the markdown
-	processedContent := utils.ProcessRelativeLinks(content, path, g.repoData.Owner, g.repoData.Name)
+	// Breadcrumbs follow the language-independent path and start at the
+	// language's home page. Directories link to their index page, if they
+	// have one in the language.
+	canonical := path
+	if c, ok := g.canonical[path]; ok {
+		canonical = c
+	}
+	pages := make(map[string]bool)
+	for _, p := range data.DocsPages {
+		pages[p.Path] = true
+	}
+	indexPage := func(dir string) string {
+		index := g.langPrefix(lang) + utils.GetOutputPath(dir+"/index.md", g.layout.DocsDir)
+		if index == outputPath || !pages[index] {
+			return ""
+		}
+		return index
+	}
+	home := utils.Breadcrumb{Title: g.message(lang, "Home"), Path: g.langPrefix(lang) + "index.html"}
+	data.Breadcrumbs = append([]utils.Breadcrumb{home}, utils.BuildBreadcrumbs(canonical, title, indexPage)...)
+
+	data.Languages = g.languageLinks(path, lang)
+	data.LastModified = g.formatDate(g.repoData.FileHistory[path].LastModified)
+	data.LastModifiedBy = g.repoData.FileHistory[path].LastAuthor
+	data.PageContributors = g.pageContributors(path)
+
+	data.PageTitle = title + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	data.PageHeading = title
+	data.PageContent = g.renderDocContent(path, content, data.RootPath)
+	data.NoIndex = g.frontMatter[path].NoIndex || matchPath(g.noindex, path)
+	data.PageTags = g.pageTags(path, data.RootPath)
+	if p := g.projectOf(path); p != nil && p.Readme == path {
+		data.GoImport, data.GoSource = g.goImportMeta(p.Dir)
+	}
+	if g.hasRawMarkdown(path) {
+		data.RawURL = filepath.Base(rawOutputPath(outputPath))
+	}
 
-	// Process image links to point to our local images
-	processedContent = processImageLinks(processedContent, path)
+	return g.renderPage(page)
+}
 
-	// Render markdown to HTML
-	contentHTML := renderMarkdown(processedContent)
+// docLinks returns the link rewriter of a markdown document shown on a page
+// at rootPath: links to the repository on GitHub, to files with a source
+// view and to other documents point at the site, and images at our local
+// images. On a combined page, sections maps the documents it includes to
+// the ids of their sections, which links to them point at, and other
+// relative links are resolved from the site root.
+func (g *Generator) docLinks(source, rootPath string, sections map[string]string) utils.LinkRewriter {
+	return func(dest string, image bool) string {
+		if !image && sections != nil {
+			doc, ok := g.linkedDocument(source, dest)
+			if file, _, _, self := g.selfLinkTarget(dest); self && g.rewriteSelfLinks {
+				doc, ok = g.findDocument(file)
+			}
+			if id := sections[doc]; ok && id != "" {
+				return "#" + id
+			}
+		}
+		if link := g.selfLink(dest, rootPath, image); link != dest {
+			return link
+		}
+		if image {
+			return g.imageLink(dest, rootPath)
+		}
+		if link := g.sourceViewLink(source, dest, rootPath); link != dest {
+			return link
+		}
+		if link := g.documentLink(source, dest); link != dest {
+			return relURL(rootPath, link)
+		}
+		if sections != nil {
+			return rootLink(source, dest)
+		}
+		return utils.RelativeLink(dest, source)
+	}
+}
 
-	// Create a copy of docsPages with current page marked as active
-	currentDocsPages := make([]utils.DocPage, len(docsPages))
-	copy(currentDocsPages, docsPages)
-	outputPath := utils.GetOutputPath(path, "docs")
-	// Ensure output directory exists
-	outPath := filepath.Join(g.outputDir, outputPath)
-	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-		return fmt.Errorf("failed to create directory for %s: %w", outPath, err)
+// renderDocContent renders the body of a document to HTML, with image links
+// relative to the given path of the site root
+func (g *Generator) renderDocContent(path, content, rootPath string) string {
+	if converted, ok := g.converted[path]; ok {
+		// Documents in other formats were converted to HTML when loaded
+		return addHeadingAnchors(processConvertedLinks(g.sanitizeContent(converted), g.imagesURL(rootPath)))
 	}
 
-	for i := range currentDocsPages {
-		if currentDocsPages[i].Path == outputPath {
-			currentDocsPages[i].IsActive = true
+	processedContent := content
+	if g.wiki[path] {
+		processedContent = utils.ProcessWikiLinks(processedContent, g.wikiLinks)
+	}
+
+	// Render markdown to HTML, pointing links at the pages of the site and
+	// images at our local images
+	return g.addTaskProgress(path, g.sanitizeContent(renderMarkdownLinks(processedContent, g.docLinks(path, rootPath, nil))))
+}
+
+// loadFrontMatter parses the front matter of every document and stores the
+// stripped content. Documents in other formats than markdown are converted to
+// HTML. Documents with invalid front matter are reported, as their title,
+// tags and draft flag are lost.
+func (g *Generator) loadFrontMatter(result *GenerationResult) {
+	var invalid []string
+	parse := func(path, content string) (utils.FrontMatter, string) {
+		fm, body, err := utils.ParseFrontMatter(content)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s in %s", err, filepath.ToSlash(path)))
 		}
+		return fm, body
 	}
 
-	// Prepare data for template
-	data := PageData{
-		RepoOwner:    g.repoData.Owner,
-		RepoName:     g.repoData.Name,
-		RepoFullName: g.repoData.Owner + "/" + g.repoData.Name,
-		Description:  g.repoData.Description,
-		CommitCount:  g.repoData.CommitCount,
-		License:      g.repoData.License,
-		RepoURL:      g.repoData.URL,
-		LastUpdate:   g.repoData.LastCommitDate.Format("January 2, 2006"),
+	for path, content := range g.repoData.MarkdownFiles {
+		fm, body := parse(path, content)
+		g.frontMatter[path] = fm
+		g.markdown[path] = body
+	}
 
-		DocsPages:   currentDocsPages,
-		CurrentPage: outputPath,
-		PageTitle:   title + " - " + g.repoData.Owner + "/" + g.repoData.Name,
-		PageContent: contentHTML,
+	for path, content := range g.repoData.DocumentFiles {
+		fm, body := parse(path, content)
+		g.frontMatter[path] = fm
+		g.markdown[path] = body
+		g.converted[path] = g.convertDocument(path, body)
+	}
 
-		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	for page, content := range g.repoData.WikiPages {
+		doc := utils.FilePath(path.Join("wiki", utils.URLPath(page)))
+		if _, exists := g.markdown[doc]; exists {
+			g.logger.Warn("Skipping wiki page that conflicts with a repository file", "path", doc)
+			continue
+		}
+		fm, body := parse(doc, content)
+		g.frontMatter[doc] = fm
+		g.markdown[doc] = body
+		g.wiki[doc] = true
+		g.wikiLinks[utils.WikiPageName(page)] = path.Base(g.docOutputPath(doc))
 	}
 
-	// Render template
-	var buf bytes.Buffer
-	if err := g.templateCache["doc"].Execute(&buf, data); err != nil {
-		return fmt.Errorf("failed to execute doc template: %w", err)
+	sort.Strings(invalid)
+	for _, msg := range invalid {
+		g.warn(result, msg)
 	}
+}
 
-	// Ensure output directory exists
-	outPath = filepath.Join(g.outputDir, outputPath)
-	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-		return fmt.Errorf("failed to create directory for %s: %w", outPath, err)
+// findIndexPages picks the README of each subdirectory, per language, to
+// generate as the index page of the directory. Other READMEs in the same
+// directory are skipped.
+func (g *Generator) findIndexPages() {
+	best := make(map[[2]string]string)
+	for path := range g.markdown {
+		if _, converted := g.converted[path]; converted || g.wiki[path] || !isReadmeFile(filepath.Base(path)) {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if dir == "." {
+			continue
+		}
+		key := [2]string{dir, g.pageLanguage(path)}
+		if current, ok := best[key]; !ok || git.PreferReadme(path, current) {
+			best[key] = path
+		}
+	}
+	for _, path := range best {
+		g.indexPages[path] = true
 	}
+}
+
+// skipDocPage reports whether a markdown file is excluded from the documentation pages
+func (g *Generator) skipDocPage(path string) bool {
+	_, converted := g.converted[path]
+	return (isReadmeFile(filepath.Base(path)) && !converted && !g.indexPages[path]) || path == g.changelogPath || g.isCommunityFile(path) || g.isPost(path) || path == g.landingPath || g.frontMatter[path].Draft ||
+		matchPath(g.exclude, path)
+}
 
-	// Write to file
-	if err := os.WriteFile(outPath, buf.Bytes(), 0o644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", outPath, err)
+// pageTitle determines the title of a doc page from front matter, its first heading or its filename
+func (g *Generator) pageTitle(path string) string {
+	if title := g.frontMatter[path].Title; title != "" {
+		return title
+	}
+	if project := g.projectOf(path); project != nil && project.Readme == path {
+		return project.Title
 	}
+	if converted, ok := g.converted[path]; ok {
+		if title := titleFromHTML(converted); title != "" {
+			return title
+		}
+		return utils.PrettifyFilename(filepath.Base(path))
+	}
+	if title := utils.GetTitleFromMarkdown(g.markdown[path]); title != "" {
+		return title
+	}
+	if g.indexPages[path] {
+		return utils.PrettifyFilename(filepath.Base(filepath.Dir(path)))
+	}
+	return utils.PrettifyFilename(filepath.Base(path))
+}
 
-	return nil
+// docOutputPath returns the output path of a doc page, as a URL path,
+// honoring a front matter slug. Translations are generated in the tree of
+// their language.
+func (g *Generator) docOutputPath(source string) string {
+	doc := utils.URLPath(source)
+	if canonical, ok := g.canonical[source]; ok {
+		doc = utils.URLPath(canonical)
+	}
+	if slug := g.frontMatter[source].Slug; slug != "" {
+		doc = path.Join(path.Dir(doc), slug+".md")
+	} else if g.indexPages[source] {
+		doc = path.Join(path.Dir(doc), "index.md")
+	}
+	// Wiki pages share a single namespace, so they are generated flat under wiki/
+	if g.wiki[source] {
+		return utils.GetOutputPath(path.Base(doc), "wiki")
+	}
+	return path.Join(g.langPrefix(g.pageLanguage(source)), utils.GetOutputPath(doc, g.layout.DocsDir))
+}
+
+// pageContributors returns the contributors of a file, using the GitHub
+// accounts resolved for the repository's top contributors where possible
+func (g *Generator) pageContributors(path string) []git.Contributor {
+	return g.withAccounts(g.repoData.FileHistory[path].Contributors)
+}
+
+// withAccounts returns a copy of contributors with the GitHub accounts
+// resolved for the repository's top contributors
+func (g *Generator) withAccounts(list []git.Contributor) []git.Contributor {
+	accounts := make(map[string]git.Contributor)
+	for _, c := range g.repoData.Contributors {
+		if c.Login != "" {
+			accounts[c.Email] = c
+		}
+	}
+
+	contributors := append([]git.Contributor(nil), list...)
+	for i, c := range contributors {
+		if account, ok := accounts[c.Email]; ok {
+			contributors[i].Login = account.Login
+			contributors[i].ProfileURL = account.ProfileURL
+			contributors[i].AvatarURL = account.AvatarURL
+		}
+	}
+	return contributors
+}
+
+// matchPath reports whether a source path matches one of the glob patterns.
+// Patterns ending in "/" match everything under that directory.
+func matchPath(patterns []string, path string) bool {
+	path = filepath.ToSlash(path)
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(path, pattern) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(filepath.FromSlash(pattern), filepath.FromSlash(path)); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // isReadmeFile checks if a file is a README
@@ -336,59 +1328,38 @@ func isReadmeFile(filename string) bool {
 
 // renderMarkdown converts markdown content to HTML
 func renderMarkdown(md string) string {
-	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
+	return renderMarkdownLinks(md, nil)
+}
+
+// renderMarkdownLinks converts markdown content to HTML, passing the
+// destination of every link and image, including <img> tags, through
+// rewrite if it isn't nil
+func renderMarkdownLinks(md string, rewrite utils.LinkRewriter) string {
+	// Math is handled by convertMath, so dollar amounts aren't taken for math
+	extensions := parser.CommonExtensions&^parser.MathJax | parser.NoEmptyLineBeforeBlock
 	p := parser.NewWithExtensions(extensions)
 	doc := p.Parse([]byte(md))
+	// Heading ids follow GitHub's, so links to sections copied from GitHub
+	// keep working
+	resolveAnchorLinks(doc, setHeadingIDs(doc))
+	if rewrite != nil {
+		utils.RewriteLinks(doc, rewrite)
+		rewriteHTMLImageNodes(doc, rewrite)
+	}
 
 	htmlFlags := html.CommonFlags | html.HrefTargetBlank
 	opts := html.RendererOptions{Flags: htmlFlags}
 	renderer := html.NewRenderer(opts)
 
-	return string(markdown.Render(doc, renderer))
+	return addHeadingAnchors(renderTaskItems(string(markdown.Render(doc, renderer))))
 }
 
-// processImageLinks updates image links to point to our local images
-func processImageLinks(content, filePath string) string {
-	// Replace image links with links to our local images directory
-	re := utils.GetImageLinkRegex()
-
-	baseDir := filepath.Dir(filePath)
-
-	return re.ReplaceAllStringFunc(content, func(match string) string {
-		submatch := re.FindStringSubmatch(match)
-		if len(submatch) < 3 {
-			return match
-		}
-
-		altText := submatch[1]
-		imagePath := submatch[2]
-
-		// Skip absolute URLs
-		if strings.HasPrefix(imagePath, "http") {
-			return match
-		}
-
-		// Make the path relative to the root
-		if !strings.HasPrefix(imagePath, "/") {
-			// Handle ./image.jpg style paths
-			if strings.HasPrefix(imagePath, "./") {
-				imagePath = imagePath[2:]
-			}
-
-			// If in a subdirectory, make path relative to root
-			if baseDir != "." {
-				imagePath = filepath.Join(baseDir, imagePath)
-			}
-		} else {
-			// Remove leading slash if any
-			imagePath = strings.TrimPrefix(imagePath, "/")
-		}
-
-		// Create a path to our local images directory
-		localPath := "../images/" + filepath.Base(imagePath)
+var headingRe = regexp.MustCompile(`(?s)<h([1-4]) id="([^"]+)">(.*?)</h[1-4]>`)
 
-		return fmt.Sprintf("![%s](%s)", altText, localPath)
-	})
+// addHeadingAnchors appends a permalink anchor to h1-h4 headings that have an ID
+func addHeadingAnchors(content string) string {
+	return headingRe.ReplaceAllString(content,
+		`<h$1 id="$2">$3 <a class="heading-anchor" href="#$2" aria-label="Permalink to this section">#</a></h$1>`)
 }
 
 // copyFile copies a file from src to dst
@@ -0,0 +1,256 @@
+package generator
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+)
+
+// testRepo returns the data of a repository with the given markdown files,
+// written to a working copy in a temporary directory
+func testRepo(t *testing.T, files map[string]string) *git.RepositoryData {
+	t.Helper()
+	repo := &git.RepositoryData{
+		Owner:         "owner",
+		Name:          "repo",
+		URL:           "https://github.com/owner/repo",
+		Path:          t.TempDir(),
+		MarkdownFiles: make(map[string]string),
+		DocumentFiles: make(map[string]string),
+		HTMLFiles:     make(map[string]string),
+		Files:         make(map[string]int64),
+		ImageFiles:    make(map[string]string),
+	}
+	for name, content := range files {
+		name = filepath.FromSlash(name)
+		path := filepath.Join(repo.Path, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		repo.MarkdownFiles[name] = content
+		repo.Files[name] = int64(len(content))
+		if name == "README.md" {
+			repo.ReadmePath = name
+			repo.ReadmeContent = content
+		}
+	}
+	return repo
+}
+
+// generate builds the site of a repository and returns its output directory
+func generate(t *testing.T, g *Generator) string {
+	t.Helper()
+	g.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := g.GenerateSite(); err != nil {
+		t.Fatalf("GenerateSite: %v", err)
+	}
+	return g.outputDir
+}
+
+// readPage returns the content of a generated page
+func readPage(t *testing.T, outputDir, page string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(page)))
+	if err != nil {
+		t.Fatalf("reading %s: %v", page, err)
+	}
+	return string(content)
+}
+
+func TestTemplateFieldsAreEscaped(t *testing.T) {
+	const script = "<script>alert(1)</script>"
+	repo := testRepo(t, map[string]string{
+		"README.md":    "# Project\n\nReadme.\n",
+		"docs/page.md": "---\ntitle: " + script + "\n---\n\nBody.\n",
+	})
+	repo.Description = script
+	repo.Topics = []string{script}
+	g := NewGenerator(repo, t.TempDir())
+	g.SetSanitize(true)
+	out := generate(t, g)
+
+	for _, page := range []string{"index.html", "docs/docs/page.html"} {
+		if content := readPage(t, out, page); strings.Contains(content, script) {
+			t.Errorf("%s contains an unescaped %s", page, script)
+		}
+	}
+}
+
+func TestTemplateURLsAreCheckedWhenSanitizing(t *testing.T) {
+	repo := testRepo(t, map[string]string{
+		"README.md":    "# Project\n\nReadme.\n",
+		"docs/page.md": "# Page\n\n{{< video src=\"javascript:alert(2)\" >}}\n\n{{< tabs >}}{{< tab \"A\" >}}<script>alert(3)</script>{{< /tab >}}{{< /tabs >}}\n",
+	})
+	repo.Homepage = "javascript:alert(1)"
+	g := NewGenerator(repo, t.TempDir())
+	g.SetSanitize(true)
+	g.SetFooter(Footer{Links: []FooterLink{{Title: "Evil", URL: "javascript:alert(4)"}}})
+	out := generate(t, g)
+
+	for _, page := range []string{"index.html", "docs/docs/page.html"} {
+		content := readPage(t, out, page)
+		for _, unsafe := range []string{`="javascript:`, "<script>alert"} {
+			if strings.Contains(content, unsafe) {
+				t.Errorf("%s contains %s", page, unsafe)
+			}
+		}
+	}
+	if content := readPage(t, out, "docs/docs/page.html"); !strings.Contains(content, `type="radio"`) {
+		t.Errorf("the tabs of docs/page.md were sanitized away")
+	}
+}
+
+func TestSinglePageLinksResolveLikeDocPages(t *testing.T) {
+	repo := testRepo(t, map[string]string{
+		"README.md":            "# Project\n\nSee [the setup](docs/guides/setup.md).\n",
+		"docs/index.md":        "# Docs\n\n[Setup](Guides/Setup.MD#step-2) [Missing](missing.md)\n",
+		"docs/guides/setup.md": "# Setup\n\n## Step 2\n",
+	})
+	g := NewGenerator(repo, t.TempDir())
+	g.SetSinglePage(true)
+	g.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	result, err := g.GenerateSite()
+	if err != nil {
+		t.Fatalf("GenerateSite: %v", err)
+	}
+
+	all := readPage(t, g.outputDir, "all.html")
+	setup := `href="#` + sectionID(g.docOutputPath(filepath.FromSlash("docs/guides/setup.md"))) + `"`
+	if strings.Count(all, setup) < 2 {
+		t.Errorf("all.html doesn't link both mentions of the setup guide to its section with %s", setup)
+	}
+	if strings.Contains(strings.ToLower(all), "setup.md") {
+		t.Errorf("all.html still links to a markdown file")
+	}
+
+	warned := false
+	for _, w := range result.Warnings {
+		if strings.Contains(w, "missing.md") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("no warning about the link to missing.md in %q", result.Warnings)
+	}
+}
+
+// TestConcurrentDocPages renders many documents in several languages with
+// several workers. Run it with -race to check the state the workers share.
+func TestConcurrentDocPages(t *testing.T) {
+	files := map[string]string{"README.md": "# Project\n\nReadme.\n"}
+	for _, lang := range []string{"", "es/", "fr/"} {
+		for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			files["docs/"+lang+name+".md"] = "---\ntags: [shared, " + name + "]\n---\n\n# " + name + "\n\n" +
+				"[next](b.md) [home](/README.md#project) ![image](../img/x.png)\n\n{{< include \"/README.md\" >}}\n"
+		}
+	}
+	g := NewGenerator(testRepo(t, files), t.TempDir())
+	g.SetLanguages([]string{"en", "es", "fr"})
+	g.SetJobs(8)
+	out := generate(t, g)
+
+	for _, page := range []string{"docs/docs/a.html", "es/docs/docs/a.html", "fr/docs/docs/f.html"} {
+		if content := readPage(t, out, page); !strings.Contains(content, "Readme.") {
+			t.Errorf("%s doesn't include the README", page)
+		}
+	}
+}
+
+func TestRenderHooksBelongToTheirGenerator(t *testing.T) {
+	files := map[string]string{
+		"README.md":    "# Project\n\nReadme.\n",
+		"docs/page.md": "# Page\n\n{{version}}\n",
+	}
+	hooked := NewGenerator(testRepo(t, files), t.TempDir())
+	hooked.AddPreRenderHook(func(path, markdown string) (string, error) {
+		return strings.ReplaceAll(markdown, "{{version}}", "v1.2.3"), nil
+	})
+	hooked.AddPostRenderHook(func(path, html string) (string, error) {
+		return strings.Replace(html, "</body>", "<!-- hooked --></body>", 1), nil
+	})
+	plain := NewGenerator(testRepo(t, files), t.TempDir())
+
+	hookedPage := readPage(t, generate(t, hooked), "docs/docs/page.html")
+	if !strings.Contains(hookedPage, "v1.2.3") || !strings.Contains(hookedPage, "<!-- hooked -->") {
+		t.Errorf("the hooks of a generator didn't run on its pages")
+	}
+	plainPage := readPage(t, generate(t, plain), "docs/docs/page.html")
+	if strings.Contains(plainPage, "v1.2.3") || strings.Contains(plainPage, "<!-- hooked -->") {
+		t.Errorf("the hooks of a generator ran on the pages of another")
+	}
+}
+
+func TestShortcodesBelongToTheirGenerator(t *testing.T) {
+	files := map[string]string{
+		"README.md":    "# Project\n\nReadme.\n",
+		"docs/page.md": "# Page\n\n{{< version >}}\n\n{{< note >}}Built in.{{< /note >}}\n",
+	}
+	custom := NewGenerator(testRepo(t, files), t.TempDir())
+	custom.RegisterShortcode("version", func(call *ShortcodeCall) (string, error) {
+		return `<span class="version">v1.2.3</span>`, nil
+	})
+	plain := NewGenerator(testRepo(t, files), t.TempDir())
+
+	customPage := readPage(t, generate(t, custom), "docs/docs/page.html")
+	if !strings.Contains(customPage, "v1.2.3") || !strings.Contains(customPage, "admonition-note") {
+		t.Errorf("the registered or built-in shortcodes weren't expanded")
+	}
+	plainPage := readPage(t, generate(t, plain), "docs/docs/page.html")
+	if strings.Contains(plainPage, "v1.2.3") || !strings.Contains(plainPage, "admonition-note") {
+		t.Errorf("a shortcode registered with another generator was expanded, or a built-in one wasn't")
+	}
+}
+
+func TestInvalidFrontMatterIsReported(t *testing.T) {
+	repo := testRepo(t, map[string]string{
+		"README.md":     "# Project\n\nReadme.\n",
+		"docs/draft.md": "---\ntitle: [Draft\ndraft: true\n---\n\nSecret.\n",
+	})
+	g := NewGenerator(repo, t.TempDir())
+	g.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	result, err := g.GenerateSite()
+	if err != nil {
+		t.Fatalf("GenerateSite: %v", err)
+	}
+
+	warned := false
+	for _, w := range result.Warnings {
+		if strings.Contains(w, "invalid front matter") && strings.Contains(w, "docs/draft.md") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("no warning about the front matter of docs/draft.md in %q", result.Warnings)
+	}
+	if page := readPage(t, g.outputDir, "docs/docs/draft.html"); strings.Contains(page, "draft: true") {
+		t.Errorf("the invalid front matter was rendered into the page")
+	}
+}
+
+func TestBreadcrumbsLinkDirectoryIndexPages(t *testing.T) {
+	repo := testRepo(t, map[string]string{
+		"README.md":             "# Project\n\nReadme.\n",
+		"docs/guides/README.md": "# Guides\n\nAll guides.\n",
+		"docs/guides/setup.md":  "# Setup\n\nSteps.\n",
+	})
+	out := generate(t, NewGenerator(repo, t.TempDir()))
+
+	setup := readPage(t, out, "docs/docs/guides/setup.html")
+	if !strings.Contains(setup, `<a href="../../../docs/docs/guides/index.html">guides</a>`) {
+		t.Errorf("the guides crumb of the setup page doesn't link to the index page of docs/guides")
+	}
+	if !strings.Contains(setup, `<span>docs</span>`) {
+		t.Errorf("the docs crumb, which has no index page, is linked")
+	}
+	if index := readPage(t, out, "docs/docs/guides/index.html"); strings.Contains(index, `>guides</a>`) {
+		t.Errorf("the index page of docs/guides links to itself")
+	}
+}
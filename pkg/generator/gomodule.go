@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// GoModuleInfo is the Go module panel of the main page
+type GoModuleInfo struct {
+	utils.GoModule
+	// GetCommand adds the module to another module's dependencies
+	GetCommand string
+	// DocsURL is the module's page on pkg.go.dev, for public module paths
+	DocsURL string
+}
+
+// goModule reads the go.mod at the root of the repository, or returns nil if
+// the repository isn't a Go module
+func (g *Generator) goModule() *GoModuleInfo {
+	mod, ok := g.readGoMod("")
+	if !ok {
+		return nil
+	}
+
+	info := &GoModuleInfo{
+		GoModule:   mod,
+		GetCommand: "go get " + mod.Path + "@latest",
+	}
+	// Only paths starting with a domain can be fetched, and documented, publicly
+	if first, _, _ := strings.Cut(mod.Path, "/"); strings.Contains(first, ".") {
+		info.DocsURL = "https://pkg.go.dev/" + mod.Path
+	}
+	return info
+}
+
+// readGoMod parses the go.mod of a directory relative to the repository root
+func (g *Generator) readGoMod(dir string) (utils.GoModule, bool) {
+	path := filepath.Join(g.repoData.Path, filepath.FromSlash(dir), "go.mod")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return utils.GoModule{}, false
+	}
+	mod, ok := utils.ParseGoMod(string(content))
+	if !ok {
+		g.logger.Warn("go.mod has no module directive", "path", filepath.Join(dir, "go.mod"))
+	}
+	return mod, ok
+}
+
+// SetGoImport makes the site host the vanity import path prefix of the
+// repository, e.g. go.example.org/project: index.html gets go-import and
+// go-source meta tags pointing the go command and documentation sites at the
+// GitHub repository, as do the pages of projects with their own go.mod
+func (g *Generator) SetGoImport(prefix string) {
+	g.goImport = strings.TrimSuffix(prefix, "/")
+}
+
+// goImportMeta returns the content of the go-import and go-source meta tags
+// of the module in dir, relative to the repository root, or "" without a
+// vanity import path
+func (g *Generator) goImportMeta(dir string) (goImport, goSource string) {
+	if g.goImport == "" {
+		return "", ""
+	}
+
+	// The module's own path, if it is under the prefix
+	importPath := g.goImport
+	if dir != "" {
+		importPath += "/" + dir
+	}
+	if mod, ok := g.readGoMod(dir); ok && (mod.Path == g.goImport || strings.HasPrefix(mod.Path, g.goImport+"/")) {
+		importPath = mod.Path
+	}
+
+	tree := g.branch()
+	if dir != "" {
+		tree += "/" + dir
+	}
+
+	goImport = g.goImport + " git " + g.repoData.URL
+	goSource = importPath + " " + g.repoData.URL + " " +
+		g.repoData.URL + "/tree/" + tree + "{/dir} " +
+		g.repoData.URL + "/blob/" + tree + "{/dir}/{file}#L{line}"
+	return goImport, goSource
+}
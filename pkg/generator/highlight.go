@@ -0,0 +1,299 @@
+package generator
+
+import (
+	"html"
+	"path"
+	"strings"
+)
+
+// syntax describes the tokens of a language well enough to highlight its
+// comments, strings, numbers and keywords
+type syntax struct {
+	lineComments []string
+	blockComment [2]string
+	// quotes start strings that end at the same quote or the end of the line,
+	// rawQuotes strings without escapes that can span lines
+	quotes    string
+	rawQuotes []string
+	keywords  map[string]bool
+}
+
+// words builds a keyword set
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		rawQuotes:    []string{"`"},
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto
+			if import interface map package range return select struct switch type var true false nil iota`),
+	}
+	goModSyntax = &syntax{
+		lineComments: []string{"//"},
+		quotes:       `"`,
+		rawQuotes:    []string{"`"},
+		keywords:     words(`module go toolchain require replace exclude retract godebug tool`),
+	}
+	pythonSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		rawQuotes:    []string{`"""`, `'''`},
+		keywords: words(`and as assert async await break class continue def del elif else except False
+			finally for from global if import in is lambda None nonlocal not or pass raise return True try
+			while with yield`),
+	}
+	jsSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		rawQuotes:    []string{"`"},
+		keywords: words(`async await break case catch class const continue debugger default delete do
+			else enum export extends false finally for function if implements import in instanceof interface
+			let new null of return super switch this throw true try type typeof undefined var void while
+			with yield`),
+	}
+	cSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		keywords: words(`auto bool break case catch char class const continue default delete do double
+			else enum extern false float for goto if inline int long namespace new nullptr NULL private
+			protected public register return short signed sizeof static struct switch template this throw
+			true try typedef typename union unsigned using virtual void volatile while`),
+	}
+	javaSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		keywords: words(`abstract boolean break byte case catch char class continue default do double
+			else enum extends false final finally float for if implements import instanceof int interface
+			long new null package private protected public return short static super switch synchronized
+			this throw throws true try var void volatile while`),
+	}
+	rustSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"`,
+		keywords: words(`as async await break const continue crate dyn else enum extern false fn for if
+			impl in let loop match mod move mut pub ref return self Self static struct super trait true type
+			unsafe use where while`),
+	}
+	rubySyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words(`and begin class def do else elsif end ensure false for if in module nil not or
+			require rescue return self then true unless until while yield`),
+	}
+	shellSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words(`case do done elif else esac export fi for function if in local return then
+			until while`),
+	}
+	configSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords:     words(`true false null`),
+	}
+	jsonSyntax = &syntax{
+		quotes:   `"`,
+		keywords: words(`true false null`),
+	}
+	cssSyntax = &syntax{
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+	}
+	htmlSyntax = &syntax{
+		blockComment: [2]string{"<!--", "-->"},
+	}
+)
+
+// syntaxes are the highlighted languages by file extension
+var syntaxes = map[string]*syntax{
+	".go":    goSyntax,
+	".mod":   goModSyntax,
+	".py":    pythonSyntax,
+	".js":    jsSyntax,
+	".mjs":   jsSyntax,
+	".ts":    jsSyntax,
+	".c":     cSyntax,
+	".h":     cSyntax,
+	".cc":    cSyntax,
+	".cpp":   cSyntax,
+	".hpp":   cSyntax,
+	".java":  javaSyntax,
+	".kt":    javaSyntax,
+	".cs":    javaSyntax,
+	".rs":    rustSyntax,
+	".rb":    rubySyntax,
+	".sh":    shellSyntax,
+	".bash":  shellSyntax,
+	".yml":   configSyntax,
+	".yaml":  configSyntax,
+	".toml":  configSyntax,
+	".json":  jsonSyntax,
+	".css":   cssSyntax,
+	".scss":  cssSyntax,
+	".html":  htmlSyntax,
+	".htm":   htmlSyntax,
+	".xml":   htmlSyntax,
+	".svg":   htmlSyntax,
+	".proto": cSyntax,
+}
+
+// token is a run of source text with the CSS class it is highlighted with,
+// if any
+type token struct {
+	class string
+	text  string
+}
+
+// highlightLines returns the lines of a source file as HTML, with comments,
+// strings, numbers and keywords wrapped in spans for the languages that are
+// recognized from the file's extension
+func highlightLines(file, content string) []string {
+	content = strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	tokens := []token{{text: content}}
+	if s := syntaxes[strings.ToLower(path.Ext(file))]; s != nil {
+		tokens = s.tokenize(content)
+	}
+
+	// Tokens spanning several lines are split, so every line is closed
+	lines := []string{""}
+	for _, t := range tokens {
+		for i, part := range strings.Split(t.text, "\n") {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			if part == "" {
+				continue
+			}
+			if t.class == "" {
+				lines[len(lines)-1] += html.EscapeString(part)
+			} else {
+				lines[len(lines)-1] += `<span class="` + t.class + `">` + html.EscapeString(part) + "</span>"
+			}
+		}
+	}
+	return lines
+}
+
+// tokenize splits source text into highlighted and plain tokens
+func (s *syntax) tokenize(src string) []token {
+	var tokens []token
+	plain := 0 // start of the pending plain text
+	emit := func(start, end int, class string) {
+		if plain < start {
+			tokens = append(tokens, token{text: src[plain:start]})
+		}
+		tokens = append(tokens, token{class: class, text: src[start:end]})
+		plain = end
+	}
+
+	for i := 0; i < len(src); {
+		rest := src[i:]
+		switch {
+		case s.blockComment[0] != "" && strings.HasPrefix(rest, s.blockComment[0]):
+			end := strings.Index(rest[len(s.blockComment[0]):], s.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(s.blockComment[0]) + len(s.blockComment[1])
+			}
+			emit(i, i+end, "comment")
+			i += end
+			continue
+		case hasAnyPrefix(rest, s.lineComments):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(i, i+end, "comment")
+			i += end
+			continue
+		}
+
+		if quote := longestPrefix(rest, s.rawQuotes); quote != "" {
+			end := strings.Index(rest[len(quote):], quote)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(quote)
+			}
+			emit(i, i+end, "string")
+			i += end
+			continue
+		}
+
+		c := src[i]
+		switch {
+		case strings.IndexByte(s.quotes, c) >= 0:
+			end := 1
+			for end < len(rest) && rest[end] != c && rest[end] != '\n' {
+				if rest[end] == '\\' && end+1 < len(rest) {
+					end++
+				}
+				end++
+			}
+			if end < len(rest) && rest[end] == c {
+				end++
+			}
+			emit(i, i+end, "string")
+			i += end
+		case isIdentStart(c):
+			end := 1
+			for end < len(rest) && (isIdentStart(rest[end]) || isDigit(rest[end])) {
+				end++
+			}
+			if s.keywords[rest[:end]] {
+				emit(i, i+end, "keyword")
+			}
+			i += end
+		case isDigit(c):
+			end := 1
+			for end < len(rest) && (isIdentStart(rest[end]) || isDigit(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			emit(i, i+end, "number")
+			i += end
+		default:
+			i++
+		}
+	}
+	if plain < len(src) {
+		tokens = append(tokens, token{text: src[plain:]})
+	}
+	return tokens
+}
+
+// hasAnyPrefix reports whether s starts with one of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	return longestPrefix(s, prefixes) != ""
+}
+
+// longestPrefix returns the longest of the prefixes s starts with, or ""
+func longestPrefix(s string, prefixes []string) string {
+	longest := ""
+	for _, p := range prefixes {
+		if len(p) > len(longest) && strings.HasPrefix(s, p) {
+			longest = p
+		}
+	}
+	return longest
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
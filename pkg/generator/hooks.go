@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PreRenderHook transforms the markdown of a document, with its front matter
+// stripped, before it is rendered. path is the source path of the document.
+type PreRenderHook func(path, markdown string) (string, error)
+
+// PostRenderHook transforms the HTML of a generated page before it is
+// written. path is the output path of the page, relative to the output directory.
+type PostRenderHook func(path, html string) (string, error)
+
+// AddPreRenderHook adds a hook that transforms the markdown of every
+// document before it is rendered, e.g. to expand custom shortcodes. Documents
+// in other formats than markdown are not passed to pre-render hooks. Hooks
+// run in the order they were added.
+func (g *Generator) AddPreRenderHook(hook PreRenderHook) {
+	g.preRenderHooks = append(g.preRenderHooks, hook)
+}
+
+// AddPostRenderHook adds a hook that transforms the HTML of every generated
+// page, e.g. to enforce a link policy. Pages are rendered concurrently, so
+// hooks must be safe to call from several goroutines. Hooks run in the order
+// they were added.
+func (g *Generator) AddPostRenderHook(hook PostRenderHook) {
+	g.postRenderHooks = append(g.postRenderHooks, hook)
+}
+
+// runPreRenderHooks passes the markdown of every document through the
+// pre-render hooks, in a stable order
+func (g *Generator) runPreRenderHooks() error {
+	if len(g.preRenderHooks) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for path := range g.markdown {
+		if _, converted := g.converted[path]; !converted {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		content := g.markdown[path]
+		for _, hook := range g.preRenderHooks {
+			var err error
+			if content, err = hook(path, content); err != nil {
+				return fmt.Errorf("pre-render hook failed for %s: %w", path, err)
+			}
+		}
+		g.markdown[path] = content
+	}
+	return nil
+}
+
+// runPostRenderHooks passes the HTML of a page through the post-render hooks
+func (g *Generator) runPostRenderHooks(path, html string) (string, error) {
+	for _, hook := range g.postRenderHooks {
+		var err error
+		if html, err = hook(path, html); err != nil {
+			return "", fmt.Errorf("post-render hook failed for %s: %w", path, err)
+		}
+	}
+	return html, nil
+}
@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"html"
+	"path"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+	"github.com/gomarkdown/markdown/ast"
+	nethtml "golang.org/x/net/html"
+)
+
+// imageLink points a relative image of a document at its copy in the images
+// directory, for a page at rootPath. Absolute URLs and data URIs are left
+// alone.
+func (g *Generator) imageLink(src, rootPath string) string {
+	if src == "" || strings.Contains(src, ":") || strings.HasPrefix(src, "#") {
+		return src
+	}
+
+	// Images are copied flat into the images directory, so only the name of
+	// the image matters
+	return g.imagesURL(rootPath) + path.Base(src)
+}
+
+// rewriteHTMLImageNodes passes the images of the raw HTML of a parsed
+// markdown document, such as <img> tags used to size an image, through
+// rewrite
+func rewriteHTMLImageNodes(doc ast.Node, rewrite utils.LinkRewriter) {
+	images := func(src string) string { return rewrite(src, true) }
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := node.(type) {
+		case *ast.HTMLBlock:
+			n.Literal = []byte(rewriteHTMLImages(string(n.Literal), images))
+		case *ast.HTMLSpan:
+			n.Literal = []byte(rewriteHTMLImages(string(n.Literal), images))
+		}
+		return ast.GoToNext
+	})
+}
+
+// rewriteHTMLImages passes the src and srcset of the <img> tags of an HTML
+// fragment, and the srcset of its <source> tags, as used in <picture>,
+// through rewrite. The rest of the fragment is kept as it is.
+func rewriteHTMLImages(fragment string, rewrite func(src string) string) string {
+	if !strings.Contains(fragment, "<") {
+		return fragment
+	}
+	var out strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			return out.String()
+		}
+		if tt != nethtml.StartTagToken && tt != nethtml.SelfClosingTagToken {
+			out.Write(z.Raw())
+			continue
+		}
+		raw := string(z.Raw())
+		token := z.Token()
+		if token.Data != "img" && token.Data != "source" {
+			out.WriteString(raw)
+			continue
+		}
+
+		changed := false
+		for i, attr := range token.Attr {
+			value := attr.Val
+			switch {
+			case attr.Key == "src" && token.Data == "img":
+				value = rewrite(value)
+			case attr.Key == "srcset":
+				value = rewriteSrcset(value, rewrite)
+			}
+			if value != attr.Val {
+				token.Attr[i].Val = value
+				changed = true
+			}
+		}
+		if !changed {
+			out.WriteString(raw)
+			continue
+		}
+		out.WriteString("<" + token.Data)
+		for _, attr := range token.Attr {
+			out.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+		}
+		if tt == nethtml.SelfClosingTagToken {
+			out.WriteString(" /")
+		}
+		out.WriteString(">")
+	}
+}
+
+// rewriteSrcset passes the URLs of a srcset attribute value through rewrite,
+// keeping their width and density descriptors
+func rewriteSrcset(srcset string, rewrite func(src string) string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = rewrite(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
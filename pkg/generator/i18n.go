@@ -0,0 +1,219 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+	"github.com/go-i2p/go-gh-page/pkg/templates"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// LanguageLink is an entry of the language switcher
+type LanguageLink struct {
+	Code string
+	Name string
+	// Path is relative to the site root
+	Path     string
+	IsActive bool
+}
+
+// languageNames are the native names shown in the language switcher
+var languageNames = map[string]string{
+	"ar": "العربية",
+	"de": "Deutsch",
+	"en": "English",
+	"es": "Español",
+	"fr": "Français",
+	"it": "Italiano",
+	"ja": "日本語",
+	"ko": "한국어",
+	"nl": "Nederlands",
+	"pl": "Polski",
+	"pt": "Português",
+	"ru": "Русский",
+	"tr": "Türkçe",
+	"uk": "Українська",
+	"zh": "中文",
+}
+
+// SetLanguages sets the content languages of the site. The first one is the
+// default language, generated at the site root; every other language gets its
+// own tree under a directory named after its code.
+func (g *Generator) SetLanguages(langs []string) {
+	g.languages = nil
+	for _, lang := range langs {
+		g.languages = append(g.languages, strings.ToLower(lang))
+	}
+}
+
+// defaultLang returns the code of the default language
+func (g *Generator) defaultLang() string {
+	if len(g.languages) == 0 {
+		return "en"
+	}
+	return g.languages[0]
+}
+
+// messages returns the UI strings of a language for the templates
+func (g *Generator) messages(lang string) map[string]string {
+	g.catalogsMu.Lock()
+	defer g.catalogsMu.Unlock()
+	if catalog, ok := g.catalogs[lang]; ok {
+		return catalog
+	}
+	catalog := templates.Catalog(lang)
+	g.catalogs[lang] = catalog
+	return catalog
+}
+
+// message returns a single UI string of a language
+func (g *Generator) message(lang, key string) string {
+	return g.messages(lang)[key]
+}
+
+// isLanguage reports whether a code is one of the configured languages
+func (g *Generator) isLanguage(code string) bool {
+	for _, lang := range g.languages {
+		if strings.EqualFold(lang, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectLanguage returns the language of a source path and the path with the
+// language marker removed. Languages are marked by a directory named after the
+// language (docs/es/intro.md) or a suffix before the extension (README.es.md).
+// Documents without a marker are in the default language.
+func (g *Generator) detectLanguage(path string) (string, string) {
+	if len(g.languages) < 2 {
+		return g.defaultLang(), path
+	}
+
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts[:len(parts)-1] {
+		if g.isLanguage(part) {
+			canonical := append(append([]string(nil), parts[:i]...), parts[i+1:]...)
+			return strings.ToLower(part), filepath.FromSlash(strings.Join(canonical, "/"))
+		}
+	}
+
+	base := parts[len(parts)-1]
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if i := strings.LastIndex(stem, "."); i > 0 && g.isLanguage(stem[i+1:]) {
+		parts[len(parts)-1] = stem[:i] + ext
+		return strings.ToLower(stem[i+1:]), filepath.FromSlash(strings.Join(parts, "/"))
+	}
+
+	return g.defaultLang(), path
+}
+
+// assignLanguages records the language and language-independent path of
+// every document, and which documents translate each other
+func (g *Generator) assignLanguages() {
+	assign := func(path string) {
+		lang, canonical := g.defaultLang(), path
+		if !g.wiki[path] {
+			lang, canonical = g.detectLanguage(path)
+		}
+		g.pageLang[path] = lang
+		if canonical != path {
+			g.canonical[path] = canonical
+		}
+		if g.translations[canonical] == nil {
+			g.translations[canonical] = make(map[string]string)
+		}
+		g.translations[canonical][lang] = path
+	}
+
+	for path := range g.markdown {
+		assign(path)
+	}
+	for path := range g.passthrough {
+		assign(path)
+	}
+}
+
+// pageLanguage returns the language of a document
+func (g *Generator) pageLanguage(path string) string {
+	if lang, ok := g.pageLang[path]; ok {
+		return lang
+	}
+	return g.defaultLang()
+}
+
+// langPrefix returns the output directory of a language tree relative to the
+// site root, e.g. "es/", or an empty string for the default language
+func (g *Generator) langPrefix(lang string) string {
+	if lang == g.defaultLang() {
+		return ""
+	}
+	return lang + "/"
+}
+
+// languageReadme returns the README of a language, falling back to the default README
+func (g *Generator) languageReadme(lang string) string {
+	if lang != g.defaultLang() {
+		var readme string
+		for path := range g.markdown {
+			if g.pageLang[path] == lang && filepath.Dir(path) == "." && isReadmeFile(path) &&
+				(readme == "" || git.PreferReadme(path, readme)) {
+				readme = path
+			}
+		}
+		if readme != "" {
+			return readme
+		}
+	}
+	return g.repoData.ReadmePath
+}
+
+// pagesForLang returns the navigation entries of one language tree
+func pagesForLang(pages []utils.DocPage, lang string) []utils.DocPage {
+	var filtered []utils.DocPage
+	for _, page := range pages {
+		if page.Lang == lang {
+			filtered = append(filtered, page)
+		}
+	}
+	return filtered
+}
+
+// languageLinks builds the language switcher for a page in the current
+// language, linking to the translations of its document or, where there is
+// none, to the home page of the language. An empty path builds the switcher
+// for the home page.
+func (g *Generator) languageLinks(path, current string) []LanguageLink {
+	if len(g.languages) < 2 {
+		return nil
+	}
+
+	var translations map[string]string
+	if path != "" {
+		canonical := path
+		if c, ok := g.canonical[path]; ok {
+			canonical = c
+		}
+		translations = g.translations[canonical]
+	}
+
+	var links []LanguageLink
+	for _, lang := range g.languages {
+		link := LanguageLink{
+			Code:     lang,
+			Name:     lang,
+			Path:     g.langPrefix(lang) + "index.html",
+			IsActive: lang == current,
+		}
+		if name, ok := languageNames[lang]; ok {
+			link.Name = name
+		}
+		if source, ok := translations[lang]; ok && !g.skipDocPage(source) {
+			link.Path = g.docOutputPath(source)
+		}
+		links = append(links, link)
+	}
+	return links
+}
@@ -0,0 +1,23 @@
+package generator
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMessagesConcurrently(t *testing.T) {
+	g := NewGenerator(testRepo(t, nil), t.TempDir())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for _, lang := range []string{"en", "es", "fr", "de"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if g.message(lang, "Commits") == "" {
+					t.Errorf("no Commits message for %s", lang)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,282 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// ImageOptions configures the image pipeline
+type ImageOptions struct {
+	// Optimize re-encodes large PNG and JPEG images when that makes them smaller
+	Optimize bool
+	// Widths are the responsive widths to generate, in pixels
+	Widths []int
+	// Formats are additional formats to generate ("webp", "avif"),
+	// using the cwebp and avifenc tools when they are installed
+	Formats []string
+}
+
+// largeImageSize is the file size above which images are re-encoded
+const largeImageSize = 256 * 1024
+
+// imageEncoders maps extra image formats to the external tool that produces them
+var imageEncoders = map[string]func(src, dst string) *exec.Cmd{
+	"webp": func(src, dst string) *exec.Cmd { return exec.Command("cwebp", "-quiet", "-q", "80", src, "-o", dst) },
+	"avif": func(src, dst string) *exec.Cmd { return exec.Command("avifenc", src, dst) },
+}
+
+// imageVariant is one generated version of an image
+type imageVariant struct {
+	Path  string // relative to the output directory
+	Width int
+}
+
+// responsiveImage holds all generated versions of a source image
+type responsiveImage struct {
+	Width    int
+	Widths   []imageVariant
+	Formats  map[string][]imageVariant
+	Original string
+}
+
+var imgTagRe = regexp.MustCompile(`<img\s[^>]*>`)
+
+// processImages runs the image pipeline over the copied images and rewrites
+// <img> tags in the generated pages to use the responsive variants
+func (g *Generator) processImages(result *GenerationResult) error {
+	opts := g.imageOptions
+	if !opts.Optimize && len(opts.Widths) == 0 && len(opts.Formats) == 0 {
+		return nil
+	}
+
+	images := make(map[string]*responsiveImage)
+	missingTools := make(map[string]bool)
+
+	for _, asset := range result.Assets {
+		ext := strings.ToLower(filepath.Ext(asset))
+		if !strings.HasPrefix(asset, g.layout.ImagesDir+"/") || (ext != ".png" && ext != ".jpg" && ext != ".jpeg") {
+			continue
+		}
+
+		path := filepath.Join(g.outputDir, asset)
+		img, err := decodeImage(path)
+		if err != nil {
+			g.logger.Warn("Skipping image that could not be decoded", "path", asset, "error", err)
+			continue
+		}
+
+		if opts.Optimize {
+			if err := reencodeImage(path, img); err != nil {
+				return err
+			}
+		}
+
+		ri := &responsiveImage{
+			Width:    img.Bounds().Dx(),
+			Formats:  make(map[string][]imageVariant),
+			Original: asset,
+		}
+		sources := []imageVariant{{Path: asset, Width: ri.Width}}
+
+		for _, width := range opts.Widths {
+			if width >= ri.Width {
+				continue
+			}
+			variant := strings.TrimSuffix(asset, filepath.Ext(asset)) + fmt.Sprintf("-%dw", width) + filepath.Ext(asset)
+			if err := writeResized(img, width, filepath.Join(g.outputDir, variant)); err != nil {
+				return err
+			}
+			ri.Widths = append(ri.Widths, imageVariant{Path: variant, Width: width})
+			sources = append(sources, imageVariant{Path: variant, Width: width})
+			result.Assets = append(result.Assets, variant)
+		}
+
+		for _, format := range opts.Formats {
+			encode, ok := imageEncoders[format]
+			if !ok || missingTools[format] {
+				continue
+			}
+			for _, src := range sources {
+				dst := strings.TrimSuffix(src.Path, filepath.Ext(src.Path)) + "." + format
+				cmd := encode(filepath.Join(g.outputDir, src.Path), filepath.Join(g.outputDir, dst))
+				if cmd.Err != nil {
+					g.logger.Warn("Image encoder not installed, skipping format", "format", format, "tool", cmd.Args[0])
+					missingTools[format] = true
+					break
+				}
+				if err := cmd.Run(); err != nil {
+					g.logger.Warn("Failed to encode image", "path", src.Path, "format", format, "error", err)
+					continue
+				}
+				ri.Formats[format] = append(ri.Formats[format], imageVariant{Path: dst, Width: src.Width})
+				result.Assets = append(result.Assets, dst)
+			}
+		}
+
+		images[asset] = ri
+	}
+
+	// Point <img> tags at the responsive variants
+	for _, page := range result.Pages {
+		path := filepath.Join(g.outputDir, page)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page, err)
+		}
+
+		rewritten := rewriteImgTags(string(content), page, images)
+		if err := os.WriteFile(path, []byte(rewritten), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", page, err)
+		}
+	}
+
+	return nil
+}
+
+// rewriteImgTags adds srcset attributes and <picture> sources to images with variants
+func rewriteImgTags(content, page string, images map[string]*responsiveImage) string {
+	pageDir := filepath.Dir(filepath.FromSlash(page))
+
+	return imgTagRe.ReplaceAllStringFunc(content, func(tag string) string {
+		m := linkAttrRe.FindStringSubmatch(tag)
+		if m == nil || strings.Contains(tag, "srcset=") {
+			return tag
+		}
+		src := m[1] + m[2]
+		if strings.Contains(src, ":") {
+			return tag
+		}
+
+		target := filepath.ToSlash(filepath.Join(pageDir, filepath.FromSlash(src)))
+		ri, ok := images[target]
+		if !ok {
+			return tag
+		}
+
+		// Links to variants use the same relative prefix as the original
+		prefix := strings.TrimSuffix(src, filepath.Base(src))
+		srcset := func(variants []imageVariant) string {
+			var parts []string
+			for _, v := range variants {
+				parts = append(parts, fmt.Sprintf("%s%s %dw", prefix, filepath.Base(v.Path), v.Width))
+			}
+			return strings.Join(parts, ", ")
+		}
+
+		sizes := fmt.Sprintf("(max-width: %dpx) 100vw, %dpx", ri.Width, ri.Width)
+		img := tag
+		if len(ri.Widths) > 0 {
+			widths := append([]imageVariant{{Path: ri.Original, Width: ri.Width}}, ri.Widths...)
+			img = strings.Replace(tag, "<img ", fmt.Sprintf(`<img srcset="%s" sizes="%s" `, srcset(widths), sizes), 1)
+		}
+
+		if len(ri.Formats) == 0 {
+			return img
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString("<picture>")
+		for _, format := range []string{"avif", "webp"} {
+			if variants := ri.Formats[format]; len(variants) > 0 {
+				fmt.Fprintf(&buf, `<source type="image/%s" srcset="%s" sizes="%s">`, format, srcset(variants), sizes)
+			}
+		}
+		buf.WriteString(img)
+		buf.WriteString("</picture>")
+		return buf.String()
+	})
+}
+
+// decodeImage reads a PNG or JPEG image from disk
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// encodeImage encodes an image in the format implied by the file extension
+func encodeImage(img image.Image, path string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	default:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	return buf.Bytes(), err
+}
+
+// reencodeImage re-encodes a large image in place if the result is smaller
+func reencodeImage(path string, img image.Image) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() <= largeImageSize {
+		return nil
+	}
+
+	data, err := encodeImage(img, path)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode %s: %w", path, err)
+	}
+	if int64(len(data)) >= info.Size() {
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// writeSquare scales an image to fit a size x size PNG, centered on a transparent background
+func writeSquare(img image.Image, size int, path string) error {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	offset := image.Pt((size-width)/2, (size-height)/2)
+	draw.CatmullRom.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(width, height))}, img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// writeResized scales an image to the given width, keeping its aspect ratio
+func writeResized(img image.Image, width int, path string) error {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	data, err := encodeImage(dst, path)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
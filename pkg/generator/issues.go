@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"github.com/go-i2p/go-gh-page/pkg/git"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// IssueEntry is a single issue or discussion shown on a snapshot page
+type IssueEntry struct {
+	Number   int
+	Title    string
+	URL      string
+	Author   string
+	Category string
+	Labels   []git.Label
+	Comments int
+	Date     string
+}
+
+// generateIssuesPage creates a snapshot page listing issues or discussions
+func (g *Generator) generateIssuesPage(filename, heading string, issues []git.Issue, docsPages []utils.DocPage) error {
+	var entries []IssueEntry
+	for _, issue := range issues {
+		entries = append(entries, IssueEntry{
+			Number:   issue.Number,
+			Title:    issue.Title,
+			URL:      issue.URL,
+			Author:   issue.Author,
+			Category: issue.Category,
+			Labels:   issue.Labels,
+			Comments: issue.Comments,
+			Date:     g.formatDate(issue.CreatedAt),
+		})
+	}
+
+	page := g.newPage("issues", filename, g.defaultLang(), docsPages)
+	page.Data.Issues = entries
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+
+	return g.renderPage(page)
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// landingFiles are the documents that become the home page instead of the
+// README, in order of preference
+var landingFiles = []string{"site/index.md", "_index.md"}
+
+// findLanding looks for a landing page among the documents. With one, the
+// README moves to a page of its own.
+func (g *Generator) findLanding() {
+	g.landingPath = ""
+	for _, name := range landingFiles {
+		if _, ok := g.markdown[filepath.FromSlash(name)]; ok {
+			g.landingPath = filepath.FromSlash(name)
+			return
+		}
+	}
+}
+
+// generateLandingPage creates the home page of the default language from
+// the landing page document
+func (g *Generator) generateLandingPage(docsPages []utils.DocPage) error {
+	lang := g.defaultLang()
+	source := g.landingPath
+	page := g.newPage("landing", "index.html", lang, pagesForLang(docsPages, lang))
+	page.Source = source
+
+	fm := g.frontMatter[source]
+	if fm.Title != "" {
+		page.Data.PageTitle = fm.Title + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	}
+	if fm.Description != "" {
+		page.Data.Description = fm.Description
+	}
+	content := renderMarkdownLinks(g.markdown[source], g.docLinks(source, "", nil))
+	page.Data.PageContent = g.addTaskProgress(source, g.sanitizeContent(content))
+	page.Data.Languages = g.languageLinks("", lang)
+	page.Data.Related = g.repoData.Related
+	page.Data.Citation = g.citation
+
+	if err := g.renderPage(page); err != nil {
+		return fmt.Errorf("failed to generate landing page: %w", err)
+	}
+	return nil
+}
+
+// documentLink points a link of a document to another document, relative to
+// the document or, with a leading slash, to the repository root, at the path
+// of its page from the site root, keeping the anchor
+func (g *Generator) documentLink(source, dest string) string {
+	if strings.Contains(dest, ":") || strings.HasPrefix(dest, "#") {
+		return dest
+	}
+
+	doc, ok := g.linkedDocument(source, dest)
+	if !ok {
+		return dest
+	}
+	var page string
+	switch {
+	case doc == g.languageReadme(g.defaultLang()):
+		page = g.readmePage(g.defaultLang())
+	case g.isPost(doc) || !g.skipDocPage(doc):
+		page = g.documentPagePath(doc)
+	default:
+		return dest
+	}
+	if _, anchor, hasAnchor := strings.Cut(dest, "#"); hasAnchor {
+		page += "#" + anchor
+	}
+	return page
+}
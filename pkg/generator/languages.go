@@ -0,0 +1,43 @@
+package generator
+
+// LanguageStat is an entry of the language bar on the main page
+type LanguageStat struct {
+	Name    string
+	Color   string
+	Percent float64
+}
+
+// otherLanguageShare is the share under which languages are grouped as
+// Other, in percent
+const otherLanguageShare = 0.5
+
+// languageStats returns the share of each language of the repository's
+// source files. Languages with a small share are grouped as Other.
+func (g *Generator) languageStats(lang string) []LanguageStat {
+	var total int64
+	for _, l := range g.repoData.Languages {
+		total += l.Bytes
+	}
+	if total == 0 {
+		return nil
+	}
+
+	var stats []LanguageStat
+	var other float64
+	for _, l := range g.repoData.Languages {
+		percent := float64(l.Bytes) * 100 / float64(total)
+		if percent < otherLanguageShare {
+			other += percent
+			continue
+		}
+		color := l.Color
+		if color == "" {
+			color = "#cccccc"
+		}
+		stats = append(stats, LanguageStat{Name: l.Name, Color: color, Percent: percent})
+	}
+	if other > 0 {
+		stats = append(stats, LanguageStat{Name: g.message(lang, "OtherLanguages"), Color: "#ededed", Percent: other})
+	}
+	return stats
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Layout names where the parts of the site are generated, relative to the
+// output directory
+type Layout struct {
+	// DocsDir holds the documentation pages
+	DocsDir string
+	// ImagesDir holds the images of the repository, and the downloaded and
+	// generated badges and external images
+	ImagesDir string
+	// AssetsDir holds vendored third-party files, such as the math engine
+	AssetsDir string
+	// ReadmePage is the page of the README. Anything but index.html leaves
+	// index.html to an overview of the repository linking to the README.
+	ReadmePage string
+}
+
+// DefaultLayout is the layout of a site unless configured otherwise
+var DefaultLayout = Layout{
+	DocsDir:    "docs",
+	ImagesDir:  "images",
+	AssetsDir:  "assets",
+	ReadmePage: "index.html",
+}
+
+// SetLayout sets the names of the directories and of the README page.
+// Empty fields keep their defaults.
+func (g *Generator) SetLayout(layout Layout) {
+	clean := func(name, fallback string) string {
+		if name = strings.Trim(filepath.ToSlash(name), "/"); name == "" || name == "." {
+			return fallback
+		}
+		return path.Clean(name)
+	}
+	g.layout = Layout{
+		DocsDir:    clean(layout.DocsDir, DefaultLayout.DocsDir),
+		ImagesDir:  clean(layout.ImagesDir, DefaultLayout.ImagesDir),
+		AssetsDir:  clean(layout.AssetsDir, DefaultLayout.AssetsDir),
+		ReadmePage: clean(layout.ReadmePage, DefaultLayout.ReadmePage),
+	}
+}
+
+// imagesPath returns the output path of a file of the images directory
+func (g *Generator) imagesPath(name string) string {
+	return g.layout.ImagesDir + "/" + name
+}
+
+// imagesURL returns the link to the images directory from a page at
+// rootPath
+func (g *Generator) imagesURL(rootPath string) string {
+	return rootPath + g.layout.ImagesDir + "/"
+}
+
+// readmePage returns the output path of the README page of a language. A
+// landing page moves the README from index.html to readme.html.
+func (g *Generator) readmePage(lang string) string {
+	if g.layout.ReadmePage == "index.html" && g.landingPath != "" {
+		return g.langPrefix(lang) + "readme.html"
+	}
+	return g.langPrefix(lang) + g.layout.ReadmePage
+}
+
+// separateReadme reports whether the README has a page of its own rather
+// than being the home page
+func (g *Generator) separateReadme() bool {
+	return g.layout.ReadmePage != "index.html" || g.landingPath != ""
+}
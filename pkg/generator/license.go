@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"html"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// generateLicensePage creates license.html with the full text of the
+// repository's license file. Markdown licenses are rendered, plain text ones
+// keep their line breaks.
+func (g *Generator) generateLicensePage(docsPages []utils.DocPage) error {
+	var content string
+	if strings.HasSuffix(strings.ToLower(g.repoData.LicensePath), ".md") {
+		content = g.sanitizeContent(renderMarkdown(g.repoData.LicenseText))
+	} else {
+		content = `<pre class="license-text">` + html.EscapeString(g.repoData.LicenseText) + "</pre>"
+	}
+
+	heading := g.repoData.License
+	page := g.newPage("doc", "license.html", g.defaultLang(), docsPages)
+	page.Source = g.repoData.LicensePath
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+	page.Data.PageContent = content
+
+	return g.renderPage(page)
+}
@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// BrokenLink is an internal link in the generated site that points at a missing file
+type BrokenLink struct {
+	Page   string `json:"page"`
+	Target string `json:"target"`
+}
+
+var (
+	linkAttrRe = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	srcsetRe   = regexp.MustCompile(`(?i)\bsrcset\s*=\s*"([^"]*)"`)
+)
+
+// srcsetURLs returns the image URLs listed in a srcset attribute value
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+// CheckLinks scans the HTML files in the output directory for internal links
+// to files that don't exist
+func CheckLinks(outputDir string) ([]BrokenLink, error) {
+	var broken []BrokenLink
+
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".html") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		page, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+
+		var targets []string
+		for _, m := range linkAttrRe.FindAllStringSubmatch(string(content), -1) {
+			targets = append(targets, m[1]+m[2])
+		}
+		for _, m := range srcsetRe.FindAllStringSubmatch(string(content), -1) {
+			targets = append(targets, srcsetURLs(m[1])...)
+		}
+
+		for _, target := range targets {
+			if !linkExists(filepath.Dir(path), target) {
+				broken = append(broken, BrokenLink{Page: filepath.ToSlash(page), Target: target})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return broken, nil
+}
+
+// linkExists checks whether a link found in a page in dir resolves to an existing file.
+// External links are not checked and always reported as existing.
+func linkExists(dir, link string) bool {
+	link = strings.TrimSpace(link)
+	if link == "" || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "//") {
+		return true
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return true
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(u.Path))
+	info, err := os.Stat(target)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		_, err = os.Stat(filepath.Join(target, "index.html"))
+		return err == nil
+	}
+	return true
+}
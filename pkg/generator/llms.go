@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// SetLLMsTxt writes llms.txt, an index of the documentation for language
+// models following https://llmstxt.org, and llms-full.txt with the text of
+// every page. The markdown source of every page is published as with
+// SetRawMarkdown, and llms.txt links it.
+func (g *Generator) SetLLMsTxt(enabled bool) {
+	g.llmsTxt = enabled
+}
+
+// llmsURL returns the URL of a file of the site in llms.txt, absolute when
+// the site has a custom domain
+func (g *Generator) llmsURL(outputPath string) string {
+	if g.cname != "" {
+		return g.siteURL() + outputPath
+	}
+	return outputPath
+}
+
+// writeLLMsTxt writes llms.txt and llms-full.txt for the README and the
+// documentation pages of the default language, in navigation order.
+// sources maps the output path of every page to its document.
+func (g *Generator) writeLLMsTxt(result *GenerationResult, docsPages []utils.DocPage, sources map[string]string) error {
+	var index, full strings.Builder
+	index.WriteString("# " + g.repoData.Name + "\n\n")
+	if g.repoData.Description != "" {
+		index.WriteString("> " + strings.Join(strings.Fields(g.repoData.Description), " ") + "\n\n")
+	}
+	index.WriteString(fmt.Sprintf("Documentation of the %s/%s repository: %s\n", g.repoData.Owner, g.repoData.Name, g.repoData.URL))
+
+	link := func(pagePath, title string) {
+		target := pagePath
+		if g.hasRawMarkdown(sources[pagePath]) {
+			target = rawOutputPath(pagePath)
+		}
+		entry := "- [" + title + "](" + g.llmsURL(target) + ")"
+		if description := g.frontMatter[sources[pagePath]].Description; description != "" {
+			entry += ": " + strings.Join(strings.Fields(description), " ")
+		}
+		index.WriteString(entry + "\n")
+	}
+	text := func(pagePath, title string) {
+		source := sources[pagePath]
+		_, content, _ := utils.ParseFrontMatter(g.rawSource(source))
+		if _, ok := g.converted[source]; ok {
+			content = plainText(g.renderDocContent(source, g.markdown[source], ""))
+		}
+		full.WriteString("# " + title + "\n\nSource: " + g.llmsURL(pagePath) + "\n\n")
+		full.WriteString(strings.TrimSpace(content) + "\n\n")
+	}
+
+	full.WriteString("# " + g.repoData.Owner + "/" + g.repoData.Name + "\n\n")
+	if readme := g.readmePage(g.defaultLang()); sources[readme] != "" {
+		index.WriteString("\n## Overview\n\n")
+		link(readme, "README")
+		text(readme, "README")
+	}
+
+	tree := utils.BuildNavTree(docsPages, g.layout.DocsDir, "")
+	sections := append([]*utils.NavSection{{Title: "Docs", Pages: tree.Pages}}, tree.Sections...)
+	for _, section := range sections {
+		var pages []utils.DocPage
+		for _, page := range utils.FlattenNavTree(section) {
+			// Pages without a document, such as hand-written HTML, have no text
+			if _, ok := sources[filepath.ToSlash(page.Path)]; ok {
+				pages = append(pages, page)
+			}
+		}
+		if len(pages) == 0 {
+			continue
+		}
+		index.WriteString("\n## " + section.Title + "\n\n")
+		for _, page := range pages {
+			link(filepath.ToSlash(page.Path), page.Title)
+			text(filepath.ToSlash(page.Path), page.Title)
+		}
+	}
+
+	index.WriteString("\n## Optional\n\n")
+	index.WriteString("- [Full documentation](" + g.llmsURL("llms-full.txt") + "): the text of every page above in one file\n")
+
+	if err := os.WriteFile(filepath.Join(g.outputDir, "llms.txt"), []byte(index.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write llms.txt: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(g.outputDir, "llms-full.txt"), []byte(full.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write llms-full.txt: %w", err)
+	}
+	result.Assets = append(result.Assets, "llms.txt", "llms-full.txt")
+	return nil
+}
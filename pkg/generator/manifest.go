@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestFile lists the files written by the last run, relative to the
+// output directory. Its presence marks the directory as a generated site.
+const ManifestFile = ".gh-page-manifest.json"
+
+// Manifest is the content of ManifestFile
+type Manifest struct {
+	// Files are the files of the site, relative to the output directory
+	Files []string `json:"files"`
+	// Generated is when the site was generated: the date of the last commit
+	// in reproducible builds
+	Generated time.Time `json:"generated"`
+	// Commit is the commit the site was generated from
+	Commit string `json:"commit,omitempty"`
+	// Moved maps the former paths of moved documents to their paths at
+	// Commit, so their old pages keep redirecting
+	Moved map[string]string `json:"moved,omitempty"`
+}
+
+// ReadManifest reads the manifest of an earlier run from an output
+// directory. A directory without one has an empty manifest.
+func ReadManifest(outputDir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(outputDir, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", ManifestFile, err)
+	}
+	return m, nil
+}
+
+// SiteFiles lists the files of a generated site, with forward slashes,
+// relative to its directory
+func SiteFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel != ManifestFile {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files of the site: %w", err)
+	}
+	return files, nil
+}
+
+// WriteManifest records the files of a generated site in its output
+// directory. The manifest gets the time the site was generated as its
+// modification time, like the other files of reproducible builds.
+func WriteManifest(outputDir string, m Manifest) error {
+	m.Files = append([]string(nil), m.Files...)
+	sort.Strings(m.Files)
+	m.Generated = m.Generated.UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	path := filepath.Join(outputDir, ManifestFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Chtimes(path, m.Generated, m.Generated); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// CheckClean returns an error if a directory must not be cleaned: it is
+// neither empty nor has a readable manifest listing the files of an earlier
+// run, so it may not be a generated site
+func CheckClean(outputDir string) error {
+	entries, err := os.ReadDir(outputDir)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(entries) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read output directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, ManifestFile)); err != nil {
+		return fmt.Errorf("refusing to clean %s: it has no %s from an earlier run, so it may not be a generated site", outputDir, ManifestFile)
+	}
+	m, err := ReadManifest(outputDir)
+	if err != nil {
+		return fmt.Errorf("refusing to clean %s: %w", outputDir, err)
+	}
+	if len(m.Files) == 0 {
+		return fmt.Errorf("refusing to clean %s: its %s lists no files", outputDir, ManifestFile)
+	}
+	return nil
+}
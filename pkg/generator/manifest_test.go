@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeFiles creates files with the given slash-separated paths under dir
+func writeFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestManifestListsSiteFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "index.html", "docs/a.html", "images/b.png")
+	files, err := SiteFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := WriteManifest(dir, Manifest{Files: files, Generated: generated}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docs/a.html", "images/b.png", "index.html"}; !slices.Equal(m.Files, want) {
+		t.Errorf("Files = %q, want %q", m.Files, want)
+	}
+	if !m.Generated.Equal(generated) {
+		t.Errorf("Generated = %v, want %v", m.Generated, generated)
+	}
+	info, err := os.Stat(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(generated) {
+		t.Errorf("manifest modified at %v, want %v", info.ModTime(), generated)
+	}
+	if err := CheckClean(dir); err != nil {
+		t.Errorf("CheckClean: %v", err)
+	}
+}
+
+func TestCheckCleanRefusesUnknownDirectories(t *testing.T) {
+	dir := t.TempDir()
+	if err := CheckClean(dir); err != nil {
+		t.Errorf("CheckClean of an empty directory: %v", err)
+	}
+	writeFiles(t, dir, "notes.txt")
+	if err := CheckClean(dir); err == nil {
+		t.Error("CheckClean of a directory without a manifest succeeded")
+	}
+	writeFiles(t, dir, ManifestFile)
+	if err := CheckClean(dir); err == nil {
+		t.Error("CheckClean of a directory with an invalid manifest succeeded")
+	}
+}
+
+func TestPublishSiteCleanKeepsFilesItDidNotGenerate(t *testing.T) {
+	parent := t.TempDir()
+	output := filepath.Join(parent, "site")
+	writeFiles(t, output, "index.html", "docs/old.html", "docs/kept.html", "old/page.html", "notes.txt", "extra/file.txt", ".git/HEAD")
+	previous := []string{"index.html", "docs/old.html", "docs/kept.html", "old/page.html"}
+	if err := WriteManifest(output, Manifest{Files: previous}); err != nil {
+		t.Fatal(err)
+	}
+
+	staging, err := NewStagingDir(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, staging, "index.html", "docs/kept.html", "docs/new.html")
+	if err := WriteManifest(staging, Manifest{Files: []string{"index.html", "docs/kept.html", "docs/new.html"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	dropped, err := PublishSite(staging, output, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(dropped)
+	if want := []string{"docs/old.html", "old/page.html"}; !slices.Equal(dropped, want) {
+		t.Errorf("dropped %q, want %q", dropped, want)
+	}
+	files, err := SiteFiles(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".git/HEAD", "docs/kept.html", "docs/new.html", "extra/file.txt", "index.html", "notes.txt"}; !slices.Equal(files, want) {
+		t.Errorf("output has %q, want %q", files, want)
+	}
+}
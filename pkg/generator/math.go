@@ -0,0 +1,316 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/templates"
+)
+
+const (
+	// MathKaTeX renders math with KaTeX
+	MathKaTeX = "katex"
+	// MathJax renders math with MathJax
+	MathJax = "mathjax"
+)
+
+// MathEngines lists the supported math engines
+var MathEngines = []string{MathKaTeX, MathJax}
+
+// Math engines, loaded from jsDelivr unless vendored into the site
+const (
+	katexURL   = "https://cdn.jsdelivr.net/npm/katex@0.16.11/dist/"
+	mathjaxURL = "https://cdn.jsdelivr.net/npm/mathjax@3.2.2/es5/"
+)
+
+// maxMathAssetSize is the largest file of a math engine downloaded in offline mode
+const maxMathAssetSize = 5 * 1024 * 1024
+
+var (
+	// ```math fenced blocks, as on GitHub
+	mathFenceRe = regexp.MustCompile("^\\s*(```|~~~)\\s*math\\s*$")
+	// $$...$$ display math, which may span lines but not paragraphs
+	displayMathRe = regexp.MustCompile(`\$\$((?:[^$]|\$[^$])+?)\$\$`)
+	// Fonts referenced by the KaTeX stylesheet
+	katexFontRe = regexp.MustCompile(`url\((fonts/[^)]+\.woff2)\)`)
+)
+
+// SetMath enables rendering $...$ and $$...$$ TeX math, and ```math blocks,
+// with the given engine, one of MathEngines. The engine is loaded from a
+// CDN, or vendored into the site in offline mode.
+func (g *Generator) SetMath(engine string) {
+	g.math = engine
+}
+
+// convertMath sets the TeX math of a document outside code aside, so markdown
+// doesn't mangle it, and marks it up for the math engine
+func (g *Generator) convertMath(content string) string {
+	if g.math == "" || (!strings.Contains(content, "$") && !strings.Contains(content, "math")) {
+		return content
+	}
+
+	var out, text strings.Builder
+	flush := func() {
+		// Code spans are set aside while converting
+		var spans []string
+		masked := codeSpanRe.ReplaceAllStringFunc(text.String(), func(span string) string {
+			spans = append(spans, span)
+			return "\x00code" + strconv.Itoa(len(spans)-1) + "\x00"
+		})
+		converted := g.convertInlineMath(masked)
+		for i, span := range spans {
+			converted = strings.Replace(converted, "\x00code"+strconv.Itoa(i)+"\x00", span, 1)
+		}
+		out.WriteString(converted)
+		text.Reset()
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	inFence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if inFence == "" {
+			if m := mathFenceRe.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil {
+				// The block runs to the closing fence
+				var tex []string
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != m[1] {
+					i++
+					tex = append(tex, lines[i])
+				}
+				i++
+				flush()
+				g.writeShortcodePlaceholder(&out, mathHTML(strings.Join(tex, ""), true))
+				g.hasMath = true
+				continue
+			}
+		}
+		if m := shortcodeCodeFenceRe.FindStringSubmatch(line); m != nil {
+			switch inFence {
+			case "":
+				flush()
+				inFence = m[1]
+			case m[1]:
+				inFence = ""
+				out.WriteString(line)
+				continue
+			}
+		}
+		if inFence != "" {
+			out.WriteString(line)
+		} else {
+			text.WriteString(line)
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// convertInlineMath replaces $$...$$ and $...$ math in a piece of markdown
+// with placeholders. A $ only opens math when followed by a non-space, and
+// only closes it when preceded by a non-space and not followed by a digit,
+// so amounts such as $5 and $10 are left alone.
+func (g *Generator) convertInlineMath(content string) string {
+	content = displayMathRe.ReplaceAllStringFunc(content, func(match string) string {
+		tex := displayMathRe.FindStringSubmatch(match)[1]
+		if strings.Contains(tex, "\n\n") {
+			return match
+		}
+		g.hasMath = true
+		return g.inlinePlaceholder(mathHTML(tex, true))
+	})
+	if !strings.Contains(content, "$") {
+		return content
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		if c == '\\' && i+1 < len(content) {
+			out.WriteString(content[i : i+2])
+			i++
+			continue
+		}
+		if c != '$' || i+1 >= len(content) || isMathSpace(content[i+1]) {
+			out.WriteByte(c)
+			continue
+		}
+		if content[i+1] == '$' {
+			// An unmatched $$ isn't inline math either
+			out.WriteString("$$")
+			i++
+			continue
+		}
+
+		end := closingDollar(content, i+1)
+		if end < 0 {
+			out.WriteByte(c)
+			continue
+		}
+		out.WriteString(g.inlinePlaceholder(mathHTML(content[i+1:end], false)))
+		g.hasMath = true
+		i = end
+	}
+	return out.String()
+}
+
+// closingDollar returns the index of the $ closing inline math opened before
+// start, or -1 if there is none in the same paragraph
+func closingDollar(content string, start int) int {
+	for j := start; j < len(content); j++ {
+		switch content[j] {
+		case '\\':
+			j++
+		case '\n':
+			if j+1 < len(content) && content[j+1] == '\n' {
+				return -1
+			}
+		case '$':
+			if isMathSpace(content[j-1]) || (j+1 < len(content) && content[j+1] >= '0' && content[j+1] <= '9') {
+				return -1
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+func isMathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// inlinePlaceholder stores a piece of HTML like a shortcode's output, with a
+// placeholder that doesn't break the paragraph it is in
+func (g *Generator) inlinePlaceholder(fragment string) string {
+	g.shortcodeHTML = append(g.shortcodeHTML, fragment)
+	return fmt.Sprintf("<!--shortcode:%d-->", len(g.shortcodeHTML)-1)
+}
+
+// mathHTML marks up TeX source for the math engine
+func mathHTML(tex string, display bool) string {
+	tex = html.EscapeString(strings.TrimSpace(tex))
+	if display {
+		return `<span class="math math-display">` + tex + `</span>`
+	}
+	return `<span class="math math-inline">` + tex + `</span>`
+}
+
+// writeMathAssets writes the script rendering math into the output directory
+// and, in offline mode, vendors the math engine into assets/
+func (g *Generator) writeMathAssets(result *GenerationResult) error {
+	if err := os.WriteFile(filepath.Join(g.outputDir, "math.js"), []byte(templates.MathScript), 0o644); err != nil {
+		return fmt.Errorf("failed to write math.js: %w", err)
+	}
+	result.Assets = append(result.Assets, "math.js")
+
+	if !g.offline {
+		return nil
+	}
+	assets, err := g.vendorMathEngine()
+	if err != nil {
+		// The site still works with clearnet access
+		g.logger.Warn("Failed to vendor math engine, loading it from the CDN", "engine", g.math, "error", err)
+		return nil
+	}
+	result.Assets = append(result.Assets, assets...)
+	g.mathVendored = true
+	return nil
+}
+
+// vendorMathEngine downloads the files of the math engine into
+// assets/<engine>/ and returns their paths relative to the output directory
+func (g *Generator) vendorMathEngine() ([]string, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+	dir := g.layout.AssetsDir + "/" + g.math + "/"
+
+	var files []string
+	baseURL := mathjaxURL
+	if g.math == MathKaTeX {
+		baseURL = katexURL
+		css, err := fetchMathAsset(client, katexURL+"katex.min.css")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, "katex.min.css", "katex.min.js")
+		for _, m := range katexFontRe.FindAllStringSubmatch(string(css), -1) {
+			files = append(files, m[1])
+		}
+	} else {
+		files = append(files, "tex-svg.js")
+	}
+
+	var written []string
+	for _, file := range files {
+		data, err := fetchMathAsset(client, baseURL+file)
+		if err != nil {
+			return nil, err
+		}
+		dest := filepath.Join(g.outputDir, filepath.FromSlash(dir+file))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, dir+file)
+	}
+	return written, nil
+}
+
+// fetchMathAsset downloads a file of the math engine
+func fetchMathAsset(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMathAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMathAssetSize {
+		return nil, fmt.Errorf("%s larger than %d bytes", url, maxMathAssetSize)
+	}
+	return data, nil
+}
+
+// addMathAssets loads the math engine in the <head> of a page with math.
+// rootPath is the relative path from the page to the site root.
+func (g *Generator) addMathAssets(rootPath, page string) string {
+	if !g.hasMath || !strings.Contains(page, `<span class="math math-`) {
+		return page
+	}
+
+	var head string
+	switch g.math {
+	case MathKaTeX:
+		base := katexURL
+		if g.mathVendored {
+			base = rootPath + g.layout.AssetsDir + "/katex/"
+		}
+		head = `<link rel="stylesheet" href="` + base + `katex.min.css">` + "\n" +
+			`<script defer src="` + base + `katex.min.js"></script>` + "\n" +
+			`<script defer src="` + rootPath + `math.js"></script>` + "\n"
+	case MathJax:
+		base := mathjaxURL
+		if g.mathVendored {
+			base = rootPath + g.layout.AssetsDir + "/mathjax/"
+		}
+		head = `<script defer src="` + rootPath + `math.js"></script>` + "\n" +
+			`<script defer src="` + base + `tex-svg.js"></script>` + "\n"
+	}
+	return strings.Replace(page, "</head>", head+"</head>", 1)
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Missing README modes control the home page of a repository without a README
+const (
+	// MissingReadmeOverview builds the home page from the repository's
+	// metadata: its description, files, Go module and contributors
+	MissingReadmeOverview = "overview"
+	// MissingReadmePlaceholder shows a placeholder instead of the README
+	MissingReadmePlaceholder = "placeholder"
+	// MissingReadmeFail stops the generation
+	MissingReadmeFail = "fail"
+)
+
+// MissingReadmeModes lists the supported missing README modes
+var MissingReadmeModes = []string{MissingReadmeOverview, MissingReadmePlaceholder, MissingReadmeFail}
+
+// SetMissingReadme sets what the home page of a repository without a README
+// shows
+func (g *Generator) SetMissingReadme(mode string) {
+	g.missingReadme = mode
+}
+
+// checkReadme reports a repository without a README, or fails with
+// MissingReadmeFail. Repositories with a landing page don't need one.
+func (g *Generator) checkReadme(result *GenerationResult) error {
+	if g.landingPath != "" || g.markdown[g.languageReadme(g.defaultLang())] != "" {
+		return nil
+	}
+	switch g.missingReadme {
+	case MissingReadmeFail:
+		return fmt.Errorf("repository has no README")
+	case MissingReadmePlaceholder:
+		g.warn(result, "Repository has no README; the home page shows a placeholder")
+	default:
+		g.warn(result, "Repository has no README; the home page is an overview of the repository")
+	}
+	return nil
+}
+
+// rootEntries lists the files and directories at the root of the
+// repository for the home page, linking to the file tree and source views
+// when they are generated, else to GitHub
+func (g *Generator) rootEntries(rootPath string) []TreeEntry {
+	dirs := make(map[string]bool)
+	var files []string
+	for rel := range g.repoData.Files {
+		file := filepath.ToSlash(rel)
+		if dir, _, ok := strings.Cut(file, "/"); ok {
+			dirs[dir] = true
+		} else {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	var entries []TreeEntry
+	for _, dir := range sortedKeys(dirs) {
+		entry := TreeEntry{Name: dir + "/", IsDir: true}
+		if g.sourceTree {
+			entry.URL = rootPath + treePagePath(dir)
+		} else {
+			entry.URL = g.repoData.URL + "/tree/" + g.branch() + "/" + dir
+			entry.External = true
+		}
+		entries = append(entries, entry)
+	}
+	for _, file := range files {
+		entry := TreeEntry{Name: path.Base(file), Size: formatSize(g.repoData.Files[filepath.FromSlash(file)])}
+		if _, ok := g.sources[file]; ok {
+			entry.URL = rootPath + sourcePagePath(file)
+		} else {
+			entry.URL = g.repoData.URL + "/blob/" + g.branch() + "/" + file
+			entry.External = true
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// maxExternalImageSize is the largest external image downloaded in offline mode
+const maxExternalImageSize = 10 * 1024 * 1024
+
+// imageExtensions maps image content types to file extensions
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+	"image/avif":    ".avif",
+}
+
+var altAttrRe = regexp.MustCompile(`(?i)\balt\s*=\s*"([^"]*)"`)
+
+// vendorExternalImages downloads images referenced by absolute URLs into
+// images/external/ and points the generated pages at the local copies, so the
+// site works on mirrors without clearnet access. Badges are replaced with
+// their alt text instead of being downloaded.
+func (g *Generator) vendorExternalImages(result *GenerationResult) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	downloaded := make(map[string]string)
+	failed := make(map[string]bool)
+
+	for _, page := range result.Pages {
+		pagePath := filepath.Join(g.outputDir, page)
+		content, err := os.ReadFile(pagePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page, err)
+		}
+		rootPath := utils.RelativeRoot(page)
+
+		rewritten := imgTagRe.ReplaceAllStringFunc(string(content), func(tag string) string {
+			m := linkAttrRe.FindStringSubmatch(tag)
+			if m == nil {
+				return tag
+			}
+			src := m[1] + m[2]
+			if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+				return tag
+			}
+
+			if isBadgeURL(src) {
+				if alt := altAttrRe.FindStringSubmatch(tag); alt != nil {
+					return alt[1]
+				}
+				return ""
+			}
+
+			local, ok := downloaded[src]
+			if !ok {
+				if failed[src] {
+					return tag
+				}
+				local, err = downloadImage(client, src, g.outputDir, g.imagesPath("external"))
+				if err != nil {
+					g.logger.Warn("Failed to download external image", "url", src, "error", err)
+					failed[src] = true
+					return tag
+				}
+				downloaded[src] = local
+				result.Assets = append(result.Assets, local)
+				result.ImagesCount++
+				g.logger.Debug("Downloaded external image", "url", src, "path", local)
+			}
+
+			return strings.Replace(tag, m[0], `src="`+rootPath+local+`"`, 1)
+		})
+
+		if err := os.WriteFile(pagePath, []byte(rewritten), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", page, err)
+		}
+	}
+
+	return nil
+}
+
+// downloadImage fetches an image into dir, relative to the output directory,
+// naming it after a hash of its URL. It returns the local path relative to the
+// output directory.
+func downloadImage(client *http.Client, rawURL, outputDir, dir string) (string, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxExternalImageSize {
+		return "", fmt.Errorf("image larger than %d bytes", maxExternalImageSize)
+	}
+
+	// Prefer the extension from the URL, falling back to the content type
+	ext := strings.ToLower(path.Ext(strings.SplitN(rawURL, "?", 2)[0]))
+	if ext == "" || len(ext) > 5 {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		ext = imageExtensions[mediaType]
+		if ext == "" {
+			ext = ".img"
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(outputDir, dir), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create external images directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	name := hex.EncodeToString(sum[:])[:16] + ext
+	if err := os.WriteFile(filepath.Join(outputDir, dir, name), data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+
+	return dir + "/" + name, nil
+}
@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+)
+
+// optimizeOutput fingerprints assets with content hashes, rewrites the
+// references to them, and minifies the generated HTML and CSS
+func (g *Generator) optimizeOutput(result *GenerationResult) error {
+	m := minify.New()
+	m.AddFunc("text/css", css.Minify)
+	m.Add("text/html", &html.Minifier{KeepDocumentTags: true, KeepEndTags: true, KeepQuotes: true})
+
+	// Minify CSS before hashing so the fingerprint matches the final content
+	for _, asset := range result.Assets {
+		if strings.HasSuffix(asset, ".css") {
+			if err := minifyFile(m, "text/css", filepath.Join(g.outputDir, asset)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Rename assets to include a hash of their content
+	renamed := make(map[string]string)
+	for i, asset := range result.Assets {
+		fingerprinted, err := fingerprintFile(g.outputDir, asset)
+		if err != nil {
+			return err
+		}
+		renamed[asset] = fingerprinted
+		result.Assets[i] = fingerprinted
+	}
+
+	for _, page := range result.Pages {
+		path := filepath.Join(g.outputDir, page)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page, err)
+		}
+
+		content = []byte(rewriteAssetLinks(string(content), page, renamed))
+		if content, err = m.Bytes("text/html", content); err != nil {
+			return fmt.Errorf("failed to minify %s: %w", page, err)
+		}
+
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", page, err)
+		}
+	}
+
+	return nil
+}
+
+// rewriteAssetLinks updates links in a page to point at renamed assets
+func rewriteAssetLinks(content, page string, renamed map[string]string) string {
+	pageDir := filepath.Dir(filepath.FromSlash(page))
+
+	rename := func(link string) string {
+		if link == "" || strings.Contains(link, ":") || strings.HasPrefix(link, "#") {
+			return link
+		}
+		target := filepath.ToSlash(filepath.Join(pageDir, filepath.FromSlash(link)))
+		newTarget, ok := renamed[target]
+		if !ok {
+			return link
+		}
+		return strings.TrimSuffix(link, filepath.Base(link)) + filepath.Base(newTarget)
+	}
+
+	content = linkAttrRe.ReplaceAllStringFunc(content, func(match string) string {
+		sub := linkAttrRe.FindStringSubmatch(match)
+		link := sub[1] + sub[2]
+		return strings.Replace(match, link, rename(link), 1)
+	})
+
+	return srcsetRe.ReplaceAllStringFunc(content, func(match string) string {
+		sub := srcsetRe.FindStringSubmatch(match)
+		for _, link := range srcsetURLs(sub[1]) {
+			match = strings.Replace(match, link+" ", rename(link)+" ", 1)
+		}
+		return match
+	})
+}
+
+// fingerprintFile renames a file in the output directory to include a short
+// hash of its content, e.g. style.css -> style.3f2a9c1b.css
+func fingerprintFile(outputDir, asset string) (string, error) {
+	path := filepath.Join(outputDir, asset)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", asset, err)
+	}
+
+	sum := sha256.Sum256(content)
+	ext := filepath.Ext(asset)
+	fingerprinted := strings.TrimSuffix(asset, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+
+	if err := os.Rename(path, filepath.Join(outputDir, fingerprinted)); err != nil {
+		return "", fmt.Errorf("failed to rename %s: %w", asset, err)
+	}
+
+	return fingerprinted, nil
+}
+
+// minifyFile minifies a file in place
+func minifyFile(m *minify.M, mediaType, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	minified, err := m.Bytes(mediaType, content)
+	if err != nil {
+		return fmt.Errorf("failed to minify %s: %w", path, err)
+	}
+
+	return os.WriteFile(path, minified, 0o644)
+}
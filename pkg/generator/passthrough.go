@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+var (
+	htmlDocTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlBodyRe     = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+)
+
+// SetWrapHTML enables rendering hand-written HTML pages inside the doc
+// template, so they get the site header and navigation. Otherwise they are
+// copied through unchanged.
+func (g *Generator) SetWrapHTML(wrap bool) {
+	g.wrapHTML = wrap
+}
+
+// loadHTMLPages registers the hand-written HTML pages under docs/, either as
+// converted documents to be wrapped in the doc template or as pages copied through
+func (g *Generator) loadHTMLPages() {
+	outputs := make(map[string]bool)
+	for path := range g.markdown {
+		outputs[g.docOutputPath(path)] = true
+	}
+
+	for path, content := range g.repoData.HTMLFiles {
+		if matchPath(g.exclude, path) {
+			continue
+		}
+		if outputs[g.docOutputPath(path)] {
+			g.logger.Warn("Skipping HTML page that conflicts with a generated page", "path", path)
+			continue
+		}
+
+		// Hand-written pages are only copied through from trusted repositories
+		if !g.wrapHTML && !g.sanitize {
+			g.passthrough[path] = content
+			continue
+		}
+
+		body := content
+		if m := htmlBodyRe.FindStringSubmatch(content); m != nil {
+			body = m[1]
+		}
+		g.markdown[path] = ""
+		g.converted[path] = body
+		if m := htmlDocTitleRe.FindStringSubmatch(content); m != nil {
+			g.frontMatter[path] = utils.FrontMatter{Title: strings.TrimSpace(html.UnescapeString(m[1]))}
+		}
+	}
+}
+
+// passthroughPages returns the navigation entries of the pages copied through
+func (g *Generator) passthroughPages() []utils.DocPage {
+	var pages []utils.DocPage
+	for path, content := range g.passthrough {
+		title := ""
+		if m := htmlDocTitleRe.FindStringSubmatch(content); m != nil {
+			title = strings.TrimSpace(html.UnescapeString(m[1]))
+		}
+		if title == "" {
+			title = titleFromHTML(content)
+		}
+		if title == "" {
+			title = utils.PrettifyFilename(filepath.Base(path))
+		}
+		pages = append(pages, utils.DocPage{Title: title, Path: g.docOutputPath(path), Lang: g.pageLanguage(path)})
+	}
+	return pages
+}
+
+// copyPassthroughPages writes the hand-written HTML pages to the output unchanged
+func (g *Generator) copyPassthroughPages(result *GenerationResult) error {
+	var paths []string
+	for path := range g.passthrough {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		outputPath := g.docOutputPath(path)
+		outPath := filepath.Join(g.outputDir, utils.FilePath(outputPath))
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", outPath, err)
+		}
+		if err := os.WriteFile(outPath, []byte(g.passthrough[path]), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outPath, err)
+		}
+		result.Pages = append(result.Pages, outputPath)
+		g.logger.Debug("Copied HTML page", "path", path)
+	}
+
+	return nil
+}
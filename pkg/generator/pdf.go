@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// pdfRenderers are the headless renderers tried, in order, when no PDF
+// command is configured. {input} and {output} are replaced with the paths
+// of the combined HTML document and the PDF.
+var pdfRenderers = [][]string{
+	{"chromium", "--headless", "--disable-gpu", "--no-pdf-header-footer", "--print-to-pdf={output}", "{input}"},
+	{"chromium-browser", "--headless", "--disable-gpu", "--no-pdf-header-footer", "--print-to-pdf={output}", "{input}"},
+	{"google-chrome", "--headless", "--disable-gpu", "--no-pdf-header-footer", "--print-to-pdf={output}", "{input}"},
+	{"wkhtmltopdf", "--quiet", "--enable-local-file-access", "{input}", "{output}"},
+}
+
+// SetPDF enables exporting the README and all documentation pages as a single
+// PDF file in the output directory. command is the renderer to run, with
+// {input} and {output} placeholders; if empty, the first installed renderer
+// of Chromium, Chrome or wkhtmltopdf is used.
+func (g *Generator) SetPDF(filename string, command []string) {
+	g.pdfFile = filename
+	g.pdfCommand = command
+}
+
+// pdfRenderer returns the command used to print the combined document
+func (g *Generator) pdfRenderer() ([]string, error) {
+	if len(g.pdfCommand) > 0 {
+		return g.pdfCommand, nil
+	}
+	for _, renderer := range pdfRenderers {
+		if _, err := exec.LookPath(renderer[0]); err == nil {
+			return renderer, nil
+		}
+	}
+	return nil, fmt.Errorf("no PDF renderer found: install chromium or wkhtmltopdf, or set a PDF command")
+}
+
+// generatePDF prints the README and the documentation pages, in navigation
+// order, to a single PDF through a headless renderer
+func (g *Generator) generatePDF(docsPages []utils.DocPage, result *GenerationResult) error {
+	renderer, err := g.pdfRenderer()
+	if err != nil {
+		return err
+	}
+
+	// The combined document is written to the site root, so images resolve
+	// like they do from the main page
+	input, err := filepath.Abs(filepath.Join(g.outputDir, ".print.html"))
+	if err != nil {
+		return err
+	}
+	output, err := filepath.Abs(filepath.Join(g.outputDir, g.pdfFile))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(input, []byte(g.printDocument(docsPages)), 0o644); err != nil {
+		return fmt.Errorf("failed to write print document: %w", err)
+	}
+	defer os.Remove(input)
+
+	var args []string
+	for _, arg := range renderer[1:] {
+		arg = strings.ReplaceAll(arg, "{input}", input)
+		args = append(args, strings.ReplaceAll(arg, "{output}", output))
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(renderer[0], args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", renderer[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if _, err := os.Stat(output); err != nil {
+		return fmt.Errorf("%s did not write %s", renderer[0], g.pdfFile)
+	}
+
+	g.logger.Info("Generated PDF", "path", output)
+	result.Assets = append(result.Assets, filepath.ToSlash(g.pdfFile))
+	return nil
+}
+
+// printDocument builds a standalone HTML document with a title page, the
+// README and every markdown or converted documentation page in navigation
+// order, each starting on a new printed page
+func (g *Generator) printDocument(docsPages []utils.DocPage) string {
+	lang := g.defaultLang()
+	name := g.repoData.Owner + "/" + g.repoData.Name
+
+	var buf strings.Builder
+	buf.WriteString("<!DOCTYPE html>\n<html lang=\"" + html.EscapeString(lang) + "\">\n<head>\n")
+	buf.WriteString("<meta charset=\"UTF-8\">\n<title>" + html.EscapeString(name) + "</title>\n")
+	buf.WriteString("<link rel=\"stylesheet\" href=\"style.css\">\n</head>\n<body class=\"print-document\">\n<main>\n")
+
+	// Title page
+	buf.WriteString("<section class=\"print-title\">\n<h1>" + html.EscapeString(name) + "</h1>\n")
+	if g.repoData.Description != "" {
+		buf.WriteString("<p>" + html.EscapeString(g.repoData.Description) + "</p>\n")
+	}
+	buf.WriteString("<p>" + html.EscapeString(g.repoData.URL) + "</p>\n")
+	buf.WriteString("<p>" + g.formatDate(g.now()) + "</p>\n</section>\n")
+
+	for _, section := range g.combinedSections(docsPages) {
+		buf.WriteString("<section class=\"combined-page\" id=\"" + section.ID + "\">\n" + section.HTML + "</section>\n")
+	}
+
+	buf.WriteString("</main>\n</body>\n</html>\n")
+	return g.addMathAssets("", g.restoreShortcodeHTML(buf.String()))
+}
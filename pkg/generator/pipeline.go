@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// Page is a page going through the render pipeline: the page data is built,
+// filters run over it, and it is rendered with its template and written to
+// the output directory
+type Page struct {
+	// Template is the name of the template the page is rendered with, e.g. "doc"
+	Template string
+	// Path is the output path, relative to the output directory
+	Path string
+	// Source is the path of the document the page renders, if any
+	Source string
+
+	Data *PageData
+}
+
+// Filter transforms a page before it is rendered, most often its
+// Data.PageContent. Filters run in the order they were added, and may run
+// concurrently on different pages.
+type Filter func(page *Page) error
+
+// AddFilter adds a filter that runs on every page before it is rendered
+func (g *Generator) AddFilter(filter Filter) {
+	g.filters = append(g.filters, filter)
+}
+
+// newPage starts a page with the data shared by every page: repository
+// metadata, the navigation of the given doc pages, the page's language and
+// the generation info
+func (g *Generator) newPage(template, outputPath, lang string, docsPages []utils.DocPage) *Page {
+	outputPath = filepath.ToSlash(outputPath)
+	rootPath := utils.RelativeRoot(outputPath)
+
+	// Mark the current page as active in a copy of the navigation
+	pages := append([]utils.DocPage(nil), docsPages...)
+	for i := range pages {
+		if filepath.ToSlash(pages[i].Path) == outputPath {
+			pages[i].IsActive = true
+		}
+	}
+
+	data := &PageData{
+		RepoOwner:        g.repoData.Owner,
+		RepoName:         g.repoData.Name,
+		RepoFullName:     g.repoData.Owner + "/" + g.repoData.Name,
+		Description:      g.repoData.Description,
+		CommitCount:      g.repoData.CommitCount,
+		HistoryTruncated: g.repoData.HistoryTruncated,
+		License:          g.repoData.License,
+		LicenseSPDX:      g.repoData.LicenseSPDX,
+		RepoURL:          g.repoData.URL,
+		LastUpdate:       g.formatDate(g.repoData.LastCommitDate),
+		LogoPath:         g.logoPath,
+		Favicons:         g.favicons,
+
+		Topics:        g.repoData.Topics,
+		Stars:         g.repoData.Stars,
+		Forks:         g.repoData.Forks,
+		DefaultBranch: g.repoData.DefaultBranch,
+		Homepage:      g.repoData.Homepage,
+
+		Lang:       lang,
+		LangPrefix: g.langPrefix(lang),
+		T:          maps.Clone(g.messages(lang)),
+
+		Version:  g.version,
+		Versions: g.versionLinks(),
+
+		DocsPages:       pages,
+		NavTree:         utils.BuildNavTree(pages, g.layout.DocsDir, rootPath),
+		HasReleases:     len(g.releases) > 0,
+		HasChangelog:    g.changelogPath != "",
+		HasIssues:       len(g.repoData.Issues) > 0,
+		HasDiscussions:  len(g.repoData.Discussions) > 0,
+		HasContributors: len(g.repoData.AllContributors) > 0,
+		HasLicense:      g.repoData.LicenseText != "",
+		HasSourceTree:   g.sourceTree,
+		HasFunding:      len(g.repoData.Funding) > 0,
+		CommunityLinks:  g.communityLinks(lang, rootPath),
+		HasSinglePage:   g.singlePage,
+		HasSearch:       g.search,
+		HasTags:         len(g.tags) > 0,
+		CurrentPage:     outputPath,
+		RootPath:        rootPath,
+		PageTitle:       g.repoData.Owner + "/" + g.repoData.Name,
+
+		HeadHTML:    g.headHTML,
+		FooterHTML:  g.footerHTML,
+		GeneratedAt: g.formatDateTime(g.now()),
+		Repo:        g.repoData,
+
+		ShowGeneratedOn: !g.footer.HideGeneratedOn,
+		Copyright:       g.copyright(),
+		DocsLicense:     g.docsLicense(),
+		FooterLinks:     g.footerLinks(rootPath),
+	}
+
+	if len(g.posts) > 0 {
+		data.BlogTitle = g.blogTitle()
+		data.BlogPath = g.blogListingPath(1)
+		data.FeedPath = g.feedPath()
+	}
+
+	if g.separateReadme() && g.markdown[g.languageReadme(lang)] != "" {
+		data.ReadmePath = g.readmePage(lang)
+	}
+
+	if g.cname != "" {
+		data.SiteURL = g.siteURL()
+		data.CanonicalURL = data.SiteURL + strings.TrimSuffix(outputPath, "index.html")
+	}
+
+	return &Page{Template: template, Path: outputPath, Data: data}
+}
+
+// renderPage runs the filters over a page, renders it with its template,
+// passes the HTML through the post-render hooks and writes it to the output directory
+func (g *Generator) renderPage(page *Page) error {
+	for _, filter := range g.filters {
+		if err := filter(page); err != nil {
+			return fmt.Errorf("failed to filter %s: %w", page.Path, err)
+		}
+	}
+
+	tmpl, ok := g.templateCache[page.Template]
+	if !ok {
+		return fmt.Errorf("unknown template %q for %s", page.Template, page.Path)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page.Data); err != nil {
+		return fmt.Errorf("failed to execute %s template: %w", page.Template, err)
+	}
+
+	output, err := g.runPostRenderHooks(page.Path, g.addMathAssets(page.Data.RootPath, g.restoreShortcodeHTML(buf.String())))
+	if err != nil {
+		return err
+	}
+
+	outPath := filepath.Join(g.outputDir, filepath.FromSlash(page.Path))
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", outPath, err)
+	}
+	if err := os.WriteFile(outPath, []byte(output), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", page.Path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// urlAttrRe matches the attributes of a page that hold links
+	urlAttrRe = regexp.MustCompile(`(?i)\b(href|src|action|content)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	// refreshURLRe matches the delay before the URL of a meta refresh
+	refreshURLRe = regexp.MustCompile(`(?i)^\s*\d+\s*;\s*url\s*=\s*`)
+	// pageURLRe matches paths and URLs of HTML pages in text
+	pageURLRe = regexp.MustCompile(`[^\s"'<>()\[\]]+\.html\b`)
+)
+
+// SetPrettyURLs generates every page as the index.html of its own
+// directory, e.g. docs/guide/index.html instead of docs/guide.html, and
+// links to pages by their directory, e.g. docs/guide/. Some hosts, and
+// webservers on I2P, serve directory URLs much better than .html
+// extensions.
+func (g *Generator) SetPrettyURLs(enabled bool) {
+	g.prettyURLs = enabled
+}
+
+// prettyPath returns the path of a page with pretty URLs
+func prettyPath(page string) string {
+	if base := path.Base(page); base == "index.html" || !strings.HasSuffix(base, ".html") {
+		return page
+	}
+	return strings.TrimSuffix(page, ".html") + "/index.html"
+}
+
+// pageLink returns the link to a page: its directory for an index.html
+func pageLink(page string) string {
+	return strings.TrimSuffix(page, "index.html")
+}
+
+// outputPage returns the final output path of a page, which differs from
+// the path it was generated at with pretty URLs
+func (g *Generator) outputPage(page string) string {
+	if moved, ok := g.prettyPages[page]; ok {
+		return moved
+	}
+	return page
+}
+
+// applyPrettyURLs moves the generated pages into directories of their own
+// and rewrites the links of the pages, the HTML assets such as redirect
+// pages, and the text assets such as the search index, the feed and
+// _redirects, to point at the directories
+func (g *Generator) applyPrettyURLs(result *GenerationResult) error {
+	g.prettyPages = make(map[string]string)
+	for _, page := range result.Pages {
+		g.prettyPages[page] = prettyPath(page)
+	}
+
+	// Absolute URLs of the site
+	var sitePrefixes []string
+	for _, prefix := range []string{g.publicURL(), g.siteURL()} {
+		if prefix != "" && !slices.Contains(sitePrefixes, prefix) {
+			sitePrefixes = append(sitePrefixes, prefix)
+		}
+	}
+	textPrefixes := append(slices.Clone(sitePrefixes), "/", "")
+
+	for i, page := range result.Pages {
+		moved := g.prettyPages[page]
+		content, err := os.ReadFile(filepath.Join(g.outputDir, filepath.FromSlash(page)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", page, err)
+		}
+		rewritten := g.rewritePageLinks(string(content), page, moved, sitePrefixes)
+		if moved != page {
+			if err := os.Remove(filepath.Join(g.outputDir, filepath.FromSlash(page))); err != nil {
+				return fmt.Errorf("failed to move %s: %w", page, err)
+			}
+			if err := os.MkdirAll(filepath.Join(g.outputDir, filepath.FromSlash(path.Dir(moved))), 0o755); err != nil {
+				return fmt.Errorf("failed to move %s: %w", page, err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(g.outputDir, filepath.FromSlash(moved)), []byte(rewritten), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", moved, err)
+		}
+		result.Pages[i] = moved
+	}
+
+	for _, asset := range result.Assets {
+		var rewrite func(string) string
+		switch ext := path.Ext(asset); {
+		case ext == ".html":
+			rewrite = func(content string) string {
+				return g.rewritePageLinks(content, asset, asset, sitePrefixes)
+			}
+		case ext == ".json" || ext == ".xml" || ext == ".txt" || path.Base(asset) == "_redirects":
+			rewrite = func(content string) string {
+				return g.rewritePageURLs(content, textPrefixes)
+			}
+		default:
+			continue
+		}
+		file := filepath.Join(g.outputDir, filepath.FromSlash(asset))
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", asset, err)
+		}
+		if rewritten := rewrite(string(content)); rewritten != string(content) {
+			if err := os.WriteFile(file, []byte(rewritten), 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", asset, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rewritePageLinks rewrites the links of a page generated at from and moved
+// to to, and absolute URLs of the site's pages in it
+func (g *Generator) rewritePageLinks(content, from, to string, sitePrefixes []string) string {
+	content = urlAttrRe.ReplaceAllStringFunc(content, func(attr string) string {
+		m := urlAttrRe.FindStringSubmatch(attr)
+		value := m[2] + m[3]
+		var rewritten string
+		if strings.EqualFold(m[1], "content") {
+			// Only the URL of a meta refresh is a link
+			delay := refreshURLRe.FindString(value)
+			if delay == "" {
+				return attr
+			}
+			rewritten = delay + g.rewriteLink(strings.TrimPrefix(value, delay), from, to, sitePrefixes)
+		} else {
+			rewritten = g.rewriteLink(value, from, to, sitePrefixes)
+		}
+		if rewritten == value {
+			return attr
+		}
+		return strings.Replace(attr, value, rewritten, 1)
+	})
+	content = srcsetRe.ReplaceAllStringFunc(content, func(attr string) string {
+		value := srcsetRe.FindStringSubmatch(attr)[1]
+		rewritten := value
+		for _, link := range srcsetURLs(value) {
+			rewritten = strings.Replace(rewritten, link, g.rewriteLink(link, from, to, sitePrefixes), 1)
+		}
+		return strings.Replace(attr, value, rewritten, 1)
+	})
+	// Absolute URLs outside of links, e.g. in structured data
+	return g.rewritePageURLs(content, sitePrefixes)
+}
+
+// rewriteLink rewrites a link of a page generated at from and moved to to,
+// so it still resolves from the new location and points at pages by their
+// directory. Links to other sites are left alone, and so are links that
+// don't change.
+func (g *Generator) rewriteLink(link, from, to string, sitePrefixes []string) string {
+	for _, prefix := range sitePrefixes {
+		if rest, ok := strings.CutPrefix(link, prefix); ok {
+			target, suffix := splitLinkSuffix(rest)
+			if moved, ok := g.prettyPages[target]; ok {
+				return prefix + pageLink(moved) + suffix
+			}
+			return link
+		}
+	}
+	if link == "" || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "?") || strings.HasPrefix(link, "//") || strings.Contains(link, ":") {
+		return link
+	}
+
+	target, suffix := splitLinkSuffix(link)
+	resolved := path.Join(path.Dir(from), target)
+	isDir := strings.HasSuffix(target, "/") || target == "." || target == ".."
+	if isDir {
+		resolved = path.Join(resolved, "index.html")
+	}
+
+	changed := from != to
+	if moved, ok := g.prettyPages[resolved]; ok {
+		resolved, isDir, changed = pageLink(moved), true, true
+	} else if strings.HasPrefix(resolved, "../") && prettyPath(resolved) != resolved {
+		// Pages of the other versions of versioned documentation
+		resolved, isDir, changed = pageLink(prettyPath(resolved)), true, true
+	} else if isDir {
+		resolved = pageLink(resolved)
+	}
+	if !changed {
+		return link
+	}
+
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(to)), filepath.FromSlash(strings.TrimSuffix(resolved, "/")))
+	if err != nil {
+		return link
+	}
+	rel = filepath.ToSlash(rel)
+	if isDir {
+		rel += "/"
+	}
+	return rel + suffix
+}
+
+// splitLinkSuffix splits a link into its path and its query and fragment
+func splitLinkSuffix(link string) (string, string) {
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		return link[:i], link[i:]
+	}
+	return link, ""
+}
+
+// rewritePageURLs points the URLs of pages in text, such as the search
+// index or the feed, at the pages' directories: the URLs starting with one
+// of prefixes followed by the path of a page
+func (g *Generator) rewritePageURLs(content string, prefixes []string) string {
+	return pageURLRe.ReplaceAllStringFunc(content, func(link string) string {
+		for _, prefix := range prefixes {
+			if page, ok := strings.CutPrefix(link, prefix); ok {
+				if moved, ok := g.prettyPages[page]; ok {
+					return prefix + pageLink(moved)
+				}
+			}
+		}
+		return link
+	})
+}
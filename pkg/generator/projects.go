@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// project is a subdirectory of a monorepo presented as its own section of the site
+type project struct {
+	Dir    string // relative to the repository root, slash-separated
+	Title  string
+	Readme string // source path of its README, if any
+}
+
+// ProjectLink is an entry of the project list on the home page
+type ProjectLink struct {
+	Title       string
+	Description string
+	// Path is relative to the site root
+	Path string
+}
+
+// SetProjects treats the given directories of a monorepo as separate
+// projects. Each gets its own section in the navigation, with its README as
+// the section's landing page, and is listed on the home page.
+func (g *Generator) SetProjects(dirs []string) {
+	g.projects = nil
+	for _, dir := range dirs {
+		dir = strings.Trim(filepath.ToSlash(filepath.Clean(dir)), "/")
+		if dir == "" || dir == "." {
+			continue
+		}
+		g.projects = append(g.projects, &project{Dir: dir})
+	}
+}
+
+// loadProjects finds the README and title of each project
+func (g *Generator) loadProjects() {
+	for _, p := range g.projects {
+		p.Title = utils.PrettifyFilename(filepath.Base(p.Dir))
+		for path := range g.indexPages {
+			if filepath.ToSlash(filepath.Dir(path)) == p.Dir && g.pageLanguage(path) == g.defaultLang() {
+				p.Readme = path
+				break
+			}
+		}
+		if p.Readme == "" {
+			g.logger.Warn("Project has no README", "dir", p.Dir)
+			continue
+		}
+		if title := g.frontMatter[p.Readme].Title; title != "" {
+			p.Title = title
+		} else if title := utils.GetTitleFromMarkdown(g.markdown[p.Readme]); title != "" {
+			p.Title = title
+		}
+	}
+}
+
+// projectOf returns the project a source path belongs to, if any. Nested
+// projects take precedence over the projects containing them.
+func (g *Generator) projectOf(path string) *project {
+	path = filepath.ToSlash(path)
+	var match *project
+	for _, p := range g.projects {
+		if strings.HasPrefix(path, p.Dir+"/") && (match == nil || len(p.Dir) > len(match.Dir)) {
+			match = p
+		}
+	}
+	return match
+}
+
+// projectLinks lists the projects for the home page, in the configured order
+func (g *Generator) projectLinks() []ProjectLink {
+	var links []ProjectLink
+	for _, p := range g.projects {
+		if p.Readme == "" {
+			continue
+		}
+		description := g.frontMatter[p.Readme].Description
+		if description == "" {
+			description = git.DescriptionFromReadme(g.markdown[p.Readme])
+		}
+		links = append(links, ProjectLink{
+			Title:       p.Title,
+			Description: description,
+			Path:        g.docOutputPath(p.Readme),
+		})
+	}
+	return links
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetRawMarkdown copies the markdown source of every page into the site,
+// next to the page with a .md extension (docs/foo.md beside docs/foo.html),
+// and links it from the page, so the documentation can be fetched as text
+func (g *Generator) SetRawMarkdown(enabled bool) {
+	g.rawMarkdown = enabled
+}
+
+// rawOutputPath returns the output path of the markdown source of a page
+func rawOutputPath(pagePath string) string {
+	return strings.TrimSuffix(pagePath, ".html") + ".md"
+}
+
+// hasRawMarkdown reports whether a document is copied into the site as
+// markdown. Documents converted from other formats aren't.
+func (g *Generator) hasRawMarkdown(path string) bool {
+	if !(g.rawMarkdown || g.llmsTxt) || path == "" {
+		return false
+	}
+	_, converted := g.converted[path]
+	return !converted
+}
+
+// rawSource returns the markdown of a document as it is in the repository or
+// wiki, with its front matter
+func (g *Generator) rawSource(path string) string {
+	if g.wiki[path] {
+		return g.repoData.WikiPages[strings.TrimPrefix(path, "wiki"+string(filepath.Separator))]
+	}
+	return g.repoData.MarkdownFiles[path]
+}
+
+// writeRawMarkdown writes the unmodified source of the markdown documents to
+// the output. sources maps the output path of every page to its document.
+func (g *Generator) writeRawMarkdown(result *GenerationResult, sources map[string]string) error {
+	for pagePath, source := range sources {
+		if !g.hasRawMarkdown(source) {
+			continue
+		}
+		outputPath := rawOutputPath(pagePath)
+		fullPath := filepath.Join(g.outputDir, filepath.FromSlash(outputPath))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(g.rawSource(source)), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", outputPath, err)
+		}
+		result.Assets = append(result.Assets, outputPath)
+	}
+	return nil
+}
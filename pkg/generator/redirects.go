@@ -0,0 +1,229 @@
+package generator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// DefaultRedirectsFile is the redirect map read from the repository when no
+// other file is given
+const DefaultRedirectsFile = "redirects.txt"
+
+// redirect sends visitors of a path of the site to another page
+type redirect struct {
+	// From is the old path, relative to the site root
+	From string
+	// To is a path relative to the site root, or an absolute URL
+	To string
+}
+
+// SetRedirects sets the file of the redirect map, relative to the
+// repository if it isn't absolute. Without a file, DefaultRedirectsFile is
+// read from the repository if it exists.
+func (g *Generator) SetRedirects(file string) {
+	g.redirectsFile = file
+}
+
+// SetMoved sets the documents moved since the site was first published, so
+// their old pages redirect to the new ones: earlier maps the former paths
+// recorded by the last build to the paths at its commit, and renames maps
+// the files renamed since that commit to their current paths.
+func (g *Generator) SetMoved(earlier, renames map[string]string) {
+	g.moved, g.renames = earlier, renames
+}
+
+// movedRedirects returns the redirects from the pages of moved documents to
+// their current pages, and records the moves in the result. Moves of
+// documents that were deleted, or whose old path is in use again, are
+// dropped.
+func (g *Generator) movedRedirects(result *GenerationResult) []redirect {
+	moved := make(map[string]string)
+	for from, to := range g.moved {
+		if renamed, ok := g.renames[to]; ok {
+			to = renamed
+		}
+		moved[from] = to
+	}
+	for from, to := range g.renames {
+		moved[from] = to
+	}
+
+	var redirects []redirect
+	for from, to := range moved {
+		if _, ok := g.markdown[to]; !ok || g.skipDocPage(to) {
+			continue
+		}
+		if _, ok := g.markdown[from]; ok {
+			continue
+		}
+		oldPath := g.docOutputPath(from)
+		if oldPath == g.documentPagePath(to) {
+			continue
+		}
+		if result.Moved == nil {
+			result.Moved = make(map[string]string)
+		}
+		result.Moved[from] = to
+		redirects = append(redirects, redirect{From: oldPath, To: to})
+	}
+	sort.Slice(redirects, func(i, j int) bool { return redirects[i].From < redirects[j].From })
+	return redirects
+}
+
+// parseRedirects parses a redirect map: one redirect per line, the old path
+// and the new path or URL separated by whitespace or "->". Blank lines and
+// lines starting with # are ignored. Paths are relative to the site root; a
+// path ending in / or without an extension is a directory, whose index.html
+// redirects.
+func parseRedirects(content string) ([]redirect, error) {
+	var redirects []redirect
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.Replace(line, "->", " ", 1))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected an old path and a new path, got %q", n, line)
+		}
+		from := strings.TrimPrefix(fields[0], "/")
+		if strings.Contains(from, "://") || from == "" || slices.Contains(strings.Split(from, "/"), "..") {
+			return nil, fmt.Errorf("line %d: %q is not a path of the site", n, fields[0])
+		}
+		redirects = append(redirects, redirect{From: from, To: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read redirects: %w", err)
+	}
+	return redirects, nil
+}
+
+// loadRedirects reads the redirect map, if there is one
+func (g *Generator) loadRedirects() ([]redirect, error) {
+	file := g.redirectsFile
+	if file == "" {
+		file = DefaultRedirectsFile
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(g.repoData.Path, file)
+	}
+	content, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) && g.redirectsFile == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redirects: %w", err)
+	}
+	redirects, err := parseRedirects(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirects file %s: %w", file, err)
+	}
+	return redirects, nil
+}
+
+// redirectStubPath returns the output path of the page redirecting from a path
+func redirectStubPath(from string) string {
+	if strings.HasSuffix(from, "/") || path.Ext(from) == "" {
+		return path.Join(from, "index.html")
+	}
+	return from
+}
+
+// redirectTarget resolves the target of a redirect: documents named by
+// their source path point at their page
+func (g *Generator) redirectTarget(to string) string {
+	if strings.Contains(to, "://") {
+		return to
+	}
+	target, anchor, hasAnchor := strings.Cut(strings.TrimPrefix(to, "/"), "#")
+	for source := range g.markdown {
+		if filepath.ToSlash(source) == target {
+			target = g.documentPagePath(source)
+			break
+		}
+	}
+	if hasAnchor {
+		target += "#" + anchor
+	}
+	return target
+}
+
+// writeRedirects writes a page for each redirect that sends visitors on to
+// the new path, and a _redirects file with the same redirects for hosts
+// such as Netlify and Cloudflare Pages. Redirects from paths the site
+// already has a page at are skipped.
+func (g *Generator) writeRedirects(result *GenerationResult, redirects []redirect) error {
+	written := make(map[string]bool)
+	for _, page := range result.Pages {
+		written[page] = true
+	}
+
+	var rules []string
+	for _, r := range redirects {
+		stub := redirectStubPath(r.From)
+		if written[stub] {
+			g.logger.Warn("Skipping redirect from a path the site has a page at", "from", r.From)
+			continue
+		}
+		written[stub] = true
+
+		target := g.redirectTarget(r.To)
+		link := target
+		if !strings.Contains(target, "://") {
+			link = utils.RelativeRoot(stub) + target
+		}
+		escaped := html.EscapeString(link)
+		page := `<!DOCTYPE html>
+<html lang="` + g.defaultLang() + `">
+<head>
+  <meta charset="UTF-8">
+  <title>` + html.EscapeString(g.message(g.defaultLang(), "Redirecting")) + `</title>
+  <meta http-equiv="refresh" content="0; url=` + escaped + `">
+  <link rel="canonical" href="` + escaped + `">
+  <meta name="robots" content="noindex">
+</head>
+<body>
+  <p>` + html.EscapeString(g.message(g.defaultLang(), "PageMoved")) + ` <a href="` + escaped + `">` + html.EscapeString(target) + `</a></p>
+</body>
+</html>
+`
+		fullPath := filepath.Join(g.outputDir, filepath.FromSlash(stub))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", stub, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(page), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", stub, err)
+		}
+		result.Assets = append(result.Assets, stub)
+
+		if !strings.Contains(target, "://") {
+			target = "/" + target
+		}
+		rules = append(rules, "/"+r.From+" "+target+" 301")
+		if stub != r.From {
+			rules = append(rules, "/"+stub+" "+target+" 301")
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	sort.Strings(rules)
+	if err := os.WriteFile(filepath.Join(g.outputDir, "_redirects"), []byte(strings.Join(rules, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write _redirects: %w", err)
+	}
+	result.Assets = append(result.Assets, "_redirects")
+	return nil
+}
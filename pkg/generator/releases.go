@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// ReleaseEntry is a single release shown on the releases page
+type ReleaseEntry struct {
+	Tag        string
+	Name       string
+	Date       string
+	NotesHTML  string
+	URL        string
+	Prerelease bool
+	Downloads  []Download
+
+	date time.Time
+}
+
+// Download is a downloadable file for a release
+type Download struct {
+	Name string
+	URL  string
+}
+
+// buildReleaseEntries merges git tags with GitHub Releases, newest first
+func (g *Generator) buildReleaseEntries() []ReleaseEntry {
+	releases := make(map[string]int)
+	for i, r := range g.repoData.Releases {
+		releases[r.TagName] = i
+	}
+
+	var entries []ReleaseEntry
+	seen := make(map[string]bool)
+
+	for _, tag := range g.repoData.Tags {
+		entry := ReleaseEntry{
+			Tag:       tag.Name,
+			Name:      tag.Name,
+			NotesHTML: g.sanitizeContent(renderMarkdown(tag.Message)),
+			URL:       g.repoData.URL + "/tree/" + tag.Name,
+			date:      tag.Date,
+		}
+		if i, ok := releases[tag.Name]; ok {
+			g.applyRelease(&entry, i)
+		}
+		entry.Downloads = append(entry.Downloads, g.sourceArchives(tag.Name)...)
+
+		entries = append(entries, entry)
+		seen[tag.Name] = true
+	}
+
+	// Releases whose tags aren't part of the clone
+	for i, r := range g.repoData.Releases {
+		if seen[r.TagName] {
+			continue
+		}
+		entry := ReleaseEntry{Tag: r.TagName, Name: r.TagName}
+		g.applyRelease(&entry, i)
+		entry.Downloads = append(entry.Downloads, g.sourceArchives(r.TagName)...)
+		entries = append(entries, entry)
+	}
+
+	// Newest first
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].date.After(entries[j].date)
+	})
+
+	for i := range entries {
+		entries[i].Date = g.formatDate(entries[i].date)
+	}
+
+	return entries
+}
+
+// applyRelease fills a release entry with the notes and assets of a GitHub Release
+func (g *Generator) applyRelease(entry *ReleaseEntry, index int) {
+	release := g.repoData.Releases[index]
+	if release.Name != "" {
+		entry.Name = release.Name
+	}
+	if release.Body != "" {
+		entry.NotesHTML = g.sanitizeContent(renderMarkdown(release.Body))
+	}
+	if release.URL != "" {
+		entry.URL = release.URL
+	}
+	if !release.PublishedAt.IsZero() {
+		entry.date = release.PublishedAt
+	}
+	entry.Prerelease = release.Prerelease
+	for _, asset := range release.Assets {
+		entry.Downloads = append(entry.Downloads, Download{Name: asset.Name, URL: asset.URL})
+	}
+}
+
+// sourceArchives returns the source code download links for a tag
+func (g *Generator) sourceArchives(tag string) []Download {
+	return []Download{
+		{Name: "Source code (zip)", URL: g.repoData.URL + "/archive/refs/tags/" + tag + ".zip"},
+		{Name: "Source code (tar.gz)", URL: g.repoData.URL + "/archive/refs/tags/" + tag + ".tar.gz"},
+	}
+}
+
+// generateReleasesPage creates releases.html listing the release history
+func (g *Generator) generateReleasesPage(docsPages []utils.DocPage) error {
+	page := g.newPage("releases", "releases.html", g.defaultLang(), docsPages)
+	page.Data.Releases = g.releases
+	page.Data.PageTitle = g.message(g.defaultLang(), "Releases") + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = g.message(g.defaultLang(), "Releases")
+
+	return g.renderPage(page)
+}
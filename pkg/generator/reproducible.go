@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SetReproducible makes two builds of the same commit byte-identical: dates
+// written into the site are taken from the last commit instead of the clock,
+// and generated files get the commit date as their modification time
+func (g *Generator) SetReproducible(enabled bool) {
+	g.reproducible = enabled
+}
+
+// now returns the time the site is generated at, which is the date of the
+// last commit in reproducible builds
+func (g *Generator) now() time.Time {
+	if g.reproducible && !g.repoData.LastCommitDate.IsZero() {
+		return g.repoData.LastCommitDate.UTC()
+	}
+	return time.Now()
+}
+
+// stampFiles sets the modification time of the generated files to the date of
+// the last commit
+func (g *Generator) stampFiles(result *GenerationResult) error {
+	stamp := g.now()
+	for _, files := range [][]string{result.Pages, result.Assets} {
+		for _, file := range files {
+			path := filepath.Join(g.outputDir, filepath.FromSlash(file))
+			if err := os.Chtimes(path, stamp, stamp); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to set the time of %s: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
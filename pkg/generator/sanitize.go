@@ -0,0 +1,269 @@
+package generator
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// The sanitizer follows the allowlist of bluemonday's UGCPolicy, but is
+// written on golang.org/x/net/html, which the generator already uses to
+// rewrite HTML, rather than pulling in bluemonday along with gorilla/css and
+// aymerick/douceur: styles are never kept, so their CSS parsing isn't
+// needed. sanitize_test.go covers the attacks the policy has to stop.
+
+// sanitizeElements are the elements kept in the repository's HTML, with the
+// attributes allowed on each besides sanitizeGlobalAttrs
+var sanitizeElements = map[string][]string{
+	"a": {"href", "name", "target", "rel"}, "abbr": nil, "b": nil, "bdi": nil, "bdo": nil,
+	"blockquote": {"cite"}, "br": nil, "caption": nil, "cite": nil, "code": nil, "col": {"span"},
+	"colgroup": {"span"}, "dd": nil, "del": {"cite", "datetime"}, "details": {"open"}, "dfn": nil,
+	"div": nil, "dl": nil, "dt": nil, "em": nil, "figcaption": nil, "figure": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil, "hr": nil, "i": nil,
+	"img":   {"src", "srcset", "sizes", "alt", "width", "height", "loading", "decoding"},
+	"input": {"type", "disabled", "checked"}, "ins": {"cite", "datetime"}, "kbd": nil,
+	"li": {"value"}, "mark": nil, "ol": {"start", "type", "reversed"}, "p": nil, "picture": nil,
+	"pre": nil, "progress": {"value", "max"}, "q": {"cite"}, "rp": nil, "rt": nil, "ruby": nil,
+	"s": nil, "samp": nil, "small": nil, "source": {"srcset", "sizes", "type", "media"},
+	"span": nil, "strike": nil, "strong": nil, "sub": nil, "summary": nil, "sup": nil,
+	"table": nil, "tbody": nil, "td": {"colspan", "rowspan"}, "tfoot": nil,
+	"th": {"colspan", "rowspan", "scope"}, "thead": nil, "time": {"datetime"}, "tr": nil,
+	"tt": nil, "u": nil, "ul": nil, "var": nil, "wbr": nil,
+}
+
+// sanitizeGlobalAttrs are the attributes allowed on every kept element
+var sanitizeGlobalAttrs = []string{"id", "class", "title", "lang", "dir", "align", "aria-label", "aria-hidden"}
+
+// sanitizeDropped are the elements of the repository's HTML removed along with
+// their content. Other elements that aren't kept are unwrapped, keeping their
+// content.
+var sanitizeDropped = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "template": true,
+	"textarea": true, "select": true, "title": true, "svg": true, "math": true,
+	"form": true, "button": true,
+}
+
+// sanitizeSchemes are the URL schemes allowed in links and image sources
+var sanitizeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// sanitizePolicy is the set of elements and attributes a sanitizer keeps
+type sanitizePolicy struct {
+	// elements are the elements kept, with the attributes allowed on each
+	// besides sanitizeGlobalAttrs
+	elements map[string][]string
+	// dropped are the elements removed along with their content
+	dropped map[string]bool
+	// inputs are the types of the <input> elements kept
+	inputs []string
+}
+
+// repoPolicy is the policy for HTML written in the repository
+var repoPolicy = sanitizePolicy{
+	elements: sanitizeElements,
+	dropped:  sanitizeDropped,
+	inputs:   []string{"checkbox"},
+}
+
+// shortcodePolicy is the policy for the output of shortcodes, whose
+// arguments come from the repository. It also keeps the video players,
+// YouTube embeds and tabs the built-in shortcodes generate.
+var shortcodePolicy = sanitizePolicy{
+	elements: extendElements(sanitizeElements, map[string][]string{
+		"iframe":  {"src", "loading", "allow", "allowfullscreen"},
+		"input":   {"type", "name", "checked", "disabled"},
+		"label":   {"for"},
+		"section": nil,
+		"video":   {"src", "controls", "preload", "poster"},
+	}),
+	dropped: extendDropped(sanitizeDropped, "iframe"),
+	inputs:  []string{"checkbox", "radio"},
+}
+
+// youtubeEmbedURL is the only source iframes may have in shortcode output
+const youtubeEmbedURL = "https://www.youtube-nocookie.com/embed/"
+
+// extendElements returns elements with the attributes of extra added
+func extendElements(elements, extra map[string][]string) map[string][]string {
+	extended := make(map[string][]string, len(elements)+len(extra))
+	for name, attrs := range elements {
+		extended[name] = attrs
+	}
+	for name, attrs := range extra {
+		extended[name] = append(slices.Clip(extended[name]), attrs...)
+	}
+	return extended
+}
+
+// extendDropped returns dropped without the kept elements
+func extendDropped(dropped map[string]bool, kept ...string) map[string]bool {
+	extended := make(map[string]bool, len(dropped))
+	for name := range dropped {
+		if !slices.Contains(kept, name) {
+			extended[name] = true
+		}
+	}
+	return extended
+}
+
+// SetSanitize enables removing scripts, event handlers, embeds and unsafe
+// URLs from the HTML rendered from the repository, for sites generated from
+// repositories that aren't trusted. Hand-written HTML pages are then rendered
+// inside the doc template, sanitized, instead of being copied through.
+func (g *Generator) SetSanitize(enabled bool) {
+	g.sanitize = enabled
+}
+
+// sanitizeContent sanitizes HTML rendered from the repository, if enabled
+func (g *Generator) sanitizeContent(content string) string {
+	if !g.sanitize {
+		return content
+	}
+	return sanitizeHTML(content)
+}
+
+// sanitizeHTML keeps an allowlist of elements and attributes of an HTML
+// fragment. The placeholders left by shortcodes are kept, so their output,
+// sanitized with shortcodePolicy when it was generated, is restored as it is.
+func sanitizeHTML(fragment string) string {
+	return repoPolicy.sanitize(fragment)
+}
+
+// sanitizeShortcodeHTML sanitizes the HTML generated by a shortcode
+func sanitizeShortcodeHTML(fragment string) string {
+	return shortcodePolicy.sanitize(fragment)
+}
+
+// sanitize keeps the elements and attributes of an HTML fragment the policy
+// allows
+func (p sanitizePolicy) sanitize(fragment string) string {
+	var out strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+
+	// Name and nesting depth of the element being dropped, if any
+	dropping := ""
+	depth := 0
+
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			return out.String()
+		}
+		token := z.Token()
+
+		if dropping != "" {
+			switch {
+			case tt == nethtml.StartTagToken && token.Data == dropping:
+				depth++
+			case tt == nethtml.EndTagToken && token.Data == dropping:
+				depth--
+				if depth == 0 {
+					dropping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case nethtml.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+		case nethtml.CommentToken:
+			if shortcodeMarkerRe.MatchString("<!--" + token.Data + "-->") {
+				out.WriteString("<!--" + token.Data + "-->")
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if p.dropped[token.Data] {
+				if tt == nethtml.StartTagToken {
+					dropping, depth = token.Data, 1
+				}
+				continue
+			}
+			if allowed, ok := p.elements[token.Data]; ok {
+				p.writeTag(&out, token, allowed)
+			}
+		case nethtml.EndTagToken:
+			if _, ok := p.elements[token.Data]; ok {
+				out.WriteString("</" + token.Data + ">")
+			}
+		}
+	}
+}
+
+// writeTag writes a start tag with its allowed attributes
+func (p sanitizePolicy) writeTag(out *strings.Builder, token nethtml.Token, allowed []string) {
+	// Inputs are only kept as the checkboxes of task lists, and the radio
+	// buttons of tabs
+	if token.Data == "input" && !slices.Contains(p.inputs, attrValue(token, "type")) {
+		return
+	}
+
+	out.WriteString("<" + token.Data)
+	blank := false
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !(slices.Contains(allowed, attr.Key) || slices.Contains(sanitizeGlobalAttrs, attr.Key)) {
+			continue
+		}
+		switch attr.Key {
+		case "href", "src", "cite", "poster":
+			if !safeURL(attr.Val) || (token.Data == "iframe" && !strings.HasPrefix(attr.Val, youtubeEmbedURL)) {
+				continue
+			}
+		case "srcset":
+			if !safeSrcset(attr.Val) {
+				continue
+			}
+		case "target":
+			if attr.Val != "_blank" {
+				continue
+			}
+			blank = true
+		case "rel":
+			// Set below for links opening in a new tab
+			if attrValue(token, "target") == "_blank" {
+				continue
+			}
+		}
+		if attr.Val == "" {
+			out.WriteString(" " + attr.Key)
+		} else {
+			out.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+		}
+	}
+	if blank {
+		out.WriteString(` rel="noopener noreferrer"`)
+	}
+	out.WriteString(">")
+}
+
+// safeURL reports whether a URL is relative or uses an allowed scheme
+func safeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || sanitizeSchemes[strings.ToLower(u.Scheme)]
+}
+
+// safeSrcset reports whether every URL of a srcset is safe
+func safeSrcset(srcset string) bool {
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !safeURL(fields[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// attrValue returns the value of an attribute of a token
+func attrValue(token nethtml.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"script", `<p>a</p><script>alert(1)</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"nested script", `<script><script>alert(1)</script></script>ok`, `ok`},
+		{"style", `<style>body{display:none}</style>text`, `text`},
+		{"event handler", `<img src="a.png" onerror="alert(1)">`, `<img src="a.png">`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"mixed case scheme", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{"entity encoded scheme", `<a href="&#106;avascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"control character in scheme", "<a href=\"java\tscript:alert(1)\">x</a>", `<a>x</a>`},
+		{"data image", `<img src="data:text/html,<script>alert(1)</script>">`, `<img>`},
+		{"srcset", `<img srcset="a.png 1x, javascript:alert(1) 2x">`, `<img>`},
+		{"iframe", `<iframe src="https://example.com"></iframe>after`, `after`},
+		{"svg", `<svg onload="alert(1)"><circle/></svg>after`, `after`},
+		{"style attribute", `<p style="background:url(javascript:alert(1))">x</p>`, `<p>x</p>`},
+		{"unknown element unwrapped", `<marquee>moving</marquee>`, `moving`},
+		{"text escaped", `&lt;script&gt;`, `&lt;script&gt;`},
+		{"quoted attribute", `<a title='"><script>alert(1)</script>'>x</a>`, `<a title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`},
+		{"form", `<form action="https://example.com"><input type="text" name="q"></form>after`, `after`},
+		{"text input", `<input type="text" value="x">`, ``},
+		{"task list checkbox", `<input type="checkbox" checked disabled>`, `<input type="checkbox" checked disabled>`},
+		{"new tab", `<a href="https://example.com" target="_blank" rel="opener">x</a>`, `<a href="https://example.com" target="_blank" rel="noopener noreferrer">x</a>`},
+		{"other target", `<a href="https://example.com" target="_top">x</a>`, `<a href="https://example.com">x</a>`},
+		{"safe markup", `<p class="note"><a href="../docs/a.html#b">a</a> <code>b</code></p>`, `<p class="note"><a href="../docs/a.html#b">a</a> <code>b</code></p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeHTML(tt.in); got != tt.want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeShortcodeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"youtube embed", `<iframe src="https://www.youtube-nocookie.com/embed/x" loading="lazy" allowfullscreen></iframe>`, `<iframe src="https://www.youtube-nocookie.com/embed/x" loading="lazy" allowfullscreen></iframe>`},
+		{"other iframe", `<iframe src="https://example.com/"></iframe>`, `<iframe></iframe>`},
+		{"video", `<video src="demo.mp4" controls preload="metadata"></video>`, `<video src="demo.mp4" controls preload="metadata"></video>`},
+		{"javascript video", `<video src="javascript:alert(1)" controls></video>`, `<video controls></video>`},
+		{"data video", `<video src="data:video/mp4;base64,AAAA" poster="javascript:alert(1)"></video>`, `<video></video>`},
+		{"tab", `<input type="radio" class="tab-input" name="sc1" id="sc1-0" checked><label class="tab-label" for="sc1-0">A</label>`, `<input type="radio" class="tab-input" name="sc1" id="sc1-0" checked><label class="tab-label" for="sc1-0">A</label>`},
+		{"script", `<div class="tabs"><script>alert(1)</script>`, `<div class="tabs">`},
+		{"event handler", `<section class="hero" onmouseover="alert(1)">`, `<section class="hero">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeShortcodeHTML(tt.in); got != tt.want {
+				t.Errorf("sanitizeShortcodeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateURL(t *testing.T) {
+	tests := []struct {
+		in       string
+		sanitize bool
+		want     string
+	}{
+		{"https://example.com/?a=1&b=2", true, "https://example.com/?a=1&amp;b=2"},
+		{"../docs/a.html", true, "../docs/a.html"},
+		{"mailto:security@example.com", true, "mailto:security@example.com"},
+		{"javascript:alert(1)", true, ""},
+		{" JavaScript:alert(1)", true, ""},
+		{"data:text/html,x", true, ""},
+		{"javascript:alert(1)", false, "javascript:alert(1)"},
+	}
+	for _, tt := range tests {
+		if got := templateURL(tt.in, tt.sanitize); got != tt.want {
+			t.Errorf("templateURL(%q, %v) = %q, want %q", tt.in, tt.sanitize, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeHTMLKeepsShortcodePlaceholders(t *testing.T) {
+	g := &Generator{}
+	var out strings.Builder
+	g.writeShortcodePlaceholder(&out, `<iframe src="https://www.youtube-nocookie.com/embed/x"></iframe>`)
+	placeholder := strings.TrimSpace(out.String())
+	if got := sanitizeHTML(placeholder); got != placeholder {
+		t.Errorf("sanitizeHTML(%q) = %q, want the placeholder kept", placeholder, got)
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/go-i2p/go-gh-page/pkg/templates"
+)
+
+// TemplateDataExample returns an example of the data page templates are
+// rendered with, as indented JSON. Every field is present under the name
+// templates use: strings hold the name of their field, lists have one
+// element and T holds the English messages.
+func TemplateDataExample() ([]byte, error) {
+	data := exampleValue(reflect.TypeOf(PageData{}), "", make(map[reflect.Type]bool))
+	data.FieldByName("T").Set(reflect.ValueOf(templates.Catalog("en")))
+	return json.MarshalIndent(data.Interface(), "", "  ")
+}
+
+// exampleValue returns a value of type t for the example template data,
+// with every exported field set and one element in every list. Recursive
+// types, such as the navigation tree, are expanded once.
+func exampleValue(t reflect.Type, name string, parents map[reflect.Type]bool) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(name)
+	case reflect.Pointer:
+		if parents[t.Elem()] {
+			return v
+		}
+		v.Set(exampleValue(t.Elem(), name, parents).Addr())
+	case reflect.Struct:
+		parents[t] = true
+		defer delete(parents, t)
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				v.Field(i).Set(exampleValue(f.Type, f.Name, parents))
+			}
+		}
+	case reflect.Slice:
+		elem := t.Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if parents[elem] {
+			return v
+		}
+		v.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), exampleValue(t.Elem(), name, parents)))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		if t.Key().Kind() == reflect.String {
+			v.SetMapIndex(reflect.ValueOf("key").Convert(t.Key()), exampleValue(t.Elem(), name, parents))
+		}
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-i2p/go-gh-page/pkg/templates"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// searchEntry is a section of a page in search-index.json: the text from
+// one heading to the next, or the text before the first heading
+type searchEntry struct {
+	Title string `json:"title"`
+	// Section is the text of the section's heading, if any
+	Section string `json:"section,omitempty"`
+	URL     string `json:"url"`
+	Lang    string `json:"lang,omitempty"`
+	Text    string `json:"text"`
+}
+
+// SetSearch adds client-side search of the documentation: a search box in
+// the navigation leading to search.html, which looks the terms up in
+// search-index.json, and opensearch.xml so browsers can add the site as a
+// search engine
+func (g *Generator) SetSearch(enabled bool) {
+	g.search = enabled
+}
+
+// generateSearch writes the search page, its script, the search index of
+// the home pages and documentation pages, and the OpenSearch description.
+// sources maps the output path of every page to its document.
+func (g *Generator) generateSearch(result *GenerationResult, docsPages []utils.DocPage, sources map[string]string) error {
+	paths := make([]string, 0, len(sources))
+	for pagePath := range sources {
+		paths = append(paths, pagePath)
+	}
+	sort.Strings(paths)
+
+	entries := []searchEntry{}
+	for _, pagePath := range paths {
+		source := sources[pagePath]
+		lang := g.pageLanguage(source)
+		title := g.pageTitle(source)
+		if source == g.languageReadme(lang) && pagePath == g.langPrefix(lang)+"index.html" {
+			title = g.repoData.Name
+		}
+		content := headingAnchorRe.ReplaceAllString(g.renderDocContent(source, g.markdown[source], utils.RelativeRoot(pagePath)), "")
+		for _, section := range searchSections(content) {
+			entry := searchEntry{Title: title, Section: section.heading, URL: pagePath, Lang: lang, Text: section.text}
+			if section.id != "" {
+				entry.URL += "#" + section.id
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if err := g.writeJSON("search-index.json", entries); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(g.outputDir, "search.js"), []byte(templates.SearchScript), 0o644); err != nil {
+		return fmt.Errorf("failed to write search.js: %w", err)
+	}
+
+	lang := g.defaultLang()
+	heading := g.message(lang, "Search")
+	page := g.newPage("search", "search.html", lang, docsPages)
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+	page.Data.NoIndex = true
+	if err := g.renderPage(page); err != nil {
+		return fmt.Errorf("failed to generate search page: %w", err)
+	}
+
+	description := g.repoData.Owner + "/" + g.repoData.Name + ": " + g.message(lang, "SearchPlaceholder")
+	opensearch := `<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <ShortName>` + html.EscapeString(truncateRunes(g.repoData.Name, 16)) + `</ShortName>
+  <Description>` + html.EscapeString(description) + `</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <Url type="text/html" method="get" template="` + html.EscapeString(g.publicURL()) + `search.html?q={searchTerms}"/>
+  <Url type="application/opensearchdescription+xml" rel="self" template="` + html.EscapeString(g.publicURL()) + `opensearch.xml"/>
+</OpenSearchDescription>
+`
+	if err := os.WriteFile(filepath.Join(g.outputDir, "opensearch.xml"), []byte(opensearch), 0o644); err != nil {
+		return fmt.Errorf("failed to write opensearch.xml: %w", err)
+	}
+
+	result.Pages = append(result.Pages, "search.html")
+	result.Assets = append(result.Assets, "search-index.json", "search.js", "opensearch.xml")
+	return nil
+}
+
+// searchSection is the text of a page under one heading
+type searchSection struct {
+	id, heading, text string
+}
+
+// searchSections splits rendered content at its headings. The text before
+// the first heading is a section without a heading; empty sections are left
+// out, except for headings.
+func searchSections(content string) []searchSection {
+	var sections []searchSection
+	start, current := 0, searchSection{}
+	for _, m := range headingRe.FindAllStringSubmatchIndex(content, -1) {
+		current.text = plainText(content[start:m[0]])
+		if current.text != "" || current.heading != "" {
+			sections = append(sections, current)
+		}
+		current = searchSection{id: content[m[4]:m[5]], heading: plainText(content[m[6]:m[7]])}
+		start = m[1]
+	}
+	current.text = plainText(content[start:])
+	if current.text != "" || current.heading != "" {
+		sections = append(sections, current)
+	}
+	return sections
+}
+
+// truncateRunes cuts a string to at most n characters
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
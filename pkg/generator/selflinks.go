@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SetRewriteSelfLinks sets whether absolute links to files of the repository
+// on GitHub, such as https://github.com/owner/repo/blob/main/docs/foo.md,
+// point at their pages on the site, and links to its images at the local
+// copies
+func (g *Generator) SetRewriteSelfLinks(enabled bool) {
+	g.rewriteSelfLinks = enabled
+}
+
+// selfLinkTarget returns the file of the repository an absolute URL points
+// at on the default branch, with its anchor, and whether the URL serves the
+// file itself (raw.githubusercontent.com, /raw/ or ?raw=true) rather than
+// GitHub's page of it
+func (g *Generator) selfLinkTarget(link string) (file, anchor string, raw, ok bool) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", "", false, false
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	switch strings.ToLower(u.Host) {
+	case "github.com", "www.github.com":
+		// owner/repo/blob/<ref>/<path> or owner/repo/raw/<ref>/<path>
+		if len(parts) < 5 || (parts[2] != "blob" && parts[2] != "raw") {
+			return "", "", false, false
+		}
+		raw = parts[2] == "raw" || u.Query().Get("raw") == "true"
+		parts = append(parts[:2], parts[3:]...)
+	case "raw.githubusercontent.com":
+		// owner/repo/<ref>/<path>
+		if len(parts) < 4 {
+			return "", "", false, false
+		}
+		raw = true
+	default:
+		return "", "", false, false
+	}
+
+	if !strings.EqualFold(parts[0], g.repoData.Owner) || !strings.EqualFold(parts[1], g.repoData.Name) || !g.isDefaultRef(parts[2]) {
+		return "", "", false, false
+	}
+	file = path.Clean(strings.Join(parts[3:], "/"))
+	if file == "." || strings.HasPrefix(file, "../") {
+		return "", "", false, false
+	}
+	return file, u.Fragment, raw, true
+}
+
+// isDefaultRef reports whether a branch in a GitHub URL is the branch the
+// site is built from. Without a known default branch, main and master are
+// assumed to be it.
+func (g *Generator) isDefaultRef(ref string) bool {
+	if ref == "HEAD" || ref == g.branch() {
+		return true
+	}
+	return g.repoData.DefaultBranch == "" && (ref == "main" || ref == "master")
+}
+
+// selfLinkPage returns the path of the page of the site, relative to the
+// site root, that replaces a link to a file of the repository
+func (g *Generator) selfLinkPage(file, anchor string) (string, bool) {
+	var page string
+	doc := filepath.FromSlash(file)
+	if _, ok := g.markdown[doc]; ok {
+		switch {
+		case doc == g.languageReadme(g.defaultLang()):
+			page = g.readmePage(g.defaultLang())
+		case g.isPost(doc) || !g.skipDocPage(doc):
+			page = g.documentPagePath(doc)
+		default:
+			return "", false
+		}
+	} else if _, ok := g.sources[file]; ok {
+		page = sourcePagePath(file)
+		if a := lineAnchorRe.FindStringSubmatch("#" + anchor); a != nil {
+			anchor = a[1]
+		}
+	} else {
+		return "", false
+	}
+	if anchor != "" {
+		page += "#" + anchor
+	}
+	return page, true
+}
+
+// selfLink points a link or image of a markdown document that leads to the
+// repository on GitHub at the page or image of the site, for a page at
+// rootPath. Links to files the site doesn't have are left pointing at GitHub.
+func (g *Generator) selfLink(dest, rootPath string, image bool) string {
+	if !g.rewriteSelfLinks {
+		return dest
+	}
+	file, anchor, raw, ok := g.selfLinkTarget(dest)
+	if !ok {
+		return dest
+	}
+	// Images are embedded from /raw/ URLs, but GitHub also serves them for
+	// the URL of their page in an image
+	if image || raw {
+		if _, ok := g.repoData.ImageFiles[filepath.FromSlash(file)]; ok {
+			return g.imagesURL(rootPath) + path.Base(file)
+		}
+	}
+	if image {
+		return dest
+	}
+	if page, ok := g.selfLinkPage(file, anchor); ok {
+		return relURL(rootPath, page)
+	}
+	return dest
+}
@@ -0,0 +1,480 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// ShortcodeCall is a use of a shortcode in a document, e.g.
+// {{< youtube dQw4w9WgXcQ >}} or {{< note "Heads up" >}}…{{< /note >}}
+type ShortcodeCall struct {
+	Name string
+	// Path is the source path of the document
+	Path string
+	// Root is the root of the repository's working copy on disk
+	Root string
+
+	// Args are the positional arguments and Params the key="value" arguments
+	Args   []string
+	Params map[string]string
+
+	// Inner is the markdown between the opening and closing tags of a paired
+	// shortcode, with nested shortcodes already expanded
+	Inner string
+
+	// ID is unique on the site, for shortcodes that need element IDs
+	ID string
+	// Parent is the enclosing shortcode, if any, and Index the position of
+	// this shortcode among the shortcodes nested in it
+	Parent *ShortcodeCall
+	Index  int
+
+	// UI strings in the language of the document
+	T map[string]string
+
+	children int
+}
+
+// Arg returns a key="value" parameter, or else the positional argument at
+// index, or an empty string
+func (c *ShortcodeCall) Arg(key string, index int) string {
+	if value, ok := c.Params[key]; ok {
+		return value
+	}
+	if index >= 0 && index < len(c.Args) {
+		return c.Args[index]
+	}
+	return ""
+}
+
+// Markdown marks content in the HTML returned by a shortcode as markdown, to
+// be rendered along with the rest of the document
+func (c *ShortcodeCall) Markdown(content string) string {
+	return markdownStart + content + markdownEnd
+}
+
+// Shortcode expands a shortcode to HTML. Use ShortcodeCall.Markdown to embed
+// markdown, such as the inner content, in the result.
+type Shortcode func(call *ShortcodeCall) (string, error)
+
+// Markers around markdown embedded in shortcode output
+const (
+	markdownStart = "\x00markdown\x00"
+	markdownEnd   = "\x00/markdown\x00"
+)
+
+// builtinShortcodes maps the names of the shortcodes every generator starts
+// with to their handlers
+var builtinShortcodes = map[string]Shortcode{
+	"note":      admonitionShortcode("Note"),
+	"tip":       admonitionShortcode("Tip"),
+	"important": admonitionShortcode("Important"),
+	"warning":   admonitionShortcode("Warning"),
+	"caution":   admonitionShortcode("Caution"),
+	"youtube":   youtubeShortcode,
+	"video":     videoShortcode,
+	"include":   includeShortcode,
+	"tabs":      tabsShortcode,
+	"tab":       tabShortcode,
+	"hero":      heroShortcode,
+	"buttons":   buttonsShortcode,
+	"features":  featuresShortcode,
+	"feature":   featureShortcode,
+}
+
+// RegisterShortcode sets the handler of a shortcode for the documents of a
+// generator, replacing any existing one, including built-in ones
+func (g *Generator) RegisterShortcode(name string, handler Shortcode) {
+	g.shortcodes[name] = handler
+}
+
+var (
+	shortcodeRe          = regexp.MustCompile(`\{\{<\s*(/?)\s*([\w-]+)((?:[^>]|>[^}])*?)\s*/?>\}\}`)
+	shortcodeArgRe       = regexp.MustCompile(`(?:([\w-]+)=)?(?:"((?:[^"\\]|\\.)*)"|(\S+))`)
+	shortcodeMarkerRe    = regexp.MustCompile(`<!--shortcode:(\d+)-->`)
+	shortcodeCodeFenceRe = regexp.MustCompile("^\\s*(```|~~~)")
+	codeSpanRe           = regexp.MustCompile("``.+?``|`[^`\n]+`")
+	youtubeIDRe          = regexp.MustCompile(`^[\w-]+$`)
+)
+
+// expandShortcodes expands the math, admonitions and shortcodes in every markdown
+// document, in a stable order so the generated IDs are the same on every run
+func (g *Generator) expandShortcodes() {
+	var paths []string
+	for path := range g.markdown {
+		if _, converted := g.converted[path]; !converted {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		g.markdown[path] = g.expandDocumentShortcodes(path, g.convertAdmonitions(path, g.convertMath(g.markdown[path])))
+	}
+}
+
+// expandDocumentShortcodes expands the shortcodes of a document outside its
+// code blocks and code spans, and unescapes {{</* name */>}} to a literal {{< name >}}
+func (g *Generator) expandDocumentShortcodes(path, content string) string {
+	if !strings.Contains(content, "{{<") {
+		return content
+	}
+
+	var out, text strings.Builder
+	inFence := ""
+	flush := func() {
+		// Code spans are set aside while expanding
+		var spans []string
+		masked := codeSpanRe.ReplaceAllStringFunc(text.String(), func(span string) string {
+			spans = append(spans, span)
+			return "\x00code" + strconv.Itoa(len(spans)-1) + "\x00"
+		})
+		expanded := g.expandShortcodeText(path, masked, nil)
+		expanded = strings.ReplaceAll(expanded, "{{</*", "{{<")
+		expanded = strings.ReplaceAll(expanded, "*/>}}", ">}}")
+		for i, span := range spans {
+			expanded = strings.Replace(expanded, "\x00code"+strconv.Itoa(i)+"\x00", span, 1)
+		}
+		out.WriteString(expanded)
+		text.Reset()
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if m := shortcodeCodeFenceRe.FindStringSubmatch(line); m != nil {
+			switch inFence {
+			case "":
+				flush()
+				inFence = m[1]
+			case m[1]:
+				inFence = ""
+				out.WriteString(line)
+				continue
+			}
+		}
+		if inFence != "" {
+			out.WriteString(line)
+		} else {
+			text.WriteString(line)
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// expandShortcodeText expands the shortcodes in a piece of markdown. Unknown
+// shortcodes are left as they are, and failing ones are logged and left out.
+func (g *Generator) expandShortcodeText(path, content string, parent *ShortcodeCall) string {
+	var out strings.Builder
+	for {
+		loc := shortcodeRe.FindStringSubmatchIndex(content)
+		if loc == nil {
+			out.WriteString(content)
+			break
+		}
+		out.WriteString(content[:loc[0]])
+		tag := content[loc[0]:loc[1]]
+		closing, name := content[loc[2]:loc[3]] == "/", content[loc[4]:loc[5]]
+		rest := content[loc[1]:]
+
+		handler, ok := g.shortcodes[name]
+		if !ok || closing {
+			out.WriteString(tag)
+			content = rest
+			continue
+		}
+
+		call := &ShortcodeCall{
+			Name:   name,
+			Path:   path,
+			Root:   g.repoData.Path,
+			Params: make(map[string]string),
+			Parent: parent,
+			T:      g.messages(g.pageLanguage(path)),
+		}
+		g.shortcodeCount++
+		call.ID = "sc" + strconv.Itoa(g.shortcodeCount)
+		if parent != nil {
+			call.Index = parent.children
+			parent.children++
+		}
+		for _, m := range shortcodeArgRe.FindAllStringSubmatch(content[loc[6]:loc[7]], -1) {
+			value := m[3]
+			if m[3] == "" {
+				value = strings.ReplaceAll(m[2], `\"`, `"`)
+			}
+			if m[1] != "" {
+				call.Params[m[1]] = value
+			} else {
+				call.Args = append(call.Args, value)
+			}
+		}
+
+		// A shortcode is paired if a matching closing tag follows
+		if !strings.HasSuffix(tag, "/>}}") {
+			if inner, after, ok := splitShortcode(rest, name); ok {
+				call.Inner = g.expandShortcodeText(path, inner, call)
+				rest = after
+			}
+		}
+
+		result, err := handler(call)
+		if err != nil {
+			g.logger.Warn("Failed to expand shortcode", "path", path, "shortcode", name, "error", err)
+		} else {
+			out.WriteString(g.placeShortcodeHTML(result))
+		}
+		content = rest
+	}
+	return out.String()
+}
+
+// splitShortcode finds the closing tag of a paired shortcode, returning the
+// content before it and the content after it
+func splitShortcode(content, name string) (string, string, bool) {
+	depth := 0
+	for _, loc := range shortcodeRe.FindAllStringSubmatchIndex(content, -1) {
+		if content[loc[4]:loc[5]] != name {
+			continue
+		}
+		switch {
+		case content[loc[2]:loc[3]] != "/":
+			if !strings.HasSuffix(content[loc[0]:loc[1]], "/>}}") {
+				depth++
+			}
+		case depth > 0:
+			depth--
+		default:
+			return content[:loc[0]], content[loc[1]:], true
+		}
+	}
+	return "", "", false
+}
+
+// placeShortcodeHTML turns the output of a shortcode into markdown. Output
+// without embedded markdown is inserted as inline HTML, or behind a
+// placeholder when sanitizing, so it isn't sanitized again as the
+// repository's HTML. Otherwise the HTML parts are replaced by placeholder
+// comments, so the markdown between them is still rendered, and restored
+// after rendering. When sanitizing, the HTML parts are sanitized with
+// shortcodePolicy, as shortcodes are given their arguments by the repository.
+func (g *Generator) placeShortcodeHTML(result string) string {
+	if !strings.Contains(result, markdownStart) {
+		if g.sanitize && result != "" {
+			return g.inlinePlaceholder(sanitizeShortcodeHTML(result))
+		}
+		return result
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.Index(result, markdownStart)
+		end := strings.Index(result, markdownEnd)
+		if start < 0 || end < start {
+			g.writeShortcodePlaceholder(&out, result)
+			break
+		}
+		g.writeShortcodePlaceholder(&out, result[:start])
+		out.WriteString(result[start+len(markdownStart) : end])
+		result = result[end+len(markdownEnd):]
+	}
+	return out.String()
+}
+
+// writeShortcodePlaceholder writes a placeholder block for a piece of HTML
+func (g *Generator) writeShortcodePlaceholder(out *strings.Builder, fragment string) {
+	if strings.TrimSpace(fragment) == "" {
+		return
+	}
+	if g.sanitize {
+		fragment = sanitizeShortcodeHTML(fragment)
+	}
+	g.shortcodeHTML = append(g.shortcodeHTML, fragment)
+	fmt.Fprintf(out, "\n\n<!--shortcode:%d-->\n\n", len(g.shortcodeHTML)-1)
+}
+
+// restoreShortcodeHTML replaces the placeholders left by shortcodes with their HTML
+func (g *Generator) restoreShortcodeHTML(content string) string {
+	if len(g.shortcodeHTML) == 0 {
+		return content
+	}
+	return shortcodeMarkerRe.ReplaceAllStringFunc(content, func(marker string) string {
+		i, err := strconv.Atoi(shortcodeMarkerRe.FindStringSubmatch(marker)[1])
+		if err != nil || i >= len(g.shortcodeHTML) {
+			return marker
+		}
+		return g.shortcodeHTML[i]
+	})
+}
+
+// admonitionShortcode renders a callout box titled after the first argument,
+// or the given UI string
+func admonitionShortcode(key string) Shortcode {
+	return func(call *ShortcodeCall) (string, error) {
+		title := call.Arg("title", 0)
+		if title == "" {
+			title = call.T[key]
+		}
+		return admonitionHTML(strings.ToLower(key), title, call.Markdown(call.Inner)), nil
+	}
+}
+
+// admonitionHTML wraps content in a callout box of the given kind
+func admonitionHTML(kind, title, content string) string {
+	return `<div class="admonition admonition-` + kind + `">` + "\n" +
+		`<p class="admonition-title">` + html.EscapeString(title) + "</p>\n" +
+		content + "\n</div>"
+}
+
+// youtubeShortcode embeds a YouTube video: {{< youtube id >}}
+func youtubeShortcode(call *ShortcodeCall) (string, error) {
+	id := call.Arg("id", 0)
+	if !youtubeIDRe.MatchString(id) {
+		return "", fmt.Errorf("invalid video id %q", id)
+	}
+	title := call.Arg("title", 1)
+	if title == "" {
+		title = "YouTube video"
+	}
+	return `<div class="video-embed"><iframe src="https://www.youtube-nocookie.com/embed/` + id +
+		`" title="` + html.EscapeString(title) + `" loading="lazy" allow="encrypted-media; picture-in-picture" allowfullscreen></iframe></div>`, nil
+}
+
+// videoShortcode embeds a video file: {{< video src="demo.mp4" >}}
+func videoShortcode(call *ShortcodeCall) (string, error) {
+	src := call.Arg("src", 0)
+	if src == "" {
+		return "", fmt.Errorf("missing src")
+	}
+	if !safeURL(src) {
+		return "", fmt.Errorf("unsafe src %q", src)
+	}
+	return `<div class="video-embed"><video src="` + html.EscapeString(src) + `" controls preload="metadata"></video></div>`, nil
+}
+
+// includeShortcode includes a file of the repository, relative to the
+// document or, with a leading slash, to the repository root. Markdown files
+// are included as-is and other files as a code block:
+// {{< include "examples/main.go" lang="go" >}}
+func includeShortcode(call *ShortcodeCall) (string, error) {
+	name := call.Arg("file", 0)
+	if name == "" {
+		return "", fmt.Errorf("missing file")
+	}
+	if call.Root == "" {
+		return "", fmt.Errorf("repository files are not available")
+	}
+
+	rel := filepath.Join(filepath.Dir(call.Path), filepath.FromSlash(name))
+	if strings.HasPrefix(name, "/") {
+		rel = filepath.FromSlash(strings.TrimPrefix(name, "/"))
+	}
+	rel = filepath.Clean(rel)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("%s is outside the repository", name)
+	}
+
+	// Symlinks are resolved, so that they can't lead out of the repository
+	content, err := git.ReadFileWithin(call.Root, rel)
+	if err != nil {
+		return "", err
+	}
+
+	ext := strings.ToLower(filepath.Ext(rel))
+	if ext == ".md" || ext == ".markdown" {
+		_, body, _ := utils.ParseFrontMatter(string(content))
+		return call.Markdown(body), nil
+	}
+
+	lang := call.Arg("lang", 1)
+	if lang == "" {
+		lang = strings.TrimPrefix(ext, ".")
+	}
+	fence := "```"
+	for strings.Contains(string(content), fence) {
+		fence += "`"
+	}
+	return call.Markdown(fence + lang + "\n" + strings.TrimRight(string(content), "\n") + "\n" + fence), nil
+}
+
+// tabsShortcode groups tab shortcodes into a tabbed box that works without
+// JavaScript: {{< tabs >}}{{< tab "Linux" >}}…{{< /tab >}}{{< /tabs >}}
+func tabsShortcode(call *ShortcodeCall) (string, error) {
+	return `<div class="tabs">` + call.Markdown(call.Inner) + `</div>`, nil
+}
+
+// tabShortcode renders one tab of a tabs shortcode, titled after its first argument
+func tabShortcode(call *ShortcodeCall) (string, error) {
+	if call.Parent == nil || call.Parent.Name != "tabs" {
+		return "", fmt.Errorf("tab used outside of tabs")
+	}
+	title := call.Arg("title", 0)
+	if title == "" {
+		title = "Tab " + strconv.Itoa(call.Index+1)
+	}
+
+	id := call.Parent.ID + "-" + strconv.Itoa(call.Index)
+	checked := ""
+	if call.Index == 0 {
+		checked = " checked"
+	}
+	return `<input type="radio" class="tab-input" name="` + call.Parent.ID + `" id="` + id + `"` + checked + `>` +
+		`<label class="tab-label" for="` + id + `">` + html.EscapeString(title) + `</label>` +
+		`<div class="tab-panel">` + call.Markdown(call.Inner) + `</div>`, nil
+}
+
+// heroShortcode opens a landing page with a large title, a tagline and the
+// inner markdown, such as buttons:
+// {{< hero title="go-i2p" tagline="I2P in pure Go" >}}…{{< /hero >}}
+func heroShortcode(call *ShortcodeCall) (string, error) {
+	var b strings.Builder
+	b.WriteString(`<section class="hero">`)
+	if title := call.Arg("title", 0); title != "" {
+		b.WriteString(`<h1 class="hero-title">` + html.EscapeString(title) + `</h1>`)
+	}
+	if tagline := call.Arg("tagline", 1); tagline != "" {
+		b.WriteString(`<p class="hero-tagline">` + html.EscapeString(tagline) + `</p>`)
+	}
+	if strings.TrimSpace(call.Inner) != "" {
+		b.WriteString(call.Markdown(call.Inner))
+	}
+	b.WriteString(`</section>`)
+	return b.String(), nil
+}
+
+// buttonsShortcode shows the links of its inner markdown as buttons, the
+// first one highlighted: {{< buttons >}}[Get started](docs/guide.md){{< /buttons >}}
+func buttonsShortcode(call *ShortcodeCall) (string, error) {
+	return `<div class="buttons">` + call.Markdown(call.Inner) + `</div>`, nil
+}
+
+// featuresShortcode lays out feature shortcodes in a grid:
+// {{< features >}}{{< feature "Fast" icon="⚡" >}}…{{< /feature >}}{{< /features >}}
+func featuresShortcode(call *ShortcodeCall) (string, error) {
+	return `<div class="features">` + call.Markdown(call.Inner) + `</div>`, nil
+}
+
+// featureShortcode renders a feature titled after its first argument, with
+// an optional icon
+func featureShortcode(call *ShortcodeCall) (string, error) {
+	var b strings.Builder
+	b.WriteString(`<div class="feature">`)
+	if icon := call.Arg("icon", -1); icon != "" {
+		b.WriteString(`<div class="feature-icon" aria-hidden="true">` + html.EscapeString(icon) + `</div>`)
+	}
+	if title := call.Arg("title", 0); title != "" {
+		b.WriteString(`<h3 class="feature-title">` + html.EscapeString(title) + `</h3>`)
+	}
+	if strings.TrimSpace(call.Inner) != "" {
+		b.WriteString(call.Markdown(call.Inner))
+	}
+	b.WriteString(`</div>`)
+	return b.String(), nil
+}
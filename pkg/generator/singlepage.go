@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// combinedSection is one document of the single-page and PDF exports
+type combinedSection struct {
+	ID    string
+	Title string
+	HTML  string
+}
+
+// SetSinglePage enables generating all.html, a single page with every
+// documentation page in navigation order
+func (g *Generator) SetSinglePage(enabled bool) {
+	g.singlePage = enabled
+}
+
+// sectionID returns the id of a document's section in a combined page
+func sectionID(outputPath string) string {
+	if outputPath == "index.html" {
+		return "readme"
+	}
+	id := strings.TrimSuffix(filepath.ToSlash(outputPath), ".html")
+	return "doc-" + strings.ReplaceAll(id, "/", "-")
+}
+
+// combinedSections renders the README and every markdown or converted
+// documentation page of the default language, in navigation order, for a
+// page at the site root. Links between the included documents point at
+// their sections.
+func (g *Generator) combinedSections(docsPages []utils.DocPage) []combinedSection {
+	// Map output paths back to the documents they were rendered from
+	sources := make(map[string]string)
+	for path := range g.markdown {
+		if !g.skipDocPage(path) {
+			sources[g.docOutputPath(path)] = path
+		}
+	}
+
+	// Section ids of the included documents
+	readme := g.languageReadme(g.defaultLang())
+	ids := map[string]string{readme: sectionID("index.html")}
+	var pages []utils.DocPage
+	for _, page := range utils.FlattenNavTree(utils.BuildNavTree(docsPages, g.layout.DocsDir, "")) {
+		// Hand-written HTML pages are complete documents of their own
+		if source, ok := sources[page.Path]; ok {
+			ids[source] = sectionID(page.Path)
+			pages = append(pages, page)
+		}
+	}
+
+	var sections []combinedSection
+	if g.markdown[readme] != "" {
+		sections = append(sections, combinedSection{
+			ID:    sectionID("index.html"),
+			Title: "README",
+			HTML:  g.renderCombinedContent(readme, ids),
+		})
+	}
+	for _, page := range pages {
+		source := sources[page.Path]
+		content := g.renderCombinedContent(source, ids)
+		if !strings.Contains(content, "<h1") {
+			content = "<h1>" + html.EscapeString(page.Title) + "</h1>\n" + content
+		}
+		sections = append(sections, combinedSection{
+			ID:    sectionID(page.Path),
+			Title: page.Title,
+			HTML:  content,
+		})
+	}
+
+	return sections
+}
+
+// stem returns a slash-separated path without its extension
+func stem(path string) string {
+	path = filepath.ToSlash(path)
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// renderCombinedContent renders a document for a page at the site root. Its
+// links are resolved like those of its own page, but point at the section of
+// an included document, given by ids.
+func (g *Generator) renderCombinedContent(source string, ids map[string]string) string {
+	links := g.docLinks(source, "", ids)
+	if converted, ok := g.converted[source]; ok {
+		content := rewriteHTMLLinks(g.sanitizeContent(converted), func(dest string) string {
+			return links(dest, false)
+		})
+		return addHeadingAnchors(processConvertedLinks(content, g.imagesURL("")))
+	}
+
+	md := g.markdown[source]
+	if g.wiki[source] {
+		md = utils.ProcessWikiLinks(md, g.wikiLinks)
+	}
+	return g.addTaskProgress(source, g.sanitizeContent(renderMarkdownLinks(md, links)))
+}
+
+// generateSinglePage creates all.html with a table of contents followed by
+// every documentation page
+func (g *Generator) generateSinglePage(docsPages []utils.DocPage) error {
+	lang := g.defaultLang()
+	sections := g.combinedSections(docsPages)
+
+	var content strings.Builder
+	content.WriteString("<nav class=\"combined-toc\" aria-label=\"" + html.EscapeString(g.message(lang, "Contents")) + "\">\n<ol>\n")
+	for _, section := range sections {
+		content.WriteString("<li><a href=\"#" + section.ID + "\">" + html.EscapeString(section.Title) + "</a></li>\n")
+	}
+	content.WriteString("</ol>\n</nav>\n")
+	for _, section := range sections {
+		content.WriteString("<section class=\"combined-page\" id=\"" + section.ID + "\">\n" + section.HTML + "</section>\n")
+	}
+
+	heading := g.message(lang, "AllDocs")
+	page := g.newPage("doc", "all.html", lang, docsPages)
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+	page.Data.PageContent = content.String()
+
+	return g.renderPage(page)
+}
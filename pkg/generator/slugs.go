@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// githubSlug returns the id GitHub gives a heading: the text in lower case,
+// without punctuation other than hyphens and underscores, and with every
+// space turned into a hyphen, so "Foo & Bar" becomes "foo--bar"
+func githubSlug(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			return r
+		}
+		return -1
+	}, strings.ToLower(text))
+}
+
+// setHeadingIDs gives the headings of a document the ids GitHub gives them,
+// numbering repeated ids from -1 in document order. Ids set in the document
+// with {#id} are kept.
+func setHeadingIDs(doc ast.Node) map[string]bool {
+	ids := make(map[string]bool)
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if h, ok := node.(*ast.Heading); ok && entering && h.HeadingID != "" {
+			ids[h.HeadingID] = true
+		}
+		return ast.GoToNext
+	})
+
+	counts := make(map[string]int)
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		h, ok := node.(*ast.Heading)
+		if !ok || !entering || h.HeadingID != "" || h.IsTitleblock {
+			return ast.GoToNext
+		}
+		slug := githubSlug(nodeText(h))
+		if slug == "" {
+			return ast.SkipChildren
+		}
+		id := slug
+		for ids[id] {
+			counts[slug]++
+			id = slug + "-" + strconv.Itoa(counts[slug])
+		}
+		ids[id] = true
+		h.HeadingID = id
+		return ast.SkipChildren
+	})
+	return ids
+}
+
+// compactSlug returns the id the site gave a heading before its ids followed
+// GitHub's, with runs of punctuation and spaces collapsed into one hyphen,
+// so "Foo & Bar" became "foo-bar"
+func compactSlug(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), "-")
+}
+
+// resolveAnchorLinks points links to a section of the same document that
+// don't match a heading id exactly, such as #Installation, the heading's
+// text or the id the site used to give it, at the heading
+func resolveAnchorLinks(doc ast.Node, ids map[string]bool) {
+	compact := make(map[string]string)
+	for id := range ids {
+		if c := compactSlug(id); compact[c] == "" || id < compact[c] {
+			compact[c] = id
+		}
+	}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		link, ok := node.(*ast.Link)
+		if !ok || !entering || len(link.Destination) < 2 || link.Destination[0] != '#' {
+			return ast.GoToNext
+		}
+		anchor := string(link.Destination[1:])
+		if ids[anchor] {
+			return ast.GoToNext
+		}
+		if unescaped, err := url.PathUnescape(anchor); err == nil {
+			anchor = unescaped
+		}
+		for _, id := range []string{strings.ToLower(anchor), githubSlug(anchor), compact[compactSlug(anchor)]} {
+			if ids[id] {
+				link.Destination = []byte("#" + id)
+				break
+			}
+		}
+		return ast.GoToNext
+	})
+}
+
+// nodeText returns the text of a node and its children, without markup
+func nodeText(node ast.Node) string {
+	var b strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Literal)
+		case *ast.Code:
+			b.Write(n.Literal)
+		}
+		return ast.GoToNext
+	})
+	return b.String()
+}
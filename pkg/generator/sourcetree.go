@@ -0,0 +1,297 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// maxSourceSize is the size above which files aren't shown on the site, but
+// link to GitHub
+const maxSourceSize = 512 * 1024
+
+// DefaultSourceExtensions are the extensions of the files shown as source
+// views in the file tree
+var DefaultSourceExtensions = []string{
+	".go", ".mod", ".py", ".js", ".ts", ".c", ".h", ".cpp", ".rs", ".java",
+	".rb", ".sh", ".yml", ".yaml", ".toml", ".json", ".css", ".html",
+}
+
+// TreeEntry is a file or directory listed on a page of the file tree
+type TreeEntry struct {
+	Name  string
+	URL   string
+	IsDir bool
+	// Size is the formatted size of a file
+	Size string
+	// External is set for files that link to GitHub instead of a source view
+	External bool
+}
+
+// SetSourceTree generates browsable pages of the repository's files:
+// tree.html lists the root directory, tree/<dir>/index.html each
+// subdirectory, and files with one of the given extensions get a read-only
+// view at source/<path>.html. Other files link to GitHub.
+func (g *Generator) SetSourceTree(enabled bool, extensions []string) {
+	g.sourceTree = enabled
+	g.sourceExtensions = nil
+	for _, ext := range extensions {
+		g.sourceExtensions = append(g.sourceExtensions, "."+strings.TrimPrefix(strings.ToLower(ext), "."))
+	}
+}
+
+// treePagePath returns the output path of the page of a directory, "" for
+// the root
+func treePagePath(dir string) string {
+	if dir == "" {
+		return "tree.html"
+	}
+	return "tree/" + dir + "/index.html"
+}
+
+// sourcePagePath returns the output path of the source view of a file
+func sourcePagePath(file string) string {
+	return "source/" + file + ".html"
+}
+
+// branch returns the branch links to the repository's files point to
+func (g *Generator) branch() string {
+	if g.repoData.DefaultBranch != "" {
+		return g.repoData.DefaultBranch
+	}
+	return "HEAD"
+}
+
+// hasSourceView reports whether a file is shown on the site
+func (g *Generator) hasSourceView(file string, size int64) bool {
+	if size > maxSourceSize {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(file))
+	for _, e := range g.sourceExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// generateSourceTree creates the pages of the file tree and the source
+// views, and returns their paths
+func (g *Generator) generateSourceTree(docsPages []utils.DocPage) ([]string, error) {
+	// Group the files and subdirectories of each directory
+	files := make(map[string][]string)
+	dirs := map[string]map[string]bool{"": {}}
+	sizes := make(map[string]int64)
+	for rel, size := range g.repoData.Files {
+		file := filepath.ToSlash(rel)
+		sizes[file] = size
+		dir := path.Dir(file)
+		if dir == "." {
+			dir = ""
+		}
+		files[dir] = append(files[dir], file)
+		for dir != "" {
+			parent := path.Dir(dir)
+			if parent == "." {
+				parent = ""
+			}
+			if dirs[parent] == nil {
+				dirs[parent] = make(map[string]bool)
+			}
+			dirs[parent][dir] = true
+			if dirs[dir] == nil {
+				dirs[dir] = make(map[string]bool)
+			}
+			dir = parent
+		}
+	}
+
+	var generated []string
+	progress := utils.NewProgress("Rendering source tree", len(dirs)+len(sizes))
+	defer progress.Finish()
+
+	viewed := make([]string, 0, len(g.sources))
+	for file := range g.sources {
+		viewed = append(viewed, file)
+	}
+	sort.Strings(viewed)
+	for _, file := range viewed {
+		outputPath := sourcePagePath(file)
+		if err := g.generateSourcePage(file, sizes[file], docsPages); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", outputPath, err)
+		}
+		generated = append(generated, outputPath)
+		progress.Add(1)
+	}
+	progress.Add(len(sizes) - len(g.sources))
+
+	for dir, subdirs := range dirs {
+		outputPath := treePagePath(dir)
+		rootPath := utils.RelativeRoot(outputPath)
+
+		var entries []TreeEntry
+		for _, sub := range sortedKeys(subdirs) {
+			entries = append(entries, TreeEntry{
+				Name:  path.Base(sub) + "/",
+				URL:   rootPath + treePagePath(sub),
+				IsDir: true,
+			})
+		}
+		sort.Strings(files[dir])
+		for _, file := range files[dir] {
+			entry := TreeEntry{Name: path.Base(file), Size: formatSize(sizes[file])}
+			if _, ok := g.sources[file]; ok {
+				entry.URL = rootPath + sourcePagePath(file)
+			} else {
+				entry.URL = g.repoData.URL + "/blob/" + g.branch() + "/" + file
+				entry.External = true
+			}
+			entries = append(entries, entry)
+		}
+
+		page := g.newPage("tree", outputPath, g.defaultLang(), docsPages)
+		page.Data.TreeEntries = entries
+		page.Data.Breadcrumbs = g.treeBreadcrumbs(dir)
+		heading := g.repoData.Name
+		if dir != "" {
+			heading += "/" + dir
+		}
+		page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+		page.Data.PageHeading = heading
+		if err := g.renderPage(page); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", outputPath, err)
+		}
+		generated = append(generated, outputPath)
+		progress.Add(1)
+	}
+
+	sort.Strings(generated)
+	return generated, nil
+}
+
+// loadSources reads the files that get a source view. Binary files are left
+// out.
+func (g *Generator) loadSources() error {
+	g.sources = make(map[string]string)
+	for rel, size := range g.repoData.Files {
+		file := filepath.ToSlash(rel)
+		if !g.hasSourceView(file, size) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(g.repoData.Path, rel))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+		g.sources[file] = string(content)
+	}
+	return nil
+}
+
+// generateSourcePage creates the source view of a file, with line numbers
+// that link to themselves, e.g. source/main.go.html#L42
+func (g *Generator) generateSourcePage(file string, size int64, docsPages []utils.DocPage) error {
+	var b strings.Builder
+	b.WriteString(`<p class="source-meta">` + formatSize(size) + ` • <a href="` +
+		html.EscapeString(g.repoData.URL+"/blob/"+g.branch()+"/"+file) + `" target="_blank">` +
+		html.EscapeString(g.message(g.defaultLang(), "ViewOnGitHub")) + "</a></p>\n")
+	b.WriteString(`<div class="source-code"><table><tbody>` + "\n")
+	for i, line := range highlightLines(file, g.sources[file]) {
+		n := strconv.Itoa(i + 1)
+		b.WriteString(`<tr id="L` + n + `"><td class="line-number"><a href="#L` + n + `">` + n +
+			`</a></td><td class="line-code"><code>` + line + "</code></td></tr>\n")
+	}
+	b.WriteString("</tbody></table></div>")
+
+	page := g.newPage("doc", sourcePagePath(file), g.defaultLang(), docsPages)
+	page.Source = filepath.FromSlash(file)
+	page.Data.Breadcrumbs = g.treeBreadcrumbs(file)
+	page.Data.PageTitle = file + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = path.Base(file)
+	page.Data.PageContent = b.String()
+	return g.renderPage(page)
+}
+
+// lineAnchorRe matches GitHub's anchors of a line or a range of lines, e.g.
+// #L10-L20, capturing the first line
+var lineAnchorRe = regexp.MustCompile(`^#(L\d+)(?:-L\d+)?$`)
+
+// sourceViewLink points a relative link of a document to a file with a
+// source view at the view, keeping line anchors
+func (g *Generator) sourceViewLink(docPath, dest, rootPath string) string {
+	target, anchor, _ := strings.Cut(dest, "#")
+	if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+		return dest
+	}
+	var file string
+	if strings.HasPrefix(target, "/") {
+		file = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		file = path.Join(path.Dir(filepath.ToSlash(docPath)), target)
+	}
+	if _, ok := g.sources[file]; !ok {
+		return dest
+	}
+	link := rootPath + sourcePagePath(file)
+	if anchor != "" {
+		if a := lineAnchorRe.FindStringSubmatch("#" + anchor); a != nil {
+			link += "#" + a[1]
+		} else {
+			link += "#" + anchor
+		}
+	}
+	return link
+}
+
+// treeBreadcrumbs returns the breadcrumb trail from the root of the file
+// tree to a directory or file
+func (g *Generator) treeBreadcrumbs(target string) []utils.Breadcrumb {
+	crumbs := []utils.Breadcrumb{{Title: g.repoData.Name, Path: treePagePath("")}}
+	if target == "" {
+		crumbs[0].Path = ""
+		return crumbs
+	}
+	parts := strings.Split(target, "/")
+	for i := range parts {
+		crumb := utils.Breadcrumb{Title: parts[i]}
+		if i < len(parts)-1 {
+			crumb.Path = treePagePath(strings.Join(parts[:i+1], "/"))
+		}
+		crumbs = append(crumbs, crumb)
+	}
+	return crumbs
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatSize formats a file size for display, e.g. 12.3 KB
+func formatSize(size int64) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	}
+}
@@ -0,0 +1,219 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stagingPattern names the directories sites are generated in before they
+// replace the output directory
+const stagingPattern = ".%s.staging-"
+
+// NewStagingDir creates an empty directory next to the output directory to
+// generate a site into, so a failed run never leaves a half-updated site.
+// Staging directories left behind by runs that crashed are removed.
+func NewStagingDir(outputDir string) (string, error) {
+	outputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	parent, prefix := filepath.Dir(outputDir), fmt.Sprintf(stagingPattern, filepath.Base(outputDir))
+
+	stale, _ := filepath.Glob(filepath.Join(parent, prefix+"*"))
+	for _, dir := range stale {
+		os.RemoveAll(dir)
+	}
+
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+	dir, err := os.MkdirTemp(parent, prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return dir, nil
+}
+
+// PublishSite moves a site generated in a staging directory into place.
+// Files of the output directory the site doesn't replace are kept, unless
+// clean is set: then the files the manifest of the output directory lists,
+// which an earlier run generated, are dropped and returned. Files put there
+// by hand, and a .git directory, so a checkout of the deploy branch can be
+// used as the output directory, are always kept.
+//
+// The staging directory replaces the output directory with a rename, so
+// readers see either the old or the new site. If that isn't possible, e.g.
+// because the output directory is a mount point or a symbolic link, the files
+// are moved in one by one instead.
+func PublishSite(stagingDir, outputDir string, clean bool) ([]string, error) {
+	// Files are moved through the directory a symbolic link points to
+	realDir := outputDir
+	if resolved, err := filepath.EvalSymlinks(outputDir); err == nil {
+		realDir = resolved
+	}
+
+	var dropped []string
+	info, err := os.Stat(realDir)
+	if err == nil && info.IsDir() {
+		var stale map[string]bool
+		if clean {
+			previous, err := ReadManifest(realDir)
+			if err != nil {
+				return nil, err
+			}
+			stale = make(map[string]bool)
+			for _, file := range previous.Files {
+				stale[file] = true
+			}
+		}
+		dropped, err = carryOver(realDir, stagingDir, stale)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+
+	if err := swapDir(stagingDir, outputDir); err != nil {
+		if err := moveFiles(stagingDir, realDir); err != nil {
+			return nil, fmt.Errorf("failed to move the site into %s: %w", outputDir, err)
+		}
+		if !clean {
+			return nil, os.RemoveAll(stagingDir)
+		}
+		for _, file := range dropped {
+			if err := os.Remove(filepath.Join(realDir, filepath.FromSlash(file))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to remove %s: %w", file, err)
+			}
+		}
+		removeEmptyDirs(realDir)
+		return dropped, os.RemoveAll(stagingDir)
+	}
+	return dropped, nil
+}
+
+// swapDir replaces dst with the directory src
+func swapDir(src, dst string) error {
+	info, err := os.Lstat(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Rename(src, dst)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dst)
+	}
+
+	old := src + ".old"
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		// Put the old site back
+		if restoreErr := os.Rename(old, dst); restoreErr != nil {
+			return fmt.Errorf("%w (and failed to restore %s from %s: %v)", err, dst, old, restoreErr)
+		}
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// carryOver moves the files of src that dst doesn't have into dst, except
+// the stale ones, which are returned
+func carryOver(src, dst string, stale map[string]bool) ([]string, error) {
+	var skipped []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			// A directory dst doesn't have moves in one piece, unless it
+			// holds stale files
+			if _, err := os.Lstat(target); errors.Is(err, fs.ErrNotExist) && !holdsStale(stale, filepath.ToSlash(rel)) {
+				if err := os.Rename(path, target); err != nil {
+					return fmt.Errorf("failed to move %s: %w", rel, err)
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+		if stale[filepath.ToSlash(rel)] {
+			skipped = append(skipped, filepath.ToSlash(rel))
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		if err := os.Rename(path, target); err != nil {
+			return fmt.Errorf("failed to move %s: %w", rel, err)
+		}
+		return nil
+	})
+	return skipped, err
+}
+
+// holdsStale reports whether any of the stale files is in a directory
+func holdsStale(stale map[string]bool, dir string) bool {
+	for file := range stale {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// moveFiles moves every file of src into dst, replacing files dst has
+func moveFiles(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		if err := os.Rename(path, target); err != nil {
+			return fmt.Errorf("failed to move %s: %w", rel, err)
+		}
+		return nil
+	})
+}
+
+// removeEmptyDirs removes the empty directories under root, deepest first
+func removeEmptyDirs(root string) {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			os.Remove(dirs[i])
+		}
+	}
+}
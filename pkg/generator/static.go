@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// wellKnownDir is copied from the repository root into the site, for files
+// such as keybase.txt or a hand-written security.txt
+const wellKnownDir = ".well-known"
+
+// securityTxtPath is where security.txt is published (RFC 9116)
+const securityTxtPath = wellKnownDir + "/security.txt"
+
+// SecurityTxt holds the fields of a generated security.txt
+type SecurityTxt struct {
+	// Contact lists email addresses or URLs to report vulnerabilities to
+	Contact []string
+	// Encryption is the URL of the key to encrypt reports with, if any
+	Encryption string
+}
+
+// SetStaticDir sets a directory of the repository whose files are copied into
+// the root of the site as they are. Files under .well-known/ in the
+// repository root are always copied.
+func (g *Generator) SetStaticDir(dir string) {
+	g.staticDir = dir
+}
+
+// SetSecurityTxt enables generating .well-known/security.txt, unless the
+// repository has one of its own
+func (g *Generator) SetSecurityTxt(security SecurityTxt) {
+	g.securityTxt = security
+}
+
+// copyStaticFiles copies the static files of the repository into the site and
+// writes security.txt. Files are copied last, so they are neither minified
+// nor fingerprinted, and never replace a generated page.
+func (g *Generator) copyStaticFiles(result *GenerationResult) error {
+	generated := make(map[string]bool)
+	for _, page := range result.Pages {
+		generated[page] = true
+	}
+	for _, asset := range result.Assets {
+		generated[asset] = true
+	}
+
+	copied := make(map[string]bool)
+	copyDir := func(dir, prefix string) error {
+		root := filepath.Join(g.repoData.Path, filepath.FromSlash(dir))
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return nil
+		}
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Links could point outside the repository, so only regular files are copied
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			dest := prefix + filepath.ToSlash(rel)
+			if generated[dest] {
+				g.logger.Warn("Skipping static file that conflicts with a generated file", "path", dest)
+				return nil
+			}
+
+			outPath := filepath.Join(g.outputDir, filepath.FromSlash(dest))
+			if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", dest, err)
+			}
+			if err := copyFile(path, outPath); err != nil {
+				return fmt.Errorf("failed to copy static file %s: %w", dest, err)
+			}
+			copied[dest] = true
+			result.Assets = append(result.Assets, dest)
+			g.logger.Debug("Copied static file", "path", dest)
+			return nil
+		})
+	}
+
+	if err := copyDir(wellKnownDir, wellKnownDir+"/"); err != nil {
+		return err
+	}
+	if g.staticDir != "" {
+		if err := copyDir(g.staticDir, ""); err != nil {
+			return err
+		}
+	}
+
+	if len(g.securityTxt.Contact) > 0 {
+		if copied[securityTxtPath] {
+			g.logger.Info("Using the repository's security.txt")
+		} else {
+			outPath := filepath.Join(g.outputDir, filepath.FromSlash(securityTxtPath))
+			if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+				return fmt.Errorf("failed to create %s directory: %w", wellKnownDir, err)
+			}
+			if err := os.WriteFile(outPath, []byte(g.securityTxtContent()), 0o644); err != nil {
+				return fmt.Errorf("failed to write security.txt: %w", err)
+			}
+			copied[securityTxtPath] = true
+			result.Assets = append(result.Assets, securityTxtPath)
+		}
+	}
+
+	// Jekyll leaves out dot directories such as .well-known
+	for dest := range copied {
+		if strings.HasPrefix(dest, ".") || strings.Contains(dest, "/.") {
+			if err := os.WriteFile(filepath.Join(g.outputDir, ".nojekyll"), nil, 0o644); err != nil {
+				return fmt.Errorf("failed to write .nojekyll: %w", err)
+			}
+			result.Assets = append(result.Assets, ".nojekyll")
+			break
+		}
+	}
+
+	return nil
+}
+
+// securityTxtContent formats security.txt. It expires a year after
+// generation, so sites that are no longer rebuilt stop advertising it.
+func (g *Generator) securityTxtContent() string {
+	var b strings.Builder
+	for _, contact := range g.securityTxt.Contact {
+		if !strings.Contains(contact, ":") && strings.Contains(contact, "@") {
+			contact = "mailto:" + contact
+		}
+		b.WriteString("Contact: " + contact + "\n")
+	}
+	b.WriteString("Expires: " + g.now().UTC().AddDate(1, 0, 0).Format(time.RFC3339) + "\n")
+	if g.securityTxt.Encryption != "" {
+		b.WriteString("Encryption: " + g.securityTxt.Encryption + "\n")
+	}
+	if g.hasSecurityPolicy() {
+		b.WriteString("Policy: " + g.repoData.URL + "/security/policy\n")
+	}
+	if len(g.languages) > 0 {
+		b.WriteString("Preferred-Languages: " + strings.Join(g.languages, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// hasSecurityPolicy reports whether the repository has a SECURITY.md, which
+// GitHub shows as its security policy
+func (g *Generator) hasSecurityPolicy() bool {
+	_, ok := g.repoData.CommunityFiles["security"]
+	return ok
+}
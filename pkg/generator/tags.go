@@ -0,0 +1,184 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// TagLink links the page of a tag
+type TagLink struct {
+	Name string
+	URL  string
+	// Count is the number of pages with the tag, and Size its weight in the
+	// tag cloud, from 1 for the least used tags to 5 for the most used
+	Count int
+	Size  int
+}
+
+// TaggedPage is a page listed on the page of a tag
+type TaggedPage struct {
+	Title       string
+	URL         string
+	Description string
+}
+
+// tag is a front matter tag and the documents that have it
+type tag struct {
+	name    string
+	sources []string
+}
+
+// tagSlug returns the name of the page of a tag: lowercase letters and
+// digits, with anything else replaced by dashes
+func tagSlug(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, name)
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	return strings.Trim(slug, "-")
+}
+
+// tagPagePath returns the output path of the page of a tag
+func tagPagePath(slug string) string {
+	return "tags/" + slug + ".html"
+}
+
+// collectTags groups the generated documents and blog posts of the default
+// language by their front matter tags. Tags differing only in case or punctuation are
+// the same tag, named as in the first document that has it.
+func (g *Generator) collectTags() {
+	var paths []string
+	for path := range g.markdown {
+		if !g.skipDocPage(path) && g.pageLanguage(path) == g.defaultLang() {
+			paths = append(paths, path)
+		}
+	}
+	for _, p := range g.posts {
+		if g.pageLanguage(p.source) == g.defaultLang() {
+			paths = append(paths, p.source)
+		}
+	}
+	sort.Strings(paths)
+
+	g.tags = make(map[string]*tag)
+	for _, path := range paths {
+		for _, name := range g.frontMatter[path].Tags {
+			slug := tagSlug(name)
+			if slug == "" {
+				continue
+			}
+			if g.tags[slug] == nil {
+				g.tags[slug] = &tag{name: name}
+			}
+			t := g.tags[slug]
+			if len(t.sources) == 0 || t.sources[len(t.sources)-1] != path {
+				t.sources = append(t.sources, path)
+			}
+		}
+	}
+}
+
+// documentPagePath returns the output path of the page of a document or
+// blog post
+func (g *Generator) documentPagePath(source string) string {
+	for _, p := range g.posts {
+		if p.source == source {
+			return p.outputPath
+		}
+	}
+	return g.docOutputPath(source)
+}
+
+// pageTags returns the links to the tags of a document
+func (g *Generator) pageTags(path, rootPath string) []TagLink {
+	var links []TagLink
+	seen := make(map[string]bool)
+	for _, name := range g.frontMatter[path].Tags {
+		slug := tagSlug(name)
+		if t := g.tags[slug]; t != nil && !seen[slug] {
+			seen[slug] = true
+			links = append(links, TagLink{Name: t.name, URL: rootPath + tagPagePath(slug), Count: len(t.sources)})
+		}
+	}
+	return links
+}
+
+// tagCloud returns the links to every tag, by name, sized by how many pages
+// have them
+func (g *Generator) tagCloud(rootPath string) []TagLink {
+	minCount, maxCount := 0, 0
+	for _, t := range g.tags {
+		if minCount == 0 || len(t.sources) < minCount {
+			minCount = len(t.sources)
+		}
+		maxCount = max(maxCount, len(t.sources))
+	}
+
+	var links []TagLink
+	for slug, t := range g.tags {
+		size := 1
+		if maxCount > minCount {
+			size = 1 + 4*(len(t.sources)-minCount)/(maxCount-minCount)
+		}
+		links = append(links, TagLink{Name: t.name, URL: rootPath + tagPagePath(slug), Count: len(t.sources), Size: size})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if a, b := strings.ToLower(links[i].Name), strings.ToLower(links[j].Name); a != b {
+			return a < b
+		}
+		return links[i].URL < links[j].URL
+	})
+	return links
+}
+
+// generateTagPages creates tags.html with the tag cloud and a page for each
+// tag listing its documents, and returns their paths
+func (g *Generator) generateTagPages(docsPages []utils.DocPage) ([]string, error) {
+	lang := g.defaultLang()
+	heading := g.message(lang, "Tags")
+
+	page := g.newPage("tags", "tags.html", lang, docsPages)
+	page.Data.TagCloud = g.tagCloud(page.Data.RootPath)
+	page.Data.PageTitle = heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+	page.Data.PageHeading = heading
+	if err := g.renderPage(page); err != nil {
+		return nil, fmt.Errorf("failed to generate tags page: %w", err)
+	}
+	generated := []string{"tags.html"}
+
+	slugs := make([]string, 0, len(g.tags))
+	for slug := range g.tags {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	for _, slug := range slugs {
+		t := g.tags[slug]
+		outputPath := tagPagePath(slug)
+		page := g.newPage("tags", outputPath, lang, docsPages)
+		for _, source := range t.sources {
+			page.Data.TaggedPages = append(page.Data.TaggedPages, TaggedPage{
+				Title:       g.pageTitle(source),
+				URL:         page.Data.RootPath + g.documentPagePath(source),
+				Description: g.frontMatter[source].Description,
+			})
+		}
+		page.Data.TagCloud = g.tagCloud(page.Data.RootPath)
+		page.Data.Breadcrumbs = []utils.Breadcrumb{{Title: heading, Path: "tags.html"}, {Title: t.name}}
+		page.Data.PageTitle = t.name + " - " + heading + " - " + g.repoData.Owner + "/" + g.repoData.Name
+		page.Data.PageHeading = t.name
+		if err := g.renderPage(page); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", outputPath, err)
+		}
+		generated = append(generated, outputPath)
+	}
+	return generated, nil
+}
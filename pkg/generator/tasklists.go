@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// A list item starting with [ ] or [x], in tight or loose lists
+	taskItemRe = regexp.MustCompile(`<li>(\s*<p>)?\[([ xX])\]\s`)
+	// List boundaries and task items, to count the tasks of each list
+	taskListTokenRe = regexp.MustCompile(`<[uo]l[^>]*>|</[uo]l>|<li class="task-list-item( task-done)?">`)
+)
+
+// SetTaskProgress enables a completion summary, e.g. "3/7 done", above
+// every task list of a documentation page
+func (g *Generator) SetTaskProgress(enabled bool) {
+	g.taskProgress = enabled
+}
+
+// renderTaskItems turns list items starting with [ ] or [x] into disabled checkboxes
+func renderTaskItems(content string) string {
+	return taskItemRe.ReplaceAllStringFunc(content, func(match string) string {
+		m := taskItemRe.FindStringSubmatch(match)
+		if m[2] == " " {
+			return `<li class="task-list-item">` + m[1] + `<input type="checkbox" class="task-list-checkbox" disabled> `
+		}
+		return `<li class="task-list-item task-done">` + m[1] + `<input type="checkbox" class="task-list-checkbox" disabled checked> `
+	})
+}
+
+// addTaskProgress inserts a completion summary before every list with task
+// items, counting only the list's own items, not those of nested lists
+func (g *Generator) addTaskProgress(path, content string) string {
+	if !g.taskProgress || !strings.Contains(content, "task-list-item") {
+		return content
+	}
+
+	type list struct {
+		start, total, done int
+	}
+	var (
+		open     []*list
+		finished []*list
+	)
+	for _, loc := range taskListTokenRe.FindAllStringSubmatchIndex(content, -1) {
+		token := content[loc[0]:loc[1]]
+		switch {
+		case strings.HasPrefix(token, "</"):
+			if len(open) > 0 {
+				finished = append(finished, open[len(open)-1])
+				open = open[:len(open)-1]
+			}
+		case strings.HasPrefix(token, "<li"):
+			if len(open) > 0 {
+				l := open[len(open)-1]
+				l.total++
+				if loc[2] >= 0 {
+					l.done++
+				}
+			}
+		default:
+			open = append(open, &list{start: loc[0]})
+		}
+	}
+
+	// Insert the summaries from the end, so earlier offsets stay valid
+	sort.Slice(finished, func(i, j int) bool { return finished[i].start > finished[j].start })
+	T := g.messages(g.pageLanguage(path))
+	for _, l := range finished {
+		if l.total == 0 {
+			continue
+		}
+		summary := fmt.Sprintf(`<div class="task-progress"><progress value="%d" max="%d"></progress> %d/%d %s</div>`+"\n",
+			l.done, l.total, l.done, l.total, T["Done"])
+		content = content[:l.start] + summary + content[l.start:]
+	}
+	return content
+}
@@ -0,0 +1,158 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+)
+
+// fieldErrorRe matches the error of a template referencing a field its data
+// doesn't have, e.g.
+//
+//	template: main:12:14: executing "main" at <.Foo>: can't evaluate field Foo in type *generator.PageData
+var fieldErrorRe = regexp.MustCompile(`^template: ([^:]+):(\d+):(\d+): executing "[^"]*" at <([^>]*)>: can't evaluate field (\w+) in type \*?(?:[\w/]+\.)?(\w+)`)
+
+// parseErrorRe matches the location of a template syntax error, e.g.
+//
+//	template: main:5: function "foo" not defined
+var parseErrorRe = regexp.MustCompile(`^template: ([^:]+):(\d+):(?:(\d+):)? (.*)$`)
+
+// ValidateTemplates parses the page templates and partials, including custom
+// ones, and renders every page template with the example template data, in
+// which every field is set, so syntax errors and references to missing fields are
+// reported before generating the site. Errors name the template file, the
+// line and column, and the fields available in place of a missing one.
+func ValidateTemplates() error {
+	g := NewGenerator(&git.RepositoryData{}, "")
+	if err := g.parseTemplates(); err != nil {
+		var parseErr error = err
+		for errors.Unwrap(parseErr) != nil {
+			parseErr = errors.Unwrap(parseErr)
+		}
+		if m := parseErrorRe.FindStringSubmatch(parseErr.Error()); m != nil {
+			return fmt.Errorf("syntax error in template %s.html, %s: %s", m[1], location(m[2], m[3]), m[4])
+		}
+		return err
+	}
+
+	data := exampleValue(reflect.TypeOf(PageData{}), "", make(map[reflect.Type]bool)).Addr().Interface()
+	names := make([]string, 0, len(g.templateCache))
+	for name := range g.templateCache {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		err := g.templateCache[name].Execute(io.Discard, data)
+		if err == nil {
+			continue
+		}
+		if m := fieldErrorRe.FindStringSubmatch(err.Error()); m != nil {
+			problems = append(problems, fieldProblem(m[1], location(m[2], m[3]), m[5], m[6]))
+			continue
+		}
+		// Escaping errors don't depend on the data; other errors may only
+		// come from the sample data, and are reported when rendering
+		var escapeErr *template.Error
+		if errors.As(err, &escapeErr) {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// location formats a line and an optional column
+func location(line, column string) string {
+	if column == "" {
+		return "line " + line
+	}
+	return "line " + line + ", column " + column
+}
+
+// fieldProblem describes a reference to a missing field, with the fields of
+// the type and the closest one
+func fieldProblem(templateName, at, field, typeName string) string {
+	msg := fmt.Sprintf("template %s.html, %s: %s has no field %s", templateName, at, typeName, field)
+	t, ok := templateTypes()[typeName]
+	if !ok {
+		return msg
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			fields = append(fields, t.Field(i).Name)
+		}
+	}
+	sort.Strings(fields)
+	if closest := closestName(field, fields); closest != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", closest)
+	}
+	return msg + "\n  available fields: " + strings.Join(fields, ", ")
+}
+
+// templateTypes returns the struct types reachable from PageData, by name
+func templateTypes() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || types[t.Name()] != nil || t.PkgPath() == "time" {
+			return
+		}
+		types[t.Name()] = t
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				visit(t.Field(i).Type)
+			}
+		}
+	}
+	visit(reflect.TypeOf(PageData{}))
+	return types
+}
+
+// closestName returns the name closest to s, ignoring case, if it is within
+// a few edits
+func closestName(s string, names []string) string {
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
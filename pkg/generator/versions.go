@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
+)
+
+// VersionLink is an entry of the version switcher
+type VersionLink struct {
+	Name string
+	// Path is relative to the root of the current version's site
+	Path     string
+	IsActive bool
+}
+
+// SetVersions makes the site one version of a versioned documentation site.
+// Each version is generated in a sibling directory named after it, and
+// current is the version being generated.
+func (g *Generator) SetVersions(versions []string, current string) {
+	g.versions = versions
+	g.version = current
+}
+
+// versionLinks builds the version switcher, linking to the home page of each version
+func (g *Generator) versionLinks() []VersionLink {
+	if len(g.versions) < 2 {
+		return nil
+	}
+
+	// Version sites are siblings, so step out of the current version's directory first
+	parent := utils.RelativeRoot(filepath.ToSlash(g.version) + "/index.html")
+
+	var links []VersionLink
+	for _, version := range g.versions {
+		links = append(links, VersionLink{
+			Name:     version,
+			Path:     parent + filepath.ToSlash(version) + "/index.html",
+			IsActive: version == g.version,
+		})
+	}
+	return links
+}
+
+// WriteVersionRedirect writes an index.html to the root of a versioned site
+// that redirects to the given version
+func WriteVersionRedirect(outputDir, version string) error {
+	target := html.EscapeString(filepath.ToSlash(version) + "/index.html")
+	page := `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta http-equiv="refresh" content="0; url=` + target + `">
+  <link rel="canonical" href="` + target + `">
+  <title>Redirecting…</title>
+</head>
+<body>
+  <p><a href="` + target + `">Continue to the documentation</a></p>
+</body>
+</html>
+`
+	if err := os.WriteFile(filepath.Join(outputDir, "index.html"), []byte(page), 0o644); err != nil {
+		return fmt.Errorf("failed to write version redirect: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-i2p/go-gh-page/pkg/git"
+	github "github.com/google/go-github/v45/github"
+	"golang.org/x/oauth2"
+)
+
+// logger receives warnings from this package
+var logger = slog.Default()
+
+// SetLogger sets the logger used by this package
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// NewClient creates a GitHub API client, authenticated with GITHUB_TOKEN if it is set
+func NewClient(ctx context.Context) *github.Client {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return github.NewClient(http.DefaultClient)
+	}
+
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+	return github.NewClient(oauth2.NewClient(ctx, ts))
+}
+
+// EnrichRepository fills in repository metadata that isn't available from the
+// clone itself: description, topics, star and fork counts, default branch and homepage
+func EnrichRepository(ctx context.Context, client *github.Client, repoData *git.RepositoryData) error {
+	repo, _, err := client.Repositories.Get(ctx, repoData.Owner, repoData.Name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch repository metadata: %w", err)
+	}
+
+	if description := repo.GetDescription(); description != "" {
+		repoData.Description = description
+	}
+	repoData.Topics = repo.Topics
+	repoData.Stars = repo.GetStargazersCount()
+	repoData.Forks = repo.GetForksCount()
+	repoData.DefaultBranch = repo.GetDefaultBranch()
+	repoData.Homepage = repo.GetHomepage()
+
+	return nil
+}
+
+// FetchRelated fills in the star counts, and missing descriptions, of the
+// related repositories on GitHub
+func FetchRelated(ctx context.Context, client *github.Client, related []git.RelatedRepo) error {
+	for i, r := range related {
+		owner, name, ok := r.GitHubRepo()
+		if !ok || r.Stars > 0 {
+			continue
+		}
+		repo, _, err := client.Repositories.Get(ctx, owner, name)
+		if err != nil {
+			return fmt.Errorf("failed to fetch related repository %s/%s: %w", owner, name, err)
+		}
+		related[i].Stars = repo.GetStargazersCount()
+		if r.Description == "" {
+			related[i].Description = repo.GetDescription()
+		}
+	}
+	return nil
+}
+
+// FetchReleases loads the repository's GitHub Releases, including their notes and assets
+func FetchReleases(ctx context.Context, client *github.Client, repoData *git.RepositoryData) error {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(ctx, repoData.Owner, repoData.Name, opts)
+		if err != nil {
+			return fmt.Errorf("failed to fetch releases: %w", err)
+		}
+
+		for _, r := range releases {
+			if r.GetDraft() {
+				continue
+			}
+
+			release := git.Release{
+				TagName:     r.GetTagName(),
+				Name:        r.GetName(),
+				Body:        r.GetBody(),
+				URL:         r.GetHTMLURL(),
+				PublishedAt: r.GetPublishedAt().Time,
+				Prerelease:  r.GetPrerelease(),
+			}
+			for _, a := range r.Assets {
+				release.Assets = append(release.Assets, git.ReleaseAsset{
+					Name: a.GetName(),
+					URL:  a.GetBrowserDownloadURL(),
+					Size: a.GetSize(),
+				})
+			}
+			repoData.Releases = append(repoData.Releases, release)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return nil
+}
+
+// FetchIssues loads the repository's open issues, excluding pull requests
+func FetchIssues(ctx context.Context, client *github.Client, repoData *git.RepositoryData) error {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, repoData.Owner, repoData.Name, opts)
+		if err != nil {
+			return fmt.Errorf("failed to fetch issues: %w", err)
+		}
+
+		for _, i := range issues {
+			if i.IsPullRequest() {
+				continue
+			}
+			issue := git.Issue{
+				Number:    i.GetNumber(),
+				Title:     i.GetTitle(),
+				URL:       i.GetHTMLURL(),
+				Author:    i.GetUser().GetLogin(),
+				Comments:  i.GetComments(),
+				CreatedAt: i.GetCreatedAt(),
+			}
+			for _, l := range i.Labels {
+				issue.Labels = append(issue.Labels, git.Label{Name: l.GetName(), Color: l.GetColor()})
+			}
+			repoData.Issues = append(repoData.Issues, issue)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return nil
+}
+
+// discussionsQuery fetches the most recently updated open discussions of a
+// repository
+const discussionsQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    discussions(first: 100, states: [OPEN], orderBy: {field: UPDATED_AT, direction: DESC}) {
+      nodes {
+        number
+        title
+        url
+        createdAt
+        author { login }
+        category { name }
+        comments { totalCount }
+        labels(first: 10) { nodes { name color } }
+      }
+    }
+  }
+}`
+
+// FetchDiscussions loads the repository's most recently updated open discussions.
+// Discussions are only available through the GraphQL API.
+func FetchDiscussions(ctx context.Context, client *github.Client, repoData *git.RepositoryData) error {
+	body := map[string]interface{}{
+		"query":     discussionsQuery,
+		"variables": map[string]string{"owner": repoData.Owner, "name": repoData.Name},
+	}
+	req, err := client.NewRequest("POST", "graphql", body)
+	if err != nil {
+		return fmt.Errorf("failed to create discussions request: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			Repository struct {
+				Discussions struct {
+					Nodes []struct {
+						Number    int       `json:"number"`
+						Title     string    `json:"title"`
+						URL       string    `json:"url"`
+						CreatedAt time.Time `json:"createdAt"`
+						Author    struct {
+							Login string `json:"login"`
+						} `json:"author"`
+						Category struct {
+							Name string `json:"name"`
+						} `json:"category"`
+						Comments struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"comments"`
+						Labels struct {
+							Nodes []git.Label `json:"nodes"`
+						} `json:"labels"`
+					} `json:"nodes"`
+				} `json:"discussions"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := client.Do(ctx, req, &result); err != nil {
+		return fmt.Errorf("failed to fetch discussions: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("failed to fetch discussions: %s", result.Errors[0].Message)
+	}
+
+	for _, d := range result.Data.Repository.Discussions.Nodes {
+		repoData.Discussions = append(repoData.Discussions, git.Issue{
+			Number:    d.Number,
+			Title:     d.Title,
+			URL:       d.URL,
+			Author:    d.Author.Login,
+			Category:  d.Category.Name,
+			Labels:    d.Labels.Nodes,
+			Comments:  d.Comments.TotalCount,
+			CreatedAt: d.CreatedAt,
+		})
+	}
+
+	return nil
+}
+
+// ResolveContributors looks up the GitHub account behind each contributor's commits
+// and replaces their avatar with the real one. Contributors that can't be resolved
+// keep their existing avatar, and failed lookups are logged as warnings. Lookups
+// stop early if the API rate limit is hit.
+func ResolveContributors(ctx context.Context, client *github.Client, owner, repo string, contributors []git.Contributor) error {
+	for i := range contributors {
+		if contributors[i].CommitHash == "" {
+			continue
+		}
+
+		commit, _, err := client.Repositories.GetCommit(ctx, owner, repo, contributors[i].CommitHash, nil)
+		if err != nil {
+			var rateErr *github.RateLimitError
+			if errors.As(err, &rateErr) {
+				return fmt.Errorf("rate limit exceeded while resolving contributors: %w", err)
+			}
+			logger.Warn("Failed to resolve contributor", "name", contributors[i].Name, "commit", contributors[i].CommitHash, "error", err)
+			continue
+		}
+
+		author := commit.GetAuthor()
+		if author == nil || author.GetLogin() == "" {
+			continue
+		}
+
+		contributors[i].Login = author.GetLogin()
+		contributors[i].ProfileURL = author.GetHTMLURL()
+		if avatar := author.GetAvatarURL(); avatar != "" {
+			contributors[i].AvatarURL = avatar
+		}
+	}
+
+	return nil
+}
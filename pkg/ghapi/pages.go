@@ -0,0 +1,87 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	github "github.com/google/go-github/v45/github"
+)
+
+// pagesPollInterval is how often the status of a Pages build is checked
+const pagesPollInterval = 5 * time.Second
+
+// EnablePages publishes the repository's GitHub Pages site from the root of a
+// branch, and sets its custom domain if one is given. Pages that are already
+// enabled are left as they are, apart from the domain.
+func EnablePages(ctx context.Context, client *github.Client, owner, repo, branch, cname string) error {
+	// Pages can't be enabled from a branch that doesn't exist
+	if _, _, err := client.Repositories.GetBranch(ctx, owner, repo, branch, true); err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return fmt.Errorf("branch %s doesn't exist yet in %s/%s; push the generated site to it first, e.g. with the workflow from -page-yaml", branch, owner, repo)
+		}
+		return fmt.Errorf("failed to check branch %s: %w", branch, err)
+	}
+
+	_, _, err := client.Repositories.EnablePages(ctx, owner, repo, &github.Pages{
+		Source: &github.PagesSource{
+			Branch: github.String(branch),
+			Path:   github.String("/"),
+		},
+		Public: github.Bool(true),
+	})
+	if err != nil && !isStatus(err, http.StatusConflict) {
+		return fmt.Errorf("could not enable github pages: %w", err)
+	}
+
+	if cname != "" {
+		_, err = client.Repositories.UpdatePages(ctx, owner, repo, &github.PagesUpdate{
+			CNAME: github.String(cname),
+		})
+		if err != nil {
+			return fmt.Errorf("could not set custom domain %s: %w", cname, err)
+		}
+	}
+
+	return nil
+}
+
+// WaitForPages polls the latest Pages build until it is built or has failed,
+// and returns the site's configuration, with its public URL and HTTPS state
+func WaitForPages(ctx context.Context, client *github.Client, owner, repo string, timeout time.Duration) (*github.Pages, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		build, _, err := client.Repositories.GetLatestPagesBuild(ctx, owner, repo)
+		switch {
+		case err == nil && build.GetStatus() == "built":
+			pages, _, err := client.Repositories.GetPagesInfo(ctx, owner, repo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch the pages site: %w", err)
+			}
+			return pages, nil
+		case err == nil && build.GetStatus() == "errored":
+			return nil, fmt.Errorf("pages build of commit %s failed: %s", build.GetCommit(), build.GetError().GetMessage())
+		case err != nil && !isStatus(err, http.StatusNotFound):
+			// No build was started yet while the API answers 404
+			if ctx.Err() == nil {
+				return nil, fmt.Errorf("failed to fetch the pages build status: %w", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("pages build not finished after %s; check the repository's Pages settings", timeout)
+		case <-time.After(pagesPollInterval):
+		}
+	}
+}
+
+// isStatus reports whether an API error is an HTTP error with the given status
+func isStatus(err error, status int) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == status
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// communityFiles are the community health files GitHub links from a
+// repository, with the name of the page they are generated as
+var communityFiles = []struct {
+	page string
+	name string
+}{
+	{"code-of-conduct", "CODE_OF_CONDUCT"},
+	{"contributing", "CONTRIBUTING"},
+	{"security", "SECURITY"},
+	{"support", "SUPPORT"},
+}
+
+// communityDirs are the directories GitHub looks for community health files
+// in, in order of preference
+var communityDirs = []string{".github", ".", "docs"}
+
+// findCommunityFiles finds the markdown file of each community health file,
+// keyed by page name. The walker skips .github, so markdown files found there
+// are read and added to the repository's markdown files.
+func findCommunityFiles(repoPath string, markdown map[string]string) map[string]string {
+	found := make(map[string]string)
+	for _, dir := range communityDirs {
+		entries, err := os.ReadDir(filepath.Join(repoPath, dir))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !isMarkdownFile(entry.Name()) {
+				continue
+			}
+			page := communityPage(entry.Name())
+			if page == "" || found[page] != "" {
+				continue
+			}
+
+			rel := filepath.Join(dir, entry.Name())
+			if _, ok := markdown[rel]; !ok {
+				if dir != ".github" {
+					// Ignored or skipped by the walker
+					continue
+				}
+				content, err := ReadFileWithin(repoPath, rel)
+				if err != nil {
+					logger.Warn("Failed to read community file", "path", rel, "error", err)
+					continue
+				}
+				markdown[rel] = string(content)
+			}
+			found[page] = rel
+			logger.Debug("Found community file", "path", rel)
+		}
+	}
+	return found
+}
+
+// communityPage returns the page name of a community health file, or ""
+func communityPage(filename string) string {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for _, f := range communityFiles {
+		if strings.EqualFold(name, f.name) {
+			return f.page
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ContributorOptions selects the commits counted for contributor statistics
+type ContributorOptions struct {
+	// ExcludeBots leaves out bot accounts such as dependabot[bot]
+	ExcludeBots bool
+	// ExcludeAuthors are glob patterns matched against author names and emails
+	ExcludeAuthors []string
+	// ExcludeMerges leaves out merge commits
+	ExcludeMerges bool
+	// Top is how many top contributors are listed, 0 for all
+	Top int
+}
+
+// contributorOptions are the options set with SetContributorOptions
+var contributorOptions = ContributorOptions{Top: 5}
+
+// SetContributorOptions sets which commits count towards the commit count and
+// the contributors of the repository and its pages
+func SetContributorOptions(opts ContributorOptions) {
+	contributorOptions = opts
+}
+
+// botNames are automation accounts that don't use the [bot] suffix
+var botNames = []string{"dependabot", "renovate", "github-actions", "greenkeeper", "snyk-bot", "imgbot", "allcontributors"}
+
+// isBot reports whether a commit author is a bot account
+func isBot(name, email string) bool {
+	name, email = strings.ToLower(name), strings.ToLower(email)
+	if strings.HasSuffix(name, "[bot]") || strings.Contains(email, "[bot]@") {
+		return true
+	}
+	for _, bot := range botNames {
+		if name == bot || strings.HasPrefix(email, bot+"@") {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether a commit is left out of contributor statistics.
+// name and email are the canonical identity of its author.
+func (o ContributorOptions) excluded(c *object.Commit, name, email string) bool {
+	if o.ExcludeMerges && c.NumParents() > 1 {
+		return true
+	}
+	if o.ExcludeBots && isBot(name, email) {
+		return true
+	}
+	for _, pattern := range o.ExcludeAuthors {
+		for _, value := range []string{name, email} {
+			if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(value)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FundingLink is a way to sponsor the project, from .github/FUNDING.yml:
+// an account on a funding platform, a custom URL, or an address, such as a
+// cryptocurrency wallet, that isn't a web page
+type FundingLink struct {
+	// Platform is the name of the funding platform, the currency of an
+	// address such as "Bitcoin", or empty for a custom URL
+	Platform string
+	// Name is the account, URL or address shown for the link
+	Name string
+	// URL links the account, empty for addresses without a scheme
+	URL string
+	// Address is set for entries that are not web pages
+	Address bool
+}
+
+// fundingPlatforms are the platforms FUNDING.yml supports, in the order
+// GitHub lists them, with the URL of an account
+var fundingPlatforms = []struct {
+	key  string
+	name string
+	url  string
+}{
+	{"github", "GitHub Sponsors", "https://github.com/sponsors/"},
+	{"patreon", "Patreon", "https://www.patreon.com/"},
+	{"open_collective", "Open Collective", "https://opencollective.com/"},
+	{"ko_fi", "Ko-fi", "https://ko-fi.com/"},
+	{"tidelift", "Tidelift", "https://tidelift.com/funding/github/"},
+	{"community_bridge", "LFX Crowdfunding", "https://crowdfunding.lfx.linuxfoundation.org/projects/"},
+	{"lfx_crowdfunding", "LFX Crowdfunding", "https://crowdfunding.lfx.linuxfoundation.org/projects/"},
+	{"liberapay", "Liberapay", "https://liberapay.com/"},
+	{"issuehunt", "IssueHunt", "https://issuehunt.io/r/"},
+	{"polar", "Polar", "https://polar.sh/"},
+	{"buy_me_a_coffee", "Buy Me a Coffee", "https://buymeacoffee.com/"},
+	{"thanks_dev", "thanks.dev", "https://thanks.dev/"},
+}
+
+// fundingFiles are the places GitHub reads the funding file from, in order
+// of preference
+var fundingFiles = []string{".github/FUNDING.yml", ".github/FUNDING.yaml"}
+
+// readFunding reads the sponsor links of the working copy's funding file.
+// A broken file is logged and ignored, as GitHub does.
+func readFunding(repoPath string) []FundingLink {
+	for _, name := range fundingFiles {
+		data, err := ReadFileWithin(repoPath, filepath.FromSlash(name))
+		if err != nil {
+			continue
+		}
+		var entries map[string]yaml.Node
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			logger.Warn("Failed to parse funding file", "path", name, "error", err)
+			return nil
+		}
+		links := parseFunding(entries)
+		logger.Debug("Found funding file", "path", name, "links", len(links))
+		return links
+	}
+	return nil
+}
+
+// parseFunding turns the entries of a funding file into links, the
+// platforms first and then the custom entries
+func parseFunding(entries map[string]yaml.Node) []FundingLink {
+	var links []FundingLink
+	for _, p := range fundingPlatforms {
+		node, ok := entries[p.key]
+		if !ok {
+			continue
+		}
+		for _, account := range fundingValues(node) {
+			links = append(links, FundingLink{Platform: p.name, Name: account, URL: p.url + account})
+		}
+	}
+
+	if node, ok := entries["custom"]; ok {
+		for _, value := range fundingValues(node) {
+			links = append(links, customFundingLink(value))
+		}
+	}
+	return links
+}
+
+// fundingValues returns the values of an entry, which is a single value or
+// a list
+func fundingValues(node yaml.Node) []string {
+	var values []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		values = []string{node.Value}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				values = append(values, item.Value)
+			}
+		}
+	}
+
+	var nonEmpty []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	return nonEmpty
+}
+
+// customFundingLink returns the link of a custom entry: a web page, a URI
+// such as bitcoin:<address>, or a bare address
+func customFundingLink(value string) FundingLink {
+	lower := strings.ToLower(value)
+	if strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") {
+		return FundingLink{Name: value, URL: value}
+	}
+	if scheme, address, ok := strings.Cut(value, ":"); ok && isURIScheme(scheme) && address != "" {
+		return FundingLink{Platform: strings.ToUpper(scheme[:1]) + strings.ToLower(scheme[1:]), Name: address, URL: value, Address: true}
+	}
+	return FundingLink{Name: value, Address: true}
+}
+
+// isURIScheme reports whether s is a URI scheme, such as bitcoin or monero
+func isURIScheme(s string) bool {
+	for i, r := range s {
+		letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !letter && (i == 0 || !(r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.')) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -1,19 +1,176 @@
 package git
 
 import (
+	"context"
+	"crypto/md5"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/go-i2p/go-gh-page/pkg/utils"
 )
 
+// logger receives progress and debug messages from this package
+var logger = slog.Default()
+
+// SetLogger sets the logger used by this package
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// documentExtensions are the extensions, besides markdown, of files collected as documentation
+var documentExtensions []string
+
+// SetDocumentExtensions sets the extensions, besides markdown, of files
+// collected as documentation, e.g. ".adoc" and ".rst"
+func SetDocumentExtensions(exts []string) {
+	documentExtensions = exts
+}
+
+// skipPaths are absolute directories left out when collecting files, such as
+// an output directory inside a local working copy
+var skipPaths []string
+
+// SetSkipPaths sets directories left out when collecting repository files
+func SetSkipPaths(paths ...string) {
+	skipPaths = nil
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			skipPaths = append(skipPaths, abs)
+		}
+	}
+}
+
+// followSymlinks is whether symlinks leading out of the repository are followed
+var followSymlinks = false
+
+// SetFollowSymlinks sets whether symlinked files and directories outside the
+// repository are followed when collecting files. They are skipped by default,
+// as a repository could otherwise publish any file readable on the build
+// machine, e.g. a link to /proc/self/environ. Links to paths inside the
+// repository are always skipped, as their targets are collected anyway,
+// except for READMEs.
+func SetFollowSymlinks(follow bool) {
+	followSymlinks = follow
+}
+
+// historyLimit caps the number of commits read for statistics and page
+// history, 0 reads them all
+var historyLimit int
+
+// historySince leaves out commits made before it, unless it is zero
+var historySince time.Time
+
+// collectStats is whether the commit history is read at all
+var collectStats = true
+
+// SetHistoryLimit limits the commits read for statistics and page history to
+// the latest limit commits (0 for no limit) made after since (zero for all)
+func SetHistoryLimit(limit int, since time.Time) {
+	historyLimit = limit
+	historySince = since
+}
+
+// SetStats sets whether the commit history is read for statistics, top
+// contributors and the history of each page. Without it only the date of the
+// last commit is read.
+func SetStats(enabled bool) {
+	collectStats = enabled
+}
+
+// walkHistory calls fn for the commits reachable from head, within the limits
+// set with SetHistoryLimit. It reports whether commits were left out.
+func walkHistory(repo *git.Repository, head plumbing.Hash, fn func(*object.Commit) error) (bool, error) {
+	options := &git.LogOptions{From: head}
+	if !historySince.IsZero() {
+		// In commit time order, the walk can stop at the first older commit
+		options.Order = git.LogOrderCommitterTime
+	}
+	cIter, err := repo.Log(options)
+	if err != nil {
+		return false, err
+	}
+
+	count, truncated := 0, false
+	err = cIter.ForEach(func(c *object.Commit) error {
+		if (historyLimit > 0 && count >= historyLimit) || (!historySince.IsZero() && c.Committer.When.Before(historySince)) {
+			truncated = true
+			return storer.ErrStop
+		}
+		count++
+		return fn(c)
+	})
+	return truncated, err
+}
+
+// resolveSymlink returns the real path a symlink points at and whether it is a directory
+func resolveSymlink(path string) (string, bool, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", false, err
+	}
+	return target, info.IsDir(), nil
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ReadFileWithin reads a file of the working copy at root, given by its path
+// relative to root. Symlinks are resolved first, and a file whose real path
+// lies outside root is refused, so that a repository can't publish files of
+// the machine building it.
+func ReadFileWithin(root, rel string) ([]byte, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(root, rel))
+	if err != nil {
+		return nil, err
+	}
+	if !isWithin(realRoot, target) {
+		return nil, fmt.Errorf("%s leads outside the repository", rel)
+	}
+	return os.ReadFile(target)
+}
+
+// isSkipPath reports whether a directory is one of the skipped paths
+func isSkipPath(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, skip := range skipPaths {
+		if abs == skip {
+			return true
+		}
+	}
+	return false
+}
+
 // RepositoryData contains all the information about a repository
 type RepositoryData struct {
 	Owner       string
@@ -21,21 +178,138 @@ type RepositoryData struct {
 	Description string
 	URL         string
 
+	// Root of the working copy on disk
+	Path string
+
+	// Commit checked out in the working copy
+	Commit string
+
+	// Empty is set for a repository without commits
+	Empty bool
+
 	// Content
 	ReadmeContent string
 	ReadmePath    string
 	MarkdownFiles map[string]string // path -> content
 
-	// Stats from git
-	Contributors   []Contributor
-	CommitCount    int
-	LastCommitDate time.Time
+	// Documentation in other formats, rendered by the generator's converters
+	DocumentFiles map[string]string // path -> content
+
+	// Hand-written HTML pages under docs/
+	HTMLFiles map[string]string // path -> content
+
+	// Stats from git. Contributors are the top contributors, AllContributors
+	// everyone, both sorted by commit count.
+	Contributors    []Contributor
+	AllContributors []Contributor
+	CommitCount     int
+	LastCommitDate  time.Time
+
+	// HistoryTruncated is set when the statistics leave out older commits
+	HistoryTruncated bool
+
+	// Languages of the source files, largest first
+	Languages []Language
+
+	// Community health files such as CONTRIBUTING.md, by page name:
+	// code-of-conduct, contributing, security and support
+	CommunityFiles map[string]string // page -> markdown path
+
+	// Sponsor links from .github/FUNDING.yml
+	Funding []FundingLink
 
-	// License information if available
-	License string
+	// License information if available: the name of the license, its SPDX
+	// identifier if it was recognized, and the license file at the root
+	License     string
+	LicenseSPDX string
+	LicensePath string
+	LicenseText string
+
+	// Metadata from the GitHub API, when available
+	Topics        []string
+	Stars         int
+	Forks         int
+	DefaultBranch string
+	Homepage      string
+
+	// Every file of the working copy that isn't ignored
+	Files map[string]int64 // path -> size
 
 	// Set of image paths in the repository (to copy to output)
 	ImageFiles map[string]string // path -> full path on disk
+
+	// Logo or favicon image, full path on disk
+	LogoFile string
+
+	// Git history of each markdown file
+	FileHistory map[string]FileHistory // path -> history
+
+	// Markdown pages of the repository's wiki, if it was included
+	WikiPages map[string]string // path -> content
+
+	// Snapshot of open issues and discussions from the GitHub API
+	Issues      []Issue
+	Discussions []Issue
+
+	// Release history
+	Tags     []Tag
+	Releases []Release
+
+	// Related repositories shown on the home page
+	Related []RelatedRepo
+}
+
+// Tag represents a git tag in the repository
+type Tag struct {
+	Name    string
+	Hash    string
+	Date    time.Time
+	Message string
+}
+
+// Release represents a GitHub Release
+type Release struct {
+	TagName     string
+	Name        string
+	Body        string
+	URL         string
+	PublishedAt time.Time
+	Prerelease  bool
+	Assets      []ReleaseAsset
+}
+
+// Issue represents a GitHub issue or discussion
+type Issue struct {
+	Number    int
+	Title     string
+	URL       string
+	Author    string
+	Category  string // discussions only
+	Labels    []Label
+	Comments  int
+	CreatedAt time.Time
+}
+
+// Label is an issue label
+type Label struct {
+	Name  string
+	Color string // hex, without the leading #
+}
+
+// ReleaseAsset is a downloadable file attached to a release
+type ReleaseAsset struct {
+	Name string
+	URL  string
+	Size int
+}
+
+// FileHistory describes the git history of a single file
+type FileHistory struct {
+	LastModified time.Time
+	LastAuthor   string
+
+	// Contributors to the file, sorted by commit count
+	Contributors []Contributor
 }
 
 // Contributor represents a repository contributor
@@ -44,25 +318,47 @@ type Contributor struct {
 	Email     string
 	Commits   int
 	AvatarURL string
+
+	// GitHub account, when it could be resolved
+	Login      string
+	ProfileURL string
+
+	// CommitHash is the most recent commit by this contributor, used to
+	// resolve their GitHub account
+	CommitHash string
+
+	// Dates of the first and latest commit by this contributor
+	FirstCommit time.Time
+	LastCommit  time.Time
 }
 
-// CloneRepository clones a Git repository to the specified directory
-func CloneRepository(url, destination, branch string) (*git.Repository, error) {
+// CloneRepository clones a Git repository to the specified directory. An
+// existing clone of the same repository is brought up to date with the remote
+// branch instead, unless fresh is set, in which case it is replaced.
+func CloneRepository(url, destination, branch string, fresh bool) (*git.Repository, error) {
 	// Check if repository already exists
 	if _, err := os.Stat(destination); err == nil {
-		// Directory exists, try to open repository
 		repo, err := git.PlainOpen(destination)
-		if err == nil {
-			fmt.Println("Using existing repository clone")
+		switch {
+		case fresh:
+			logger.Info("Removing existing clone", "dir", destination)
+		case err == nil:
+			if err := refreshClone(repo, url, branch); err != nil {
+				return nil, fmt.Errorf("failed to update existing clone in %s: %w", destination, err)
+			}
+			logger.Info("Updated existing repository clone", "dir", destination)
 			return repo, nil
 		}
-		// If error, remove directory and clone fresh
-		os.RemoveAll(destination)
+		// Not a repository, or a fresh clone was asked for
+		if err := os.RemoveAll(destination); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", destination, err)
+		}
 	}
 
 	// Clone options
 	options := &git.CloneOptions{
-		URL: url,
+		URL:      url,
+		Progress: utils.ProgressWriter("Cloning"),
 	}
 
 	// Set branch if not default
@@ -70,8 +366,238 @@ func CloneRepository(url, destination, branch string) (*git.Repository, error) {
 		options.ReferenceName = plumbing.NewBranchReferenceName(branch)
 	}
 
-	// Clone the repository
-	return git.PlainClone(destination, false, options)
+	// Clone the repository. A repository without commits can't be cloned,
+	// so an empty one is set up with the remote instead.
+	repo, err := git.PlainClone(destination, false, options)
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		logger.Warn("Remote repository is empty", "url", url)
+		return initEmptyClone(url, destination)
+	}
+	return repo, err
+}
+
+// initEmptyClone creates an empty repository with url as its origin remote,
+// the clone of a repository without commits
+func initEmptyClone(url, destination string) (*git.Repository, error) {
+	repo, err := git.PlainInit(destination, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create empty repository: %w", err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{url}}); err != nil {
+		return nil, fmt.Errorf("failed to add origin remote: %w", err)
+	}
+	return repo, nil
+}
+
+// refreshClone fetches the remote and hard-resets an existing clone to the
+// remote branch, after checking that the clone is of the expected repository
+func refreshClone(repo *git.Repository, url, branch string) error {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return fmt.Errorf("no origin remote: %w", err)
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 || normalizeRemoteURL(urls[0]) != normalizeRemoteURL(url) {
+		return fmt.Errorf("clone is of %s, not %s", strings.Join(urls, ", "), url)
+	}
+
+	err = repo.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Tags:       git.AllTags,
+		Force:      true,
+		Progress:   utils.ProgressWriter("Fetching"),
+	})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		logger.Warn("Remote repository is empty", "url", url)
+		return nil
+	}
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	// main and master stand for the remote's default branch, like when cloning
+	name := branch
+	if branch == "main" || branch == "master" {
+		refs, err := remote.List(&git.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list remote references: %w", err)
+		}
+		for _, ref := range refs {
+			if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+				name = ref.Target().Short()
+			}
+		}
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", name), true)
+	if err != nil {
+		return fmt.Errorf("branch %s not found on origin: %w", name, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	checkout := &git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Force: true}
+	if _, err := repo.Reference(checkout.Branch, false); err != nil {
+		checkout.Create = true
+		checkout.Hash = remoteRef.Hash()
+	}
+	if err := worktree.Checkout(checkout); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", name, err)
+	}
+	if err := worktree.Reset(&git.ResetOptions{Mode: git.HardReset, Commit: remoteRef.Hash()}); err != nil {
+		return fmt.Errorf("failed to reset to origin/%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// CheckoutRef checks out a tag, commit hash (full or abbreviated) or branch
+// of a clone as a detached HEAD. Branches are looked up on origin when there
+// is no local branch of that name.
+func CheckoutRef(repo *git.Repository, ref string) (string, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		var remoteErr error
+		if hash, remoteErr = repo.ResolveRevision(plumbing.Revision("origin/" + ref)); remoteErr != nil {
+			return "", fmt.Errorf("ref %s not found: %w", ref, err)
+		}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		return "", fmt.Errorf("failed to check out %s: %w", ref, err)
+	}
+
+	return hash.String(), nil
+}
+
+// Submodules lists the paths of the submodules of a working copy, relative
+// to its root, split into those selected by the patterns and the rest.
+// Patterns are globs matched against the submodule path or name; "*" selects
+// every submodule and a pattern starting with "!" excludes matching ones.
+func Submodules(repo *git.Repository, patterns []string) ([]string, []string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	subs, err := worktree.Submodules()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read submodules: %w", err)
+	}
+
+	var selected, excluded []string
+	for _, sub := range subs {
+		cfg := sub.Config()
+		if matchSubmodule(cfg.Path, cfg.Name, patterns) {
+			selected = append(selected, cfg.Path)
+		} else {
+			excluded = append(excluded, cfg.Path)
+		}
+	}
+	return selected, excluded, nil
+}
+
+// matchSubmodule reports whether a submodule is selected by the patterns.
+// Only exclusions selects everything else.
+func matchSubmodule(path, name string, patterns []string) bool {
+	matches := func(pattern string) bool {
+		if pattern == "*" || strings.TrimSuffix(pattern, "/") == path || pattern == name {
+			return true
+		}
+		ok, _ := filepath.Match(pattern, path)
+		return ok
+	}
+
+	included, hasIncludes := false, false
+	for _, pattern := range patterns {
+		if exclude, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matches(exclude) {
+				return false
+			}
+			continue
+		}
+		hasIncludes = true
+		if matches(pattern) {
+			included = true
+		}
+	}
+	return included || (!hasIncludes && len(patterns) > 0)
+}
+
+// UpdateSubmodules initializes the submodules at the given paths and checks
+// them out, along with their own submodules, at the commits recorded in the
+// current checkout. Paths that are not submodules of this checkout are ignored.
+func UpdateSubmodules(repo *git.Repository, paths []string) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	subs, err := worktree.Submodules()
+	if err != nil {
+		return fmt.Errorf("failed to read submodules: %w", err)
+	}
+
+	for _, sub := range subs {
+		path := sub.Config().Path
+		if !slices.Contains(paths, path) {
+			continue
+		}
+		logger.Debug("Updating submodule", "path", path, "url", sub.Config().URL)
+		err := sub.Update(&git.SubmoduleUpdateOptions{
+			Init:              true,
+			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update submodule %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// OpenLocal opens the working copy containing path, which may be a
+// subdirectory, and returns the repository and the root of its worktree
+func OpenLocal(path string) (*git.Repository, string, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open repository at %s: %w", path, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	return repo, worktree.Filesystem.Root(), nil
+}
+
+// remoteRepoRe matches the owner and name at the end of a remote URL, e.g.
+// https://github.com/owner/name.git or git@github.com:owner/name.git
+var remoteRepoRe = regexp.MustCompile(`[:/]([^/:]+)/([^/:]+?)(?:\.git)?/?$`)
+
+// RemoteRepository returns the owner and name of the repository the origin
+// remote of a clone points at
+func RemoteRepository(repo *git.Repository) (string, string, error) {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", "", fmt.Errorf("no origin remote: %w", err)
+	}
+	for _, url := range remote.Config().URLs {
+		if m := remoteRepoRe.FindStringSubmatch(url); m != nil {
+			return m[1], m[2], nil
+		}
+	}
+	return "", "", fmt.Errorf("origin remote %s does not name an owner and repository", strings.Join(remote.Config().URLs, ", "))
+}
+
+// normalizeRemoteURL reduces a remote URL to a comparable form, ignoring
+// case, a trailing slash and the .git suffix
+func normalizeRemoteURL(url string) string {
+	url = strings.ToLower(strings.TrimSuffix(url, "/"))
+	return strings.TrimSuffix(url, ".git")
 }
 
 // GetRepositoryData extracts information from a cloned repository
@@ -80,8 +606,12 @@ func GetRepositoryData(repo *git.Repository, owner, name, repoPath string) (*Rep
 		Owner:         owner,
 		Name:          name,
 		URL:           fmt.Sprintf("https://github.com/%s/%s", owner, name),
+		Path:          repoPath,
 		MarkdownFiles: make(map[string]string),
+		DocumentFiles: make(map[string]string),
+		HTMLFiles:     make(map[string]string),
 		ImageFiles:    make(map[string]string),
+		Files:         make(map[string]int64),
 	}
 
 	// Get the repository description from the repository
@@ -90,48 +620,79 @@ func GetRepositoryData(repo *git.Repository, owner, name, repoPath string) (*Rep
 		repoData.Description = config.Raw.Section("").Option("description")
 	}
 
-	// Get HEAD reference
+	// Get HEAD reference. A repository without commits has none, and only
+	// its working tree is read.
+	stats := collectStats
+	var head plumbing.Hash
 	ref, err := repo.Head()
-	if err != nil {
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		logger.Warn("Repository has no commits")
+		repoData.Empty = true
+		stats = false
+	case err != nil:
 		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+	default:
+		head = ref.Hash()
+		repoData.Commit = head.String()
 	}
 
-	// Get commit history
-	cIter, err := repo.Log(&git.LogOptions{From: ref.Hash()})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get commit history: %w", err)
+	// Without statistics only the date of the last commit is needed
+	if !stats && !repoData.Empty {
+		commit, err := repo.CommitObject(head)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+		}
+		repoData.LastCommitDate = commit.Author.When
 	}
 
 	// Process commits
+	authors := readMailmap(repoPath)
 	contributors := make(map[string]*Contributor)
-	err = cIter.ForEach(func(c *object.Commit) error {
-		// Count commits
-		repoData.CommitCount++
+	progress := utils.NewProgress("Reading history", historyLimit)
+	walked := 0
+	walkCommit := func(c *object.Commit) error {
+		walked++
+		progress.Add(1)
 
 		// Update last commit date if needed
 		if repoData.LastCommitDate.IsZero() || c.Author.When.After(repoData.LastCommitDate) {
 			repoData.LastCommitDate = c.Author.When
 		}
 
-		// Track contributors
-		email := c.Author.Email
+		// Count commits and track contributors under their canonical identity
+		name, email := authors.resolve(c.Author.Name, c.Author.Email)
+		if contributorOptions.excluded(c, name, email) {
+			return nil
+		}
+		repoData.CommitCount++
 		if _, exists := contributors[email]; !exists {
 			contributors[email] = &Contributor{
-				Name:    c.Author.Name,
-				Email:   email,
-				Commits: 0,
-				// GitHub avatar URL uses MD5 hash of email, which we'd generate here
-				// but for simplicity we'll use a default avatar
-				AvatarURL: fmt.Sprintf("https://avatars.githubusercontent.com/u/0?v=4"),
+				Name:       name,
+				Email:      email,
+				Commits:    0,
+				AvatarURL:  gravatarURL(email),
+				CommitHash: c.Hash.String(),
 			}
 		}
-		contributors[email].Commits++
+		contributor := contributors[email]
+		contributor.Commits++
+		if contributor.FirstCommit.IsZero() || c.Author.When.Before(contributor.FirstCommit) {
+			contributor.FirstCommit = c.Author.When
+		}
+		if c.Author.When.After(contributor.LastCommit) {
+			contributor.LastCommit = c.Author.When
+		}
 
 		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to process commits: %w", err)
 	}
+	if stats {
+		repoData.HistoryTruncated, err = walkHistory(repo, head, walkCommit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process commits: %w", err)
+		}
+	}
+	progress.Finish()
 
 	// Convert contributors map to slice and sort by commit count
 	for _, contributor := range contributors {
@@ -141,88 +702,254 @@ func GetRepositoryData(repo *git.Repository, owner, name, repoPath string) (*Rep
 	// Sort contributors by commit count (we'll implement this in utils)
 	sortContributorsByCommits(repoData.Contributors)
 
-	// If we have more than 5 contributors, limit to top 5
-	if len(repoData.Contributors) > 5 {
-		repoData.Contributors = repoData.Contributors[:5]
+	// Only list the top contributors
+	repoData.AllContributors = repoData.Contributors
+	if top := contributorOptions.Top; top > 0 && len(repoData.Contributors) > top {
+		repoData.Contributors = slices.Clone(repoData.Contributors[:top])
+	}
+
+	// Collect tags for the release history
+	repoData.Tags, err = getTags(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
 	}
 
 	// Walk the repository to find markdown and image files
-	err = filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	ignore := newIgnoreRules(repo)
+	realRoot, err := filepath.EvalSymlinks(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", repoPath, err)
+	}
 
-		// Skip .git directory
-		if d.IsDir() && d.Name() == ".git" {
-			return filepath.SkipDir
-		}
+	// Total size of the source files of each language
+	languageSizes := make(map[string]int64)
 
-		// Skip other common directories we don't want
-		if d.IsDir() && (d.Name() == "node_modules" || d.Name() == "vendor" || d.Name() == ".github") {
-			return filepath.SkipDir
-		}
+	// Real paths of the symlinked directories being walked, to detect cycles
+	walking := make(map[string]bool)
+
+	// walk collects the files under root, which appear at prefix in the repository
+	var walk func(root, prefix string) error
+	walk = func(root, prefix string) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
 
-		// Process files
-		if !d.IsDir() {
-			relativePath, err := filepath.Rel(repoPath, path)
+			// Skip .git directory
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			// Skip paths matched by .gitignore or .ghpageignore, and pick up the
+			// ignore files of each directory before its contents
+			rel, err := filepath.Rel(root, path)
 			if err != nil {
 				return err
 			}
+			rel = filepath.Join(prefix, rel)
+			if rel != "." && ignore.ignored(rel, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				ignore.load(path, rel)
+			}
 
-			// Handle markdown files
-			if isMarkdownFile(d.Name()) {
-				content, err := os.ReadFile(path)
+			// Skip other common directories we don't want
+			if d.IsDir() && (d.Name() == "node_modules" || d.Name() == "vendor" || d.Name() == ".github") {
+				return filepath.SkipDir
+			}
+			if d.IsDir() && isSkipPath(path) {
+				return filepath.SkipDir
+			}
+
+			// Skip symlinks leading back into the repository, where their
+			// targets are collected anyway, and those leading out of it unless
+			// following them was asked for
+			if d.Type()&fs.ModeSymlink != 0 {
+				target, isDir, err := resolveSymlink(path)
 				if err != nil {
-					return fmt.Errorf("failed to read file %s: %w", path, err)
+					logger.Warn("Skipping broken symlink", "path", rel, "error", err)
+					return nil
+				}
+				inRepo := isWithin(realRoot, target)
+				if !inRepo && !followSymlinks {
+					logger.Warn("Skipping symlink leading out of the repository", "path", rel, "target", target)
+					return nil
+				}
+				if isDir {
+					if inRepo {
+						logger.Debug("Skipping symlinked directory already collected", "path", rel, "target", target)
+						return nil
+					}
+					if walking[target] {
+						logger.Warn("Skipping symlink cycle", "path", rel, "target", target)
+						return nil
+					}
+					walking[target] = true
+					defer delete(walking, target)
+					return walk(target, rel)
+				}
+				// READMEs are kept, as they are shown on the home page rather than
+				// as pages of their own
+				if inRepo && !isReadmeFile(d.Name()) {
+					logger.Debug("Skipping symlink to a file already collected", "path", rel, "target", target)
+					return nil
 				}
+			}
+
+			// Process files
+			if !d.IsDir() {
+				// Handle markdown files
+				if isMarkdownFile(d.Name()) {
+					content, err := os.ReadFile(path)
+					if err != nil {
+						return fmt.Errorf("failed to read file %s: %w", path, err)
+					}
 
-				// Store markdown content
-				repoData.MarkdownFiles[relativePath] = string(content)
+					// Store markdown content
+					repoData.MarkdownFiles[rel] = string(content)
 
-				// Check if this is a README file
-				if isReadmeFile(d.Name()) && (repoData.ReadmePath == "" || relativePath == "README.md") {
-					repoData.ReadmePath = relativePath
-					repoData.ReadmeContent = string(content)
+					// Check if this is a README file
+					if isReadmeFile(d.Name()) && (repoData.ReadmePath == "" || PreferReadme(rel, repoData.ReadmePath)) {
+						repoData.ReadmePath = rel
+						repoData.ReadmeContent = string(content)
+					}
+
+					logger.Debug("Found markdown file", "path", rel)
 				}
 
-				fmt.Printf("Found markdown file: %s\n", relativePath)
-			}
+				// Handle documentation in other formats
+				if isDocumentFile(d.Name()) {
+					content, err := os.ReadFile(path)
+					if err != nil {
+						return fmt.Errorf("failed to read file %s: %w", path, err)
+					}
+					repoData.DocumentFiles[rel] = string(content)
+					logger.Debug("Found document file", "path", rel)
+				}
 
-			// Handle image files
-			if isImageFile(d.Name()) {
-				repoData.ImageFiles[relativePath] = path
-				fmt.Printf("Found image file: %s\n", relativePath)
-			}
+				// Handle hand-written HTML pages
+				if isHTMLFile(d.Name()) && strings.HasPrefix(filepath.ToSlash(rel), "docs/") {
+					content, err := os.ReadFile(path)
+					if err != nil {
+						return fmt.Errorf("failed to read file %s: %w", path, err)
+					}
+					repoData.HTMLFiles[rel] = string(content)
+					logger.Debug("Found HTML file", "path", rel)
+				}
+
+				// Handle image files
+				if isImageFile(d.Name()) {
+					repoData.ImageFiles[rel] = path
+					logger.Debug("Found image file", "path", rel)
+				}
 
-			// Check for license file
-			if isLicenseFile(d.Name()) && repoData.License == "" {
-				content, err := os.ReadFile(path)
+				// Record the size of every file, and of source files by language
+				info, err := d.Info()
+				if d.Type()&fs.ModeSymlink != 0 {
+					info, err = os.Stat(path)
+				}
 				if err == nil {
-					// Try to determine license type from content
-					licenseType := detectLicenseType(string(content))
-					if licenseType != "" {
-						repoData.License = licenseType
-					} else {
-						repoData.License = "License"
+					repoData.Files[rel] = info.Size()
+					if lang := languageOf(d.Name()); lang != "" {
+						languageSizes[lang] += info.Size()
+					}
+				}
+
+				// Check for a license file at the root
+				if rel == d.Name() && isLicenseFile(d.Name()) && repoData.LicensePath == "" {
+					content, err := os.ReadFile(path)
+					if err == nil {
+						repoData.LicensePath = rel
+						repoData.LicenseText = string(content)
+						repoData.LicenseSPDX, repoData.License = detectLicense(string(content))
+						if repoData.License == "" {
+							repoData.License = "License"
+						}
 					}
 				}
 			}
-		}
 
-		return nil
-	})
+			return nil
+		})
+	}
+	err = walk(repoPath, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to walk repository: %w", err)
 	}
+	repoData.Languages = sortLanguages(languageSizes)
+
+	repoData.CommunityFiles = findCommunityFiles(repoPath, repoData.MarkdownFiles)
+	repoData.Funding = readFunding(repoPath)
+
+	// Find when each documentation file was last changed
+	docFiles := make(map[string]string)
+	for path, content := range repoData.MarkdownFiles {
+		docFiles[path] = content
+	}
+	for path, content := range repoData.DocumentFiles {
+		docFiles[path] = content
+	}
+	for path, content := range repoData.HTMLFiles {
+		docFiles[path] = content
+	}
+	if stats {
+		repoData.FileHistory, err = getFileHistory(repo, head, docFiles, authors, walked)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file history: %w", err)
+		}
+	}
+
+	// Look for a logo to use as the site icon
+	repoData.LogoFile = findLogo(repoPath, repoData.ImageFiles)
 
 	// If we didn't find a description, try to extract from README
 	if repoData.Description == "" && repoData.ReadmeContent != "" {
-		repoData.Description = extractDescriptionFromReadme(repoData.ReadmeContent)
+		repoData.Description = DescriptionFromReadme(repoData.ReadmeContent)
 	}
 
 	return repoData, nil
 }
 
+// ReadWikiPages reads the markdown pages of a cloned wiki repository
+func ReadWikiPages(wikiPath string) (map[string]string, error) {
+	pages := make(map[string]string)
+
+	err := filepath.WalkDir(wikiPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !isMarkdownFile(d.Name()) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(wikiPath, path)
+		if err != nil {
+			return err
+		}
+		content, err := ReadFileWithin(wikiPath, relativePath)
+		if err != nil {
+			logger.Warn("Skipping wiki page", "path", relativePath, "error", err)
+			return nil
+		}
+
+		pages[relativePath] = string(content)
+		logger.Debug("Found wiki page", "path", relativePath)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk wiki: %w", err)
+	}
+
+	return pages, nil
+}
+
 // isMarkdownFile checks if a filename has a markdown extension
 func isMarkdownFile(filename string) bool {
 	extensions := []string{".md", ".markdown", ".mdown", ".mkdn"}
@@ -235,15 +962,58 @@ func isMarkdownFile(filename string) bool {
 	return false
 }
 
+// isDocumentFile checks if a filename has one of the configured document extensions
+func isDocumentFile(filename string) bool {
+	lowerFilename := strings.ToLower(filename)
+	for _, ext := range documentExtensions {
+		if strings.HasSuffix(lowerFilename, strings.ToLower(ext)) && !isMarkdownFile(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTMLFile checks if a filename has an HTML extension
+func isHTMLFile(filename string) bool {
+	lowerFilename := strings.ToLower(filename)
+	return strings.HasSuffix(lowerFilename, ".html") || strings.HasSuffix(lowerFilename, ".htm")
+}
+
 // isReadmeFile checks if a file is a README
 func isReadmeFile(filename string) bool {
 	lowerFilename := strings.ToLower(filename)
 	return strings.HasPrefix(lowerFilename, "readme.") && isMarkdownFile(filename)
 }
 
+// readmeNames are the README filenames in order of preference
+var readmeNames = []string{"README.md", "README.markdown", "readme.md"}
+
+// PreferReadme reports whether the README at candidate is preferred over the
+// one at current. Shallower READMEs win, then names in the order of
+// readmeNames before other spellings, then the lexically smaller path.
+func PreferReadme(candidate, current string) bool {
+	if c, r := strings.Count(filepath.ToSlash(candidate), "/"), strings.Count(filepath.ToSlash(current), "/"); c != r {
+		return c < r
+	}
+	if c, r := readmeRank(filepath.Base(candidate)), readmeRank(filepath.Base(current)); c != r {
+		return c < r
+	}
+	return candidate < current
+}
+
+// readmeRank returns the position of a README filename in readmeNames
+func readmeRank(name string) int {
+	for i, readme := range readmeNames {
+		if name == readme {
+			return i
+		}
+	}
+	return len(readmeNames)
+}
+
 // isImageFile checks if a filename has an image extension
 func isImageFile(filename string) bool {
-	extensions := []string{".jpg", ".jpeg", ".png", ".gif", ".svg", ".webp"}
+	extensions := []string{".jpg", ".jpeg", ".png", ".gif", ".svg", ".webp", ".ico"}
 	lowerFilename := strings.ToLower(filename)
 	for _, ext := range extensions {
 		if strings.HasSuffix(lowerFilename, ext) {
@@ -253,73 +1023,307 @@ func isImageFile(filename string) bool {
 	return false
 }
 
+// findLogo looks for a logo or favicon in the repository. Root-level logo and
+// favicon files are preferred, then .github/logo.png, then a logo anywhere else.
+func findLogo(repoPath string, imageFiles map[string]string) string {
+	var nested string
+	for relativePath, fullPath := range imageFiles {
+		name := strings.ToLower(filepath.Base(relativePath))
+		if !strings.HasPrefix(name, "logo.") && !strings.HasPrefix(name, "favicon.") {
+			continue
+		}
+		if filepath.Dir(relativePath) == "." {
+			return fullPath
+		}
+		if nested == "" || relativePath < nested {
+			nested = relativePath
+		}
+	}
+
+	// .github is skipped by the walker, so check it directly
+	for _, name := range []string{"logo.png", "logo.svg"} {
+		path := filepath.Join(repoPath, ".github", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	if nested != "" {
+		return imageFiles[nested]
+	}
+	return ""
+}
+
 // isLicenseFile checks if a file is likely a license file
 func isLicenseFile(filename string) bool {
 	lowerFilename := strings.ToLower(filename)
 	return lowerFilename == "license" || lowerFilename == "license.md" ||
-		lowerFilename == "license.txt" || lowerFilename == "copying"
+		lowerFilename == "license.txt" || lowerFilename == "copying" ||
+		lowerFilename == "licence" || lowerFilename == "licence.md" ||
+		lowerFilename == "unlicense"
 }
 
-// detectLicenseType tries to determine the license type from its content
-func detectLicenseType(content string) string {
-	content = strings.ToLower(content)
-
-	// Check for common license types
-	if strings.Contains(content, "mit license") {
-		return "MIT License"
-	} else if strings.Contains(content, "apache license") {
-		return "Apache License"
-	} else if strings.Contains(content, "gnu general public license") ||
-		strings.Contains(content, "gpl") {
-		return "GPL License"
-	} else if strings.Contains(content, "bsd") {
-		return "BSD License"
-	} else if strings.Contains(content, "mozilla public license") {
-		return "Mozilla Public License"
+// DescriptionFromReadme tries to get a short description from README: the
+// first line of text after the title, or of the whole README, without its
+// markdown formatting. Badges, images and HTML-only lines are skipped.
+func DescriptionFromReadme(content string) string {
+	lines := utils.MarkdownLines(content)
+	start := 0
+	for i, line := range lines {
+		if line.Heading == 1 {
+			start = i + 1
+			break
+		}
 	}
 
+	for _, from := range []int{start, 0} {
+		for _, line := range lines[from:] {
+			if line.Heading > 0 {
+				continue
+			}
+			// Lines without letters or digits are rules or table separators
+			text := utils.StripMarkdown(line.Text)
+			if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				return utils.TruncateText(text, 150)
+			}
+		}
+	}
 	return ""
 }
 
-// extractDescriptionFromReadme tries to get a short description from README
-func extractDescriptionFromReadme(content string) string {
-	// Try to find the first paragraph after the title
-	re := regexp.MustCompile(`(?m)^#\s+.+\n+(.+)`)
-	matches := re.FindStringSubmatch(content)
-	if len(matches) > 1 {
-		// Return first paragraph, up to 150 chars
-		desc := matches[1]
-		if len(desc) > 150 {
-			desc = desc[:147] + "..."
+// gravatarURL returns the Gravatar image URL for an email address
+func gravatarURL(email string) string {
+	hash := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return fmt.Sprintf("https://www.gravatar.com/avatar/%x?d=identicon&s=80", hash)
+}
+
+// sortContributorsByCommits sorts contributors by commit count (descending).
+// Ties are ordered by name and email, since contributors are collected from
+// maps and would otherwise come out in a different order on every run.
+func sortContributorsByCommits(contributors []Contributor) {
+	sort.Slice(contributors, func(i, j int) bool {
+		a, b := contributors[i], contributors[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
 		}
-		return desc
+		return a.Email < b.Email
+	})
+}
+
+// getFileHistory walks the commit history from head and records the most recent
+// commit and the contributors of each of the given files, following renames.
+// Merge commits are skipped, since their changes are attributed to the commits
+// they merge, and so are commits left out by the contributor options. Authors
+// are identified by their canonical identity in the mailmap. commits is the number of commits, for progress reporting.
+func getFileHistory(repo *git.Repository, head plumbing.Hash, files map[string]string, authors mailmap, commits int) (map[string]FileHistory, error) {
+	history := make(map[string]FileHistory)
+	contributors := make(map[string]map[string]*Contributor) // path -> email -> contributor
+
+	// Diffs use slash-separated paths
+	wanted := make(map[string]string)
+	for path := range files {
+		wanted[filepath.ToSlash(path)] = path
 	}
 
-	// If no match, just take the first non-empty line
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.HasPrefix(line, "#") {
-			if len(line) > 150 {
-				line = line[:147] + "..."
+	progress := utils.NewProgress("Reading file history", commits)
+	defer progress.Finish()
+	_, err := walkHistory(repo, head, func(c *object.Commit) error {
+		progress.Add(1)
+		if c.NumParents() > 1 {
+			return nil
+		}
+
+		name, email := authors.resolve(c.Author.Name, c.Author.Email)
+		if contributorOptions.excluded(c, name, email) {
+			return nil
+		}
+
+		changed, err := changedFiles(c)
+		if err != nil {
+			return err
+		}
+		for _, change := range changed {
+			path, ok := wanted[change.To]
+			if !ok {
+				continue
+			}
+
+			if _, seen := history[path]; !seen {
+				history[path] = FileHistory{
+					LastModified: c.Author.When,
+					LastAuthor:   name,
+				}
+				contributors[path] = make(map[string]*Contributor)
+			}
+
+			if _, exists := contributors[path][email]; !exists {
+				contributors[path][email] = &Contributor{
+					Name:       name,
+					Email:      email,
+					AvatarURL:  gravatarURL(email),
+					CommitHash: c.Hash.String(),
+				}
+			}
+			contributors[path][email].Commits++
+
+			// Older commits refer to the file by its previous name
+			if change.From != "" && change.From != change.To {
+				wanted[change.From] = path
 			}
-			return line
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return ""
+	for path, byEmail := range contributors {
+		h := history[path]
+		for _, contributor := range byEmail {
+			h.Contributors = append(h.Contributors, *contributor)
+		}
+		sortContributorsByCommits(h.Contributors)
+		history[path] = h
+	}
+
+	return history, nil
 }
 
-// sortContributorsByCommits sorts contributors by commit count (descending)
-func sortContributorsByCommits(contributors []Contributor) {
-	// Simple bubble sort implementation
-	for i := 0; i < len(contributors); i++ {
-		for j := i + 1; j < len(contributors); j++ {
-			if contributors[i].Commits < contributors[j].Commits {
-				contributors[i], contributors[j] = contributors[j], contributors[i]
+// fileChange is a file added, modified or renamed by a commit
+type fileChange struct {
+	From string // previous path, empty for added files
+	To   string
+}
+
+// changedFiles returns the files changed by a commit relative to its first parent,
+// detecting renames. Deleted files are not included.
+func changedFiles(c *object.Commit) ([]fileChange, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []fileChange
+	for _, change := range changes {
+		if change.To.Name != "" {
+			files = append(files, fileChange{From: change.From.Name, To: change.To.Name})
+		}
+	}
+	return files, nil
+}
+
+// Renames returns the files renamed between a commit and HEAD, by their
+// path at the commit
+func Renames(repo *git.Repository, since string) (map[string]string, error) {
+	from, err := repo.CommitObject(plumbing.NewHash(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", since, err)
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+	head, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, headTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with HEAD: %w", since, err)
+	}
+	renames := make(map[string]string)
+	for _, change := range changes {
+		if change.From.Name != "" && change.To.Name != "" && change.From.Name != change.To.Name {
+			renames[change.From.Name] = change.To.Name
+		}
+	}
+	return renames, nil
+}
+
+// getTags lists the tags in the repository, newest first
+func getTags(repo *git.Repository) ([]Tag, error) {
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		tag := Tag{
+			Name: ref.Name().Short(),
+			Hash: ref.Hash().String(),
+		}
+
+		// Annotated tags carry their own date and message
+		if tagObj, err := repo.TagObject(ref.Hash()); err == nil {
+			tag.Date = tagObj.Tagger.When
+			tag.Message = strings.TrimSpace(tagObj.Message)
+			tag.Hash = tagObj.Target.String()
+		} else if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+			tag.Date = commit.Committer.When
+		}
+
+		tags = append(tags, tag)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Date.After(tags[j].Date)
+	})
+
+	return tags, nil
+}
+
+// MatchingTags lists the tags whose names match any of the glob patterns,
+// e.g. "v*", newest first
+func MatchingTags(repo *git.Repository, patterns []string) ([]Tag, error) {
+	tags, err := getTags(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	var matching []Tag
+	for _, tag := range tags {
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, tag.Name); ok {
+				matching = append(matching, tag)
+				break
 			}
 		}
 	}
+	return matching, nil
 }
 
 // GetCommitStats gets commit statistics for the repository
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// pageIgnoreFile lists paths left out of the site, with the same syntax as .gitignore
+const pageIgnoreFile = ".ghpageignore"
+
+// ignoreRules collects the .gitignore and .ghpageignore patterns of a
+// working copy while it is walked, parents before children
+type ignoreRules struct {
+	git  []gitignore.Pattern
+	page []gitignore.Pattern
+
+	// Tracked files and the directories containing them, which are kept even
+	// when they match a .gitignore pattern
+	tracked     map[string]bool
+	trackedDirs map[string]bool
+}
+
+// newIgnoreRules creates the ignore rules of a repository, reading the
+// tracked files from its index
+func newIgnoreRules(repo *git.Repository) *ignoreRules {
+	rules := &ignoreRules{
+		tracked:     make(map[string]bool),
+		trackedDirs: make(map[string]bool),
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		logger.Debug("Could not read index, ignoring tracked files too", "error", err)
+		return rules
+	}
+	for _, entry := range idx.Entries {
+		rules.tracked[entry.Name] = true
+		for dir := filepath.ToSlash(filepath.Dir(entry.Name)); dir != "."; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			rules.trackedDirs[dir] = true
+		}
+	}
+	return rules
+}
+
+// load reads the ignore files of a directory, given relative to the repository root
+func (r *ignoreRules) load(dir, rel string) {
+	var domain []string
+	if rel != "." {
+		domain = strings.Split(filepath.ToSlash(rel), "/")
+	}
+	r.git = append(r.git, readIgnoreFile(filepath.Join(dir, ".gitignore"), domain)...)
+	r.page = append(r.page, readIgnoreFile(filepath.Join(dir, pageIgnoreFile), domain)...)
+}
+
+// ignored reports whether a path relative to the repository root is left out.
+// .gitignore patterns only apply to untracked files, such as build output in
+// a reused working directory; .ghpageignore patterns apply to every file.
+func (r *ignoreRules) ignored(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+
+	if matchIgnore(r.page, parts, isDir) {
+		return true
+	}
+	if (isDir && r.trackedDirs[rel]) || (!isDir && r.tracked[rel]) {
+		return false
+	}
+	return matchIgnore(r.git, parts, isDir)
+}
+
+// matchIgnore applies patterns in order, so later patterns such as negations
+// override earlier ones
+func matchIgnore(patterns []gitignore.Pattern, path []string, isDir bool) bool {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if result := patterns[i].Match(path, isDir); result > gitignore.NoMatch {
+			return result == gitignore.Exclude
+		}
+	}
+	return false
+}
+
+// readIgnoreFile parses an ignore file, returning no patterns if it does not exist
+func readIgnoreFile(path string, domain []string) []gitignore.Pattern {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns
+}
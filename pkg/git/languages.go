@@ -0,0 +1,192 @@
+package git
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Language is a language of the repository's source files, with the total
+// size of its files and the color GitHub's language bar uses for it
+type Language struct {
+	Name  string
+	Bytes int64
+	Color string
+}
+
+// languageFilenames are files recognized by their whole name
+var languageFilenames = map[string]string{
+	"makefile":       "Makefile",
+	"gnumakefile":    "Makefile",
+	"dockerfile":     "Dockerfile",
+	"containerfile":  "Dockerfile",
+	"cmakelists.txt": "CMake",
+}
+
+// languageExtensions are files recognized by their extension. Like GitHub,
+// only programming and markup languages are counted; prose and data formats
+// such as markdown, JSON and YAML are not.
+var languageExtensions = map[string]string{
+	".go":     "Go",
+	".py":     "Python",
+	".js":     "JavaScript",
+	".mjs":    "JavaScript",
+	".cjs":    "JavaScript",
+	".jsx":    "JavaScript",
+	".ts":     "TypeScript",
+	".tsx":    "TypeScript",
+	".java":   "Java",
+	".c":      "C",
+	".h":      "C",
+	".cc":     "C++",
+	".cpp":    "C++",
+	".cxx":    "C++",
+	".hh":     "C++",
+	".hpp":    "C++",
+	".hxx":    "C++",
+	".cs":     "C#",
+	".rs":     "Rust",
+	".rb":     "Ruby",
+	".php":    "PHP",
+	".sh":     "Shell",
+	".bash":   "Shell",
+	".zsh":    "Shell",
+	".html":   "HTML",
+	".htm":    "HTML",
+	".css":    "CSS",
+	".scss":   "SCSS",
+	".kt":     "Kotlin",
+	".kts":    "Kotlin",
+	".swift":  "Swift",
+	".m":      "Objective-C",
+	".mm":     "Objective-C++",
+	".lua":    "Lua",
+	".pl":     "Perl",
+	".pm":     "Perl",
+	".hs":     "Haskell",
+	".scala":  "Scala",
+	".dart":   "Dart",
+	".ex":     "Elixir",
+	".exs":    "Elixir",
+	".erl":    "Erlang",
+	".vue":    "Vue",
+	".svelte": "Svelte",
+	".s":      "Assembly",
+	".asm":    "Assembly",
+	".zig":    "Zig",
+	".nix":    "Nix",
+	".ps1":    "PowerShell",
+	".r":      "R",
+	".jl":     "Julia",
+	".clj":    "Clojure",
+	".cljs":   "Clojure",
+	".ml":     "OCaml",
+	".mli":    "OCaml",
+	".groovy": "Groovy",
+	".tcl":    "Tcl",
+	".bat":    "Batchfile",
+	".cmd":    "Batchfile",
+	".f90":    "Fortran",
+	".sol":    "Solidity",
+	".vim":    "Vim Script",
+	".el":     "Emacs Lisp",
+	".tex":    "TeX",
+	".cmake":  "CMake",
+	".d":      "D",
+	".nim":    "Nim",
+	".cr":     "Crystal",
+	".fs":     "F#",
+	".fsx":    "F#",
+	".lisp":   "Common Lisp",
+	".scm":    "Scheme",
+	".rkt":    "Racket",
+	".elm":    "Elm",
+	".awk":    "Awk",
+}
+
+// languageColors are the colors of GitHub's language bar
+var languageColors = map[string]string{
+	"Go":            "#00ADD8",
+	"Python":        "#3572A5",
+	"JavaScript":    "#f1e05a",
+	"TypeScript":    "#3178c6",
+	"Java":          "#b07219",
+	"C":             "#555555",
+	"C++":           "#f34b7d",
+	"C#":            "#178600",
+	"Rust":          "#dea584",
+	"Ruby":          "#701516",
+	"PHP":           "#4F5D95",
+	"Shell":         "#89e051",
+	"HTML":          "#e34c26",
+	"CSS":           "#663399",
+	"SCSS":          "#c6538c",
+	"Kotlin":        "#A97BFF",
+	"Swift":         "#F05138",
+	"Objective-C":   "#438eff",
+	"Objective-C++": "#6866fb",
+	"Lua":           "#000080",
+	"Perl":          "#0298c3",
+	"Haskell":       "#5e5086",
+	"Scala":         "#c22d40",
+	"Dart":          "#00B4AB",
+	"Elixir":        "#6e4a7e",
+	"Erlang":        "#B83998",
+	"Vue":           "#41b883",
+	"Svelte":        "#ff3e00",
+	"Assembly":      "#6E4C13",
+	"Zig":           "#ec915c",
+	"Nix":           "#7e7eff",
+	"PowerShell":    "#012456",
+	"R":             "#198CE7",
+	"Julia":         "#a270ba",
+	"Clojure":       "#db5855",
+	"OCaml":         "#ef7a08",
+	"Groovy":        "#4298b8",
+	"Tcl":           "#e4cc98",
+	"Batchfile":     "#C1F12E",
+	"Fortran":       "#4d41b1",
+	"Solidity":      "#AA6746",
+	"Vim Script":    "#199f4b",
+	"Emacs Lisp":    "#c065db",
+	"TeX":           "#3D6117",
+	"CMake":         "#DA3434",
+	"D":             "#ba595e",
+	"Nim":           "#ffc200",
+	"Crystal":       "#000100",
+	"F#":            "#b845fc",
+	"Common Lisp":   "#3fb68b",
+	"Scheme":        "#1e4aec",
+	"Racket":        "#3c5caa",
+	"Elm":           "#60B5CC",
+	"Awk":           "#c30e9b",
+	"Makefile":      "#427819",
+	"Dockerfile":    "#384d54",
+}
+
+// languageOf returns the language of a file from its name, or "" if it isn't
+// a source file
+func languageOf(filename string) string {
+	name := strings.ToLower(filename)
+	if lang, ok := languageFilenames[name]; ok {
+		return lang
+	}
+	return languageExtensions[filepath.Ext(name)]
+}
+
+// sortLanguages returns the languages by size, largest first
+func sortLanguages(sizes map[string]int64) []Language {
+	languages := make([]Language, 0, len(sizes))
+	for name, size := range sizes {
+		if size > 0 {
+			languages = append(languages, Language{Name: name, Bytes: size, Color: languageColors[name]})
+		}
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Bytes != languages[j].Bytes {
+			return languages[i].Bytes > languages[j].Bytes
+		}
+		return languages[i].Name < languages[j].Name
+	})
+	return languages
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// licenseRule identifies a license by phrases its text contains
+type licenseRule struct {
+	spdx string
+	name string
+	// all must appear in the text, none must not
+	all  []string
+	none []string
+	// title rules are first matched against the start of the text, since
+	// the full texts of these licenses mention each other
+	title bool
+}
+
+// licenseRules are checked in order, so licenses whose text mentions another
+// license (the LGPL mentions the GPL) come first
+var licenseRules = []licenseRule{
+	{spdx: "AGPL-3.0", name: "GNU Affero General Public License v3.0", all: []string{"gnu affero general public license"}, title: true},
+	{spdx: "LGPL-3.0", name: "GNU Lesser General Public License v3.0", all: []string{"gnu lesser general public license", "version 3"}, title: true},
+	{spdx: "LGPL-2.1", name: "GNU Lesser General Public License v2.1", all: []string{"gnu lesser general public license", "version 2.1"}, title: true},
+	{spdx: "LGPL-2.0", name: "GNU Library General Public License v2.0", all: []string{"gnu library general public license"}, title: true},
+	{spdx: "GPL-3.0", name: "GNU General Public License v3.0", all: []string{"gnu general public license", "version 3"}, title: true},
+	{spdx: "GPL-2.0", name: "GNU General Public License v2.0", all: []string{"gnu general public license", "version 2"}, title: true},
+	{spdx: "MPL-2.0", name: "Mozilla Public License 2.0", all: []string{"mozilla public license", "2.0"}, title: true},
+	{spdx: "Apache-2.0", name: "Apache License 2.0", all: []string{"apache license", "version 2.0"}, title: true},
+	{spdx: "Unlicense", name: "The Unlicense", all: []string{"this is free and unencumbered software released into the public domain"}},
+	{spdx: "ISC", name: "ISC License", all: []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{spdx: "BSD-3-Clause", name: "BSD 3-Clause License", all: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{spdx: "BSD-3-Clause", name: "BSD 3-Clause License", all: []string{"redistribution and use in source and binary forms", "names of its contributors may"}},
+	{spdx: "BSD-2-Clause", name: "BSD 2-Clause License", all: []string{"redistribution and use in source and binary forms"}, none: []string{"advertising materials"}},
+	{spdx: "MIT", name: "MIT License", all: []string{"permission is hereby granted, free of charge"}},
+	{spdx: "MIT", name: "MIT License", all: []string{"mit license"}},
+}
+
+// licenseTitleLength is how much of the start of a license text holds its title
+const licenseTitleLength = 200
+
+// spdxHeaderRe matches an SPDX-License-Identifier line
+var spdxHeaderRe = regexp.MustCompile(`(?i)SPDX-License-Identifier:\s*([A-Za-z0-9.+-]+)`)
+
+// spaceRe matches runs of whitespace, which license texts wrap differently
+var spaceRe = regexp.MustCompile(`\s+`)
+
+// detectLicense determines the SPDX identifier and the name of a license from
+// its text, using an SPDX-License-Identifier header if there is one. It
+// returns empty strings for texts it doesn't recognize.
+func detectLicense(content string) (spdx, name string) {
+	if m := spdxHeaderRe.FindStringSubmatch(content); m != nil {
+		id := strings.TrimSuffix(strings.TrimSuffix(m[1], "-only"), "-or-later")
+		for _, rule := range licenseRules {
+			if strings.EqualFold(rule.spdx, id) {
+				return rule.spdx, rule.name
+			}
+		}
+		return m[1], m[1]
+	}
+
+	content = spaceRe.ReplaceAllString(strings.ToLower(content), " ")
+	title := content[:min(len(content), licenseTitleLength)]
+	for _, rule := range licenseRules {
+		if rule.title && containsAll(title, rule.all) && !containsAny(title, rule.none) {
+			return rule.spdx, rule.name
+		}
+	}
+	for _, rule := range licenseRules {
+		if containsAll(content, rule.all) && !containsAny(content, rule.none) {
+			return rule.spdx, rule.name
+		}
+	}
+	return "", ""
+}
+
+// containsAll reports whether s contains every phrase
+func containsAll(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if !strings.Contains(s, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny reports whether s contains one of the phrases
+func containsAny(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(s, phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// mailmapEntry is the canonical identity of a commit author. Empty fields
+// keep the name or email of the commit.
+type mailmapEntry struct {
+	name  string
+	email string
+}
+
+// mailmap maps the identities authors committed under to their canonical
+// name and email, as listed in the repository's .mailmap
+type mailmap struct {
+	// byEmail holds entries matching any name with the commit email
+	byEmail map[string]mailmapEntry
+	// byNameEmail holds entries matching a commit name and email, keyed by
+	// the lowercased name, a NUL and the lowercased email
+	byNameEmail map[string]mailmapEntry
+}
+
+// readMailmap reads the .mailmap at the root of a working copy. A missing or
+// unreadable file gives an empty mailmap.
+func readMailmap(repoPath string) mailmap {
+	m := mailmap{
+		byEmail:     make(map[string]mailmapEntry),
+		byNameEmail: make(map[string]mailmapEntry),
+	}
+
+	content, err := ReadFileWithin(repoPath, ".mailmap")
+	if err != nil {
+		return m
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		m.parseLine(line)
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Warn("Failed to read .mailmap", "error", err)
+	}
+	return m
+}
+
+// parseLine adds an entry in one of the forms git accepts:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func (m mailmap) parseLine(line string) {
+	var names, emails []string
+	for {
+		open := strings.Index(line, "<")
+		end := strings.Index(line, ">")
+		if open < 0 || end < open {
+			break
+		}
+		names = append(names, strings.TrimSpace(line[:open]))
+		emails = append(emails, strings.TrimSpace(line[open+1:end]))
+		line = line[end+1:]
+	}
+
+	switch len(emails) {
+	case 1:
+		if names[0] != "" {
+			m.add("", emails[0], mailmapEntry{name: names[0]})
+		}
+	case 2:
+		m.add(names[1], emails[1], mailmapEntry{name: names[0], email: emails[0]})
+	}
+}
+
+// add records the canonical identity of a commit name and email. An empty
+// commit name matches any name.
+func (m mailmap) add(name, email string, entry mailmapEntry) {
+	email = strings.ToLower(email)
+	if name == "" {
+		// Entries for the same email complement each other
+		existing := m.byEmail[email]
+		if entry.name == "" {
+			entry.name = existing.name
+		}
+		if entry.email == "" {
+			entry.email = existing.email
+		}
+		m.byEmail[email] = entry
+		return
+	}
+	m.byNameEmail[strings.ToLower(name)+"\x00"+email] = entry
+}
+
+// resolve returns the canonical name and email of a commit author
+func (m mailmap) resolve(name, email string) (string, string) {
+	entry, ok := m.byNameEmail[strings.ToLower(name)+"\x00"+strings.ToLower(email)]
+	if !ok {
+		entry, ok = m.byEmail[strings.ToLower(email)]
+	}
+	if !ok {
+		return name, email
+	}
+	if entry.name != "" {
+		name = entry.name
+	}
+	if entry.email != "" {
+		email = entry.email
+	}
+	return name, email
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRelatedFile lists the related repositories of a working copy,
+// unless another file is given
+const DefaultRelatedFile = "related.yml"
+
+// relatedURLSafe reports whether the URL of a related repository is an http
+// or https URL, or relative to the site
+func relatedURLSafe(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "" || scheme == "http" || scheme == "https"
+}
+
+// RelatedRepo is a related repository, such as another library of the same
+// organization, shown as a card on the home page
+type RelatedRepo struct {
+	// Name is the name shown on the card; an owner/name on GitHub also
+	// gives the default URL and the star count
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	URL         string `yaml:"url"`
+	// Stars is the star count, from the file or the GitHub API
+	Stars int `yaml:"stars"`
+}
+
+// GitHubRepo returns the owner and name of a related repository on GitHub
+func (r RelatedRepo) GitHubRepo() (string, string, bool) {
+	path, ok := strings.CutPrefix(r.URL, "https://github.com/")
+	if !ok {
+		return "", "", false
+	}
+	owner, name, ok := strings.Cut(strings.TrimSuffix(path, "/"), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", false
+	}
+	return owner, strings.TrimSuffix(name, ".git"), true
+}
+
+// ReadRelated reads the related repositories from a YAML list in a file,
+// relative to the working copy if it isn't absolute. Without a file,
+// DefaultRelatedFile is read if it exists. Repositories named owner/name
+// without a URL link to GitHub. Entries whose URL isn't http, https or
+// relative, such as javascript: URLs, are left out with a warning.
+func ReadRelated(repoPath, file string) ([]RelatedRepo, error) {
+	path := file
+	if path == "" {
+		path = DefaultRelatedFile
+	}
+	var data []byte
+	var err error
+	if filepath.IsAbs(path) {
+		data, err = os.ReadFile(path)
+	} else {
+		data, err = ReadFileWithin(repoPath, path)
+	}
+	if errors.Is(err, fs.ErrNotExist) && file == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read related repositories: %w", err)
+	}
+
+	var repos []RelatedRepo
+	if err := yaml.Unmarshal(data, &repos); err != nil {
+		return nil, fmt.Errorf("invalid related repositories file %s: %w", path, err)
+	}
+	var related []RelatedRepo
+	for i, r := range repos {
+		if r.Name == "" {
+			return nil, fmt.Errorf("invalid related repositories file %s: entry %d has no name", path, i+1)
+		}
+		if !relatedURLSafe(r.URL) {
+			logger.Warn("Skipping related repository with an unsupported URL", "file", path, "name", r.Name, "url", r.URL)
+			continue
+		}
+		if r.URL == "" {
+			if owner, name, ok := strings.Cut(r.Name, "/"); ok && owner != "" && name != "" && !strings.Contains(name, "/") {
+				r.URL = "https://github.com/" + r.Name
+			}
+		}
+		related = append(related, r)
+	}
+	return related, nil
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadRelatedSkipsUnsafeURLs(t *testing.T) {
+	dir := t.TempDir()
+	file := `- name: go-i2p/i2pkeys
+- name: Docs
+  url: ../docs/
+- name: Home
+  url: https://example.org/
+- name: Evil
+  url: javascript:alert(1)
+- name: Data
+  url: " DATA:text/html,x"
+`
+	if err := os.WriteFile(filepath.Join(dir, DefaultRelatedFile), []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := ReadRelated(dir, "")
+	if err != nil {
+		t.Fatalf("ReadRelated: %v", err)
+	}
+	want := []string{"https://github.com/go-i2p/i2pkeys", "../docs/", "https://example.org/"}
+	if len(repos) != len(want) {
+		t.Fatalf("ReadRelated returned %d repositories, want %d: %+v", len(repos), len(want), repos)
+	}
+	for i, r := range repos {
+		if r.URL != want[i] {
+			t.Errorf("repository %d has URL %q, want %q", i, r.URL, want[i])
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Renders the TeX source of .math elements with KaTeX or MathJax, whichever
+// the page loads: KaTeX is loaded before this script, MathJax after it
+(function () {
+  function renderAll(render) {
+    document.querySelectorAll('.math').forEach(function (el) {
+      render(el, el.textContent, el.classList.contains('math-display'));
+    });
+  }
+
+  if (window.katex) {
+    renderAll(function (el, tex, display) {
+      katex.render(tex, el, { displayMode: display, throwOnError: false });
+    });
+    return;
+  }
+
+  window.MathJax = {
+    startup: {
+      typeset: false,
+      pageReady: function () {
+        return MathJax.startup.defaultPageReady().then(function () {
+          renderAll(function (el, tex, display) {
+            el.replaceChildren(MathJax.tex2svg(tex, { display: display }));
+          });
+        });
+      }
+    }
+  };
+})();
@@ -0,0 +1,136 @@
+// Searches the documentation in the browser: search.html?q=terms lists the
+// sections of search-index.json that contain every term, title and heading
+// matches first, with the terms highlighted in a snippet of the text. The
+// arrow keys move between the results, and / focuses the search box.
+(function () {
+  // The index and the URLs in it are relative to the site root, where this
+  // script is
+  var root = document.currentScript.src;
+  var input = document.getElementById('search-input');
+  var status = document.getElementById('search-status');
+  var results = document.getElementById('search-results');
+
+  document.addEventListener('keydown', function (event) {
+    var typing = /^(INPUT|TEXTAREA|SELECT)$/.test(document.activeElement.tagName);
+    if (event.key === '/' && !typing) {
+      event.preventDefault();
+      input.focus();
+      input.select();
+      return;
+    }
+    if (event.key !== 'ArrowDown' && event.key !== 'ArrowUp') {
+      return;
+    }
+    var links = Array.prototype.slice.call(results.querySelectorAll('a'));
+    if (!links.length || (typing && document.activeElement !== input)) {
+      return;
+    }
+    event.preventDefault();
+    var at = links.indexOf(document.activeElement);
+    if (event.key === 'ArrowDown') {
+      links[Math.min(at + 1, links.length - 1)].focus();
+    } else if (at <= 0) {
+      input.focus();
+    } else {
+      links[at - 1].focus();
+    }
+  });
+
+  var query = (new URLSearchParams(window.location.search).get('q') || '').trim();
+  input.value = query;
+  if (!query) {
+    return;
+  }
+  var terms = query.toLowerCase().split(/\s+/);
+
+  // Text around the first match of a term
+  function snippet(text) {
+    var lower = text.toLowerCase();
+    var at = -1;
+    terms.forEach(function (term) {
+      var i = lower.indexOf(term);
+      if (i >= 0 && (at < 0 || i < at)) {
+        at = i;
+      }
+    });
+    var start = Math.max(at - 60, 0);
+    var end = Math.min(Math.max(at, 0) + 160, text.length);
+    if (start > 0) {
+      start = text.indexOf(' ', start) + 1;
+    }
+    return (start > 0 ? '…' : '') + text.slice(start, end) + (end < text.length ? '…' : '');
+  }
+
+  // Appends text to an element with the terms wrapped in <mark>
+  function appendHighlighted(element, text) {
+    var lower = text.toLowerCase();
+    var pos = 0;
+    while (pos < text.length) {
+      var next = -1;
+      var length = 0;
+      terms.forEach(function (term) {
+        var i = lower.indexOf(term, pos);
+        if (i >= 0 && (next < 0 || i < next || (i === next && term.length > length))) {
+          next = i;
+          length = term.length;
+        }
+      });
+      if (next < 0) {
+        break;
+      }
+      element.append(text.slice(pos, next));
+      var mark = document.createElement('mark');
+      mark.textContent = text.slice(next, next + length);
+      element.append(mark);
+      pos = next + length;
+    }
+    element.append(text.slice(pos));
+  }
+
+  fetch(new URL('search-index.json', root))
+    .then(function (response) { return response.json(); })
+    .then(function (sections) {
+      var matches = [];
+      sections.forEach(function (section) {
+        var title = section.title.toLowerCase();
+        var heading = (section.section || '').toLowerCase();
+        var text = section.text.toLowerCase();
+        var score = 0;
+        for (var i = 0; i < terms.length; i++) {
+          if (heading.indexOf(terms[i]) >= 0) {
+            score += 10;
+          } else if (title.indexOf(terms[i]) >= 0) {
+            score += 5;
+          } else if (text.indexOf(terms[i]) >= 0) {
+            score += 1;
+          } else {
+            return;
+          }
+        }
+        matches.push({ section: section, score: score });
+      });
+      matches.sort(function (a, b) { return b.score - a.score; });
+
+      status.textContent = matches.length
+        ? status.dataset.results.replace('{count}', matches.length)
+        : status.dataset.noResults;
+      matches.forEach(function (match) {
+        var section = match.section;
+        var item = document.createElement('li');
+        var link = document.createElement('a');
+        link.href = new URL(section.url, root).href;
+        appendHighlighted(link, section.title);
+        if (section.section && section.section !== section.title) {
+          link.append(' › ');
+          appendHighlighted(link, section.section);
+        }
+        var text = document.createElement('p');
+        appendHighlighted(text, snippet(section.text));
+        item.append(link, text);
+        results.appendChild(item);
+      });
+    })
+    .catch(function () {
+      status.textContent = status.dataset.noResults;
+    });
+})();
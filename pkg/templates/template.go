@@ -1,6 +1,14 @@
 package templates
 
-import _ "embed"
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
 
 //go:embed main.html
 var MainTemplate string
@@ -8,8 +16,225 @@ var MainTemplate string
 //go:embed doc.html
 var DocTemplate string
 
+//go:embed releases.html
+var ReleasesTemplate string
+
+//go:embed changelog.html
+var ChangelogTemplate string
+
+//go:embed issues.html
+var IssuesTemplate string
+
+//go:embed contributors.html
+var ContributorsTemplate string
+
+//go:embed tree.html
+var TreeTemplate string
+
+//go:embed search.html
+var SearchTemplate string
+
+//go:embed tags.html
+var TagsTemplate string
+
+//go:embed blog.html
+var BlogTemplate string
+
+//go:embed landing.html
+var LandingTemplate string
+
 //go:embed style.css
 var StyleTemplate string
 
+//go:embed math.js
+var MathScript string
+
+//go:embed search.js
+var SearchScript string
+
 //go:embed page.yml
 var CITemplate string
+
+//go:embed partials/*.html
+var partialFiles embed.FS
+
+//go:embed messages/*.json
+var messageFiles embed.FS
+
+// Messages holds the catalogs of UI strings used by the templates, keyed by
+// language code and then by message name. English is complete; other
+// catalogs may omit messages, which then fall back to English.
+var Messages = loadMessages()
+
+// loadMessages reads the embedded message catalogs
+func loadMessages() map[string]map[string]string {
+	catalogs := make(map[string]map[string]string)
+	entries, _ := messageFiles.ReadDir("messages")
+	for _, entry := range entries {
+		data, err := messageFiles.ReadFile("messages/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		catalog := make(map[string]string)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid message catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	return catalogs
+}
+
+// HasCatalog reports whether there is a message catalog for a language,
+// either for the exact code (pt-br) or for its base language (pt)
+func HasCatalog(lang string) bool {
+	lang = strings.ToLower(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	_, exact := Messages[lang]
+	_, ok := Messages[base]
+	return exact || ok
+}
+
+// Catalog returns the UI strings of a language. Messages missing from the
+// catalog of the language are taken from its base language and then English.
+func Catalog(lang string) map[string]string {
+	lang = strings.ToLower(lang)
+	base, _, _ := strings.Cut(lang, "-")
+
+	catalog := make(map[string]string)
+	for _, code := range []string{"en", base, lang} {
+		for key, msg := range Messages[code] {
+			catalog[key] = msg
+		}
+	}
+	return catalog
+}
+
+// Partials holds the sub-templates shared by all page templates, keyed by
+// name: layout, head, nav, nav-section, header and footer. Page templates
+// render {{template "layout" .}} and define the "content" of the page.
+var Partials = loadPartials()
+
+// loadPartials reads the embedded partials
+func loadPartials() map[string]string {
+	partials := make(map[string]string)
+	entries, _ := partialFiles.ReadDir("partials")
+	for _, entry := range entries {
+		data, err := partialFiles.ReadFile("partials/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		partials[strings.TrimSuffix(entry.Name(), ".html")] = string(data)
+	}
+	return partials
+}
+
+// PartialNames returns the sorted names of the partials
+func PartialNames() []string {
+	var names []string
+	for name := range Partials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadDir overrides templates with the files of a directory. A file named
+// after a partial (e.g. footer.html) replaces only that partial, a file named
+// after a page template (main.html, doc.html, releases.html, changelog.html,
+// issues.html, contributors.html, tree.html, search.html, tags.html, blog.html, landing.html) replaces the whole page, and style.css replaces the stylesheet.
+// Message catalogs in a messages/ subdirectory (e.g. messages/es.json) are
+// merged over the built-in catalog of their language.
+// It returns the names of the files that were loaded.
+func LoadDir(dir string) ([]string, error) {
+	pages := map[string]*string{
+		"main.html":         &MainTemplate,
+		"doc.html":          &DocTemplate,
+		"releases.html":     &ReleasesTemplate,
+		"changelog.html":    &ChangelogTemplate,
+		"issues.html":       &IssuesTemplate,
+		"contributors.html": &ContributorsTemplate,
+		"tree.html":         &TreeTemplate,
+		"search.html":       &SearchTemplate,
+		"tags.html":         &TagsTemplate,
+		"blog.html":         &BlogTemplate,
+		"landing.html":      &LandingTemplate,
+		"style.css":         &StyleTemplate,
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	var loaded []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if entry.Name() == "messages" {
+				names, err := loadMessageDir(filepath.Join(dir, "messages"))
+				if err != nil {
+					return nil, err
+				}
+				loaded = append(loaded, names...)
+			}
+			continue
+		}
+		name := entry.Name()
+		if filepath.Ext(name) != ".html" && name != "style.css" {
+			continue
+		}
+		partial := strings.TrimSuffix(name, ".html")
+		target, isPage := pages[name]
+		if _, isPartial := Partials[partial]; !isPage && !isPartial {
+			return nil, fmt.Errorf("unknown template %s in %s", name, dir)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		if isPage {
+			*target = string(data)
+		} else {
+			Partials[partial] = string(data)
+		}
+		loaded = append(loaded, name)
+	}
+
+	return loaded, nil
+}
+
+// loadMessageDir merges the message catalogs of a directory into Messages
+func loadMessageDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message directory: %w", err)
+	}
+
+	var loaded []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message catalog %s: %w", name, err)
+		}
+		catalog := make(map[string]string)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %w", name, err)
+		}
+
+		lang := strings.ToLower(strings.TrimSuffix(name, ".json"))
+		if Messages[lang] == nil {
+			Messages[lang] = make(map[string]string)
+		}
+		for key, msg := range catalog {
+			Messages[lang][key] = msg
+		}
+		loaded = append(loaded, "messages/"+name)
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Deployment modes of the generated workflow
+const (
+	// WorkflowBranch commits the site to the gh-pages branch
+	WorkflowBranch = "branch"
+	// WorkflowArtifact uploads the site as a Pages artifact and deploys it
+	// with actions/deploy-pages, without a gh-pages branch
+	WorkflowArtifact = "artifact"
+)
+
+// WorkflowModes lists the supported deployment modes
+var WorkflowModes = []string{WorkflowBranch, WorkflowArtifact}
+
+// Workflow holds the parameters of the GitHub Actions workflow written by
+// -page-yaml
+type Workflow struct {
+	// Branch is the branch whose pushes rebuild the site
+	Branch string
+	// Schedule is a cron expression for periodic rebuilds; empty disables them
+	Schedule string
+	// GoVersion is the Go version the generator is built with
+	GoVersion string
+	// Flags are extra arguments passed to github-site-gen
+	Flags []string
+	// Mode is how the site is deployed, one of WorkflowModes
+	Mode string
+}
+
+// DefaultWorkflow rebuilds the site hourly and on pushes to main
+var DefaultWorkflow = Workflow{
+	Branch:    "main",
+	Schedule:  "0 * * * *",
+	GoVersion: "1.24.x",
+	Mode:      WorkflowBranch,
+}
+
+// Render fills in the workflow template. The template uses [[ ]] delimiters,
+// since GitHub Actions expressions already use ${{ }}.
+func (w Workflow) Render() (string, error) {
+	if !slices.Contains(WorkflowModes, w.Mode) {
+		return "", fmt.Errorf("invalid workflow mode %q", w.Mode)
+	}
+	if w.Branch == "" || strings.ContainsAny(w.Branch, " \t\n'\"") {
+		return "", fmt.Errorf("invalid workflow branch %q", w.Branch)
+	}
+	if w.GoVersion == "" || strings.ContainsAny(w.GoVersion, " \t\n'\"") {
+		return "", fmt.Errorf("invalid Go version %q", w.GoVersion)
+	}
+	if w.Schedule != "" && (len(strings.Fields(w.Schedule)) != 5 || strings.ContainsAny(w.Schedule, "\n'")) {
+		return "", fmt.Errorf("invalid cron schedule %q: expected five fields", w.Schedule)
+	}
+	for _, flag := range w.Flags {
+		if strings.Contains(flag, "\n") {
+			return "", fmt.Errorf("invalid workflow flag %q", flag)
+		}
+	}
+
+	tmpl, err := template.New("page.yml").Delims("[[", "]]").Parse(CITemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse workflow template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, w); err != nil {
+		return "", fmt.Errorf("failed to render workflow: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ChangelogVersion is a single release section of a changelog
+type ChangelogVersion struct {
+	Version  string
+	Date     string
+	Anchor   string
+	URL      string
+	Summary  string // markdown before the first subsection
+	Sections []ChangelogSection
+}
+
+// ChangelogSection is a group of changes within a version, e.g. "Added" or "Fixed"
+type ChangelogSection struct {
+	Title string
+	Body  string // markdown
+}
+
+// Changelog is a parsed "Keep a Changelog"-style document
+type Changelog struct {
+	Title    string
+	Preamble string // markdown before the first version
+	Versions []ChangelogVersion
+}
+
+var (
+	changelogVersionRe = regexp.MustCompile(`^##\s+\[?([^\]\s]+)\]?(?:\s*[-–—]?\s*\(?([^)]*)\)?)?\s*$`)
+	changelogSectionRe = regexp.MustCompile(`^###\s+(.+?)\s*$`)
+	changelogLinkRe    = regexp.MustCompile(`^\[([^\]]+)\]:\s*(\S+)\s*$`)
+	anchorRe           = regexp.MustCompile(`[^a-z0-9.]+`)
+)
+
+// ParseChangelog splits a changelog into versions and their change sections.
+// Version headings are level-2 headings such as "## [1.0.0] - 2024-01-31",
+// and change types are level-3 headings such as "### Added". Link reference
+// definitions of versions give their URL; other definitions, such as those
+// of issues, are added to every part of the changelog using them, as the
+// parts are rendered separately.
+func ParseChangelog(content string) Changelog {
+	var cl Changelog
+	var preamble, body strings.Builder
+	var definitions [][]string
+
+	var current *ChangelogVersion
+	var section *ChangelogSection
+
+	flush := func() {
+		text := strings.TrimSpace(body.String())
+		body.Reset()
+		if current == nil {
+			return
+		}
+		if section != nil {
+			section.Body = text
+			current.Sections = append(current.Sections, *section)
+			section = nil
+		} else {
+			current.Summary = text
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		// Link reference definitions are collected, and sorted out once the
+		// versions are known
+		if m := changelogLinkRe.FindStringSubmatch(line); m != nil {
+			definitions = append(definitions, m)
+			continue
+		}
+
+		if m := changelogVersionRe.FindStringSubmatch(line); m != nil {
+			flush()
+			if current != nil {
+				cl.Versions = append(cl.Versions, *current)
+			}
+			current = &ChangelogVersion{
+				Version: m[1],
+				Date:    strings.TrimSpace(m[2]),
+				Anchor:  strings.Trim(anchorRe.ReplaceAllString(strings.ToLower(m[1]), "-"), "-"),
+			}
+			continue
+		}
+
+		if current == nil {
+			if cl.Title == "" && strings.HasPrefix(line, "# ") {
+				cl.Title = strings.TrimSpace(line[2:])
+				continue
+			}
+			preamble.WriteString(line + "\n")
+			continue
+		}
+
+		if m := changelogSectionRe.FindStringSubmatch(line); m != nil {
+			flush()
+			section = &ChangelogSection{Title: m[1]}
+			continue
+		}
+
+		body.WriteString(line + "\n")
+	}
+
+	flush()
+	if current != nil {
+		cl.Versions = append(cl.Versions, *current)
+	}
+	cl.Preamble = strings.TrimSpace(preamble.String())
+
+	versions := map[string]int{"unreleased": -1}
+	for i, v := range cl.Versions {
+		versions[strings.ToLower(v.Version)] = i
+	}
+	var references []string
+	for _, m := range definitions {
+		i, ok := versions[strings.ToLower(m[1])]
+		switch {
+		case !ok:
+			references = append(references, m[0])
+		case i >= 0 && cl.Versions[i].URL == "":
+			cl.Versions[i].URL = m[2]
+		}
+	}
+
+	if len(references) > 0 {
+		cl.Preamble = withReferences(cl.Preamble, references)
+		for i := range cl.Versions {
+			v := &cl.Versions[i]
+			v.Summary = withReferences(v.Summary, references)
+			for j := range v.Sections {
+				v.Sections[j].Body = withReferences(v.Sections[j].Body, references)
+			}
+		}
+	}
+
+	return cl
+}
+
+// withReferences appends the link reference definitions a part of a
+// changelog may use to its markdown, unless it is empty
+func withReferences(markdown string, references []string) string {
+	if markdown == "" {
+		return ""
+	}
+	return markdown + "\n\n" + strings.Join(references, "\n")
+}
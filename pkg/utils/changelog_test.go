@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChangelogKeepsOtherLinkReferences(t *testing.T) {
+	cl := ParseChangelog(`# Changelog
+
+## [Unreleased]
+
+### Fixed
+- Crash on startup ([#123])
+
+## [1.0.0] - 2024-01-31
+
+### Security
+- Fix [CVE-2024-1234]
+
+[unreleased]: https://github.com/owner/repo/compare/v1.0.0...HEAD
+[1.0.0]: https://github.com/owner/repo/releases/tag/v1.0.0
+[#123]: https://github.com/owner/repo/issues/123
+[CVE-2024-1234]: https://nvd.nist.gov/vuln/detail/CVE-2024-1234
+`)
+
+	if len(cl.Versions) != 2 {
+		t.Fatalf("parsed %d versions, want 2", len(cl.Versions))
+	}
+	if got, want := cl.Versions[1].URL, "https://github.com/owner/repo/releases/tag/v1.0.0"; got != want {
+		t.Errorf("URL of 1.0.0 = %q, want %q", got, want)
+	}
+	for _, v := range cl.Versions {
+		for _, s := range v.Sections {
+			for _, ref := range []string{"[#123]: ", "[CVE-2024-1234]: "} {
+				if !strings.Contains(s.Body, ref) {
+					t.Errorf("section %s of %s lacks the definition %s", s.Title, v.Version, ref)
+				}
+			}
+			if strings.Contains(s.Body, "[1.0.0]:") || strings.Contains(s.Body, "[unreleased]:") {
+				t.Errorf("section %s of %s includes a version link: %q", s.Title, v.Version, s.Body)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Citation is how a project asks to be cited, from its CITATION.cff file
+// (https://citation-file-format.github.io). When the file has a
+// preferred-citation, such as the paper describing the software, its fields
+// take the place of the software's own.
+type Citation struct {
+	// Type is "software" for the software itself, or the type of the
+	// preferred citation, such as "article"
+	Type    string
+	Message string
+	Title   string
+	Authors []CitationAuthor
+	Version string
+	// Year of the release or publication, if known
+	Year    string
+	DOI     string
+	URL     string
+	Journal string
+}
+
+// CitationAuthor is a person, with family and given names, or an entity
+// such as an organization, with a name
+type CitationAuthor struct {
+	FamilyNames  string `yaml:"family-names"`
+	GivenNames   string `yaml:"given-names"`
+	NameParticle string `yaml:"name-particle"`
+	NameSuffix   string `yaml:"name-suffix"`
+	Name         string `yaml:"name"`
+}
+
+// citationFields are the fields of a CITATION.cff file and of its
+// preferred-citation that make up a citation
+type citationFields struct {
+	Type           string           `yaml:"type"`
+	Title          string           `yaml:"title"`
+	Authors        []CitationAuthor `yaml:"authors"`
+	Version        string           `yaml:"version"`
+	DateReleased   string           `yaml:"date-released"`
+	DatePublished  string           `yaml:"date-published"`
+	Year           string           `yaml:"year"`
+	DOI            string           `yaml:"doi"`
+	URL            string           `yaml:"url"`
+	RepositoryCode string           `yaml:"repository-code"`
+	Journal        string           `yaml:"journal"`
+}
+
+// ParseCitation reads a CITATION.cff file. It fails if the file isn't
+// valid YAML or has no title.
+func ParseCitation(content string) (Citation, error) {
+	var file struct {
+		citationFields    `yaml:",inline"`
+		Message           string          `yaml:"message"`
+		PreferredCitation *citationFields `yaml:"preferred-citation"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		return Citation{}, err
+	}
+
+	fields := file.citationFields
+	if fields.Type == "" {
+		fields.Type = "software"
+	}
+	if p := file.PreferredCitation; p != nil {
+		fields = *p
+		if fields.Type == "" {
+			fields.Type = "generic"
+		}
+	}
+	if strings.TrimSpace(fields.Title) == "" {
+		return Citation{}, fmt.Errorf("missing title")
+	}
+
+	c := Citation{
+		Type:    fields.Type,
+		Message: strings.TrimSpace(file.Message),
+		Title:   strings.TrimSpace(fields.Title),
+		Authors: fields.Authors,
+		Version: fields.Version,
+		Year:    fields.Year,
+		DOI:     fields.DOI,
+		URL:     fields.URL,
+		Journal: fields.Journal,
+	}
+	if c.URL == "" {
+		c.URL = fields.RepositoryCode
+	}
+	// Dates are YYYY-MM-DD
+	for _, date := range []string{fields.DateReleased, fields.DatePublished} {
+		if c.Year == "" && len(date) >= 4 {
+			c.Year = date[:4]
+		}
+	}
+	return c, nil
+}
+
+// FamilyName returns the family name of a person with its particle, such as
+// "van Rossum", or the name of an entity
+func (a CitationAuthor) FamilyName() string {
+	if a.FamilyNames == "" {
+		return a.Name
+	}
+	return strings.TrimSpace(a.NameParticle + " " + a.FamilyNames)
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter contains the metadata parsed from a markdown file's YAML header
+type FrontMatter struct {
+	Title         string `yaml:"title"`
+	Description   string `yaml:"description"`
+	Weight        int    `yaml:"weight"`
+	Draft         bool   `yaml:"draft"`
+	Section       string `yaml:"section"`
+	SectionWeight int    `yaml:"section_weight"`
+	Slug          string `yaml:"slug"`
+	NoIndex       bool   `yaml:"noindex"`
+	Tags          Tags   `yaml:"tags"`
+	// Date is the publication date of a blog post, e.g. 2024-05-01
+	Date string `yaml:"date"`
+}
+
+// Tags are the tags of a page, given in front matter as a list or as a
+// comma-separated string
+type Tags []string
+
+// UnmarshalYAML accepts a list of tags or a comma-separated string
+func (t *Tags) UnmarshalYAML(node *yaml.Node) error {
+	var list []string
+	if node.Kind == yaml.ScalarNode {
+		list = strings.Split(node.Value, ",")
+	} else if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*t = nil
+	for _, tag := range list {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
+
+// ParseFrontMatter splits a leading YAML front matter block from markdown content.
+// It returns the parsed metadata and the remaining content. If the content has no
+// front matter, the content is returned unchanged. If the block cannot be
+// parsed, it is still stripped, and an error is returned with empty metadata.
+func ParseFrontMatter(content string) (FrontMatter, string, error) {
+	var fm FrontMatter
+
+	// Front matter must start on the very first line
+	normalized := strings.TrimPrefix(content, "\ufeff")
+	if !strings.HasPrefix(normalized, "---\n") && !strings.HasPrefix(normalized, "---\r\n") {
+		return fm, content, nil
+	}
+
+	// Find the closing delimiter
+	lines := strings.SplitAfter(normalized, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimRight(lines[i], "\r\n")
+		if trimmed == "---" || trimmed == "..." {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return fm, content, nil
+	}
+
+	header := strings.Join(lines[1:end], "")
+	body := strings.Join(lines[end+1:], "")
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return FrontMatter{}, body, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	return fm, body, nil
+}
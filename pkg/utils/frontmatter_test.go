@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		title   string
+		draft   bool
+		body    string
+		invalid bool
+	}{
+		{"none", "# Title\n", "", false, "# Title\n", false},
+		{"valid", "---\ntitle: Guide\ndraft: true\n---\nBody\n", "Guide", true, "Body\n", false},
+		{"unclosed", "---\ntitle: Guide\nBody\n", "", false, "---\ntitle: Guide\nBody\n", false},
+		{"invalid", "---\ntitle: [Guide\ndraft: true\n---\nBody\n", "", false, "Body\n", true},
+		{"wrong type", "---\nweight: heavy\n---\nBody\n", "", false, "Body\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := ParseFrontMatter(tt.in)
+			if (err != nil) != tt.invalid {
+				t.Errorf("ParseFrontMatter error = %v, want invalid = %v", err, tt.invalid)
+			}
+			if fm.Title != tt.title || fm.Draft != tt.draft || body != tt.body {
+				t.Errorf("ParseFrontMatter = %+v, %q, want title %q, draft %v, body %q", fm, body, tt.title, tt.draft, tt.body)
+			}
+		})
+	}
+}
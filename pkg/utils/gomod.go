@@ -0,0 +1,61 @@
+package utils
+
+import "strings"
+
+// GoModule is the metadata of a Go module from its go.mod file
+type GoModule struct {
+	Path      string
+	GoVersion string
+	// Requires are the direct dependencies, in the order of go.mod
+	Requires []GoRequire
+}
+
+// GoRequire is a dependency of a Go module
+type GoRequire struct {
+	Path    string
+	Version string
+}
+
+// ParseGoMod reads the module path, Go version and direct dependencies of a
+// go.mod file. Dependencies marked "// indirect" are left out. It reports
+// false if the file has no module directive.
+func ParseGoMod(content string) (GoModule, bool) {
+	var mod GoModule
+	inRequire := false
+
+	for _, line := range strings.Split(content, "\n") {
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			line, comment = line[:i], strings.TrimSpace(line[i+2:])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var require []string
+		switch {
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case inRequire:
+			require = fields
+		case fields[0] == "module" && len(fields) > 1:
+			mod.Path = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) > 1:
+			mod.GoVersion = fields[1]
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require":
+			require = fields[1:]
+		}
+
+		if len(require) == 2 && comment != "indirect" && !strings.HasPrefix(comment, "indirect;") {
+			mod.Requires = append(mod.Requires, GoRequire{
+				Path:    strings.Trim(require[0], `"`),
+				Version: require[1],
+			})
+		}
+	}
+	return mod, mod.Path != ""
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// GoWorkModules returns the module directories listed in the use directives
+// of a go.work file, relative to its directory. The workspace root itself is
+// left out.
+func GoWorkModules(content string) []string {
+	var dirs []string
+	inUse := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		var dir string
+		switch {
+		case inUse && line == ")":
+			inUse = false
+			continue
+		case inUse:
+			dir = line
+		case line == "use (":
+			inUse = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			dir = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+		default:
+			continue
+		}
+
+		dir = filepath.ToSlash(filepath.Clean(strings.Trim(dir, `"`)))
+		if dir != "" && dir != "." {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
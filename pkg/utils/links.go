@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// LinkRewriter returns the new destination of a link or image of a
+// document, or the destination unchanged
+type LinkRewriter func(dest string, image bool) string
+
+// RewriteLinks calls rewrite for the destination of every link and image of
+// a parsed markdown document and sets the destination it returns. Links of
+// every kind are covered: inline links, reference-style links, whose
+// destination the parser took from the reference definition, and autolinks.
+// Footnote references are left alone.
+func RewriteLinks(doc ast.Node, rewrite LinkRewriter) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := node.(type) {
+		case *ast.Link:
+			if n.NoteID == 0 && n.Footnote == nil {
+				n.Destination = []byte(rewrite(string(n.Destination), false))
+			}
+		case *ast.Image:
+			n.Destination = []byte(rewrite(string(n.Destination), true))
+		}
+		return ast.GoToNext
+	})
+}
+
+// RelativeLink points a relative link of a document to another markdown
+// document at the document's HTML page. filePath is the path of the linking
+// document in the repository.
+func RelativeLink(target, filePath string) string {
+	// Skip absolute URLs and anchors
+	if strings.HasPrefix(target, "http") || strings.HasPrefix(target, "#") {
+		return target
+	}
+
+	// Remove anchor if present
+	anchor := ""
+	if idx := strings.Index(target, "#"); idx > -1 {
+		target, anchor = target[:idx], target[idx:]
+	}
+	if !isMarkdownLink(target) {
+		return target + anchor
+	}
+
+	// If the link is relative, resolve it
+	baseDir := path.Dir(URLPath(filePath))
+	resolvedPath := target
+	if !strings.HasPrefix(resolvedPath, "/") {
+		// Handle ./file.md style links
+		resolvedPath = path.Join(baseDir, strings.TrimPrefix(resolvedPath, "./"))
+	} else {
+		// Remove leading slash
+		resolvedPath = path.Clean(resolvedPath[1:])
+	}
+
+	// READMEs of subdirectories are generated as the index page of their directory
+	if strings.HasPrefix(strings.ToLower(path.Base(resolvedPath)), "readme.") && path.Dir(resolvedPath) != "." {
+		resolvedPath = path.Join(path.Dir(resolvedPath), "index.md")
+	}
+
+	// Pages mirror the layout of the documents, so the link between them
+	// is the link between the documents
+	htmlPath := strings.TrimSuffix(resolvedPath, path.Ext(resolvedPath)) + ".html"
+	return RelativeURL(baseDir, htmlPath) + anchor
+}
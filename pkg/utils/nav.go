@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// NavSection represents a group of documentation pages in the navigation tree
+type NavSection struct {
+	Title    string
+	Weight   int
+	Pages    []DocPage
+	Sections []*NavSection
+
+	// IsOpen is true when the section contains the active page
+	IsOpen bool
+
+	// RootPath is the relative path from the current page to the site root,
+	// prefixed to page paths when rendering links
+	RootPath string
+}
+
+// BuildNavTree groups doc pages into a tree of sections that mirrors the
+// repository directory layout. Pages with a front matter section are grouped
+// under that section instead of their directory. docsDir is the directory
+// the pages are generated in, which isn't a section.
+func BuildNavTree(pages []DocPage, docsDir, rootPath string) *NavSection {
+	root := &NavSection{RootPath: rootPath}
+	index := make(map[string]*NavSection)
+
+	for _, page := range pages {
+		parent := root
+		key := ""
+		for _, name := range sectionPath(page, docsDir) {
+			key = path.Join(key, name)
+			section, exists := index[key]
+			if !exists {
+				title := name
+				if page.Section == "" {
+					title = PrettifyFilename(name)
+				}
+				section = &NavSection{
+					Title:    title,
+					Weight:   page.SectionWeight,
+					RootPath: rootPath,
+				}
+				index[key] = section
+				parent.Sections = append(parent.Sections, section)
+			}
+			if page.SectionWeight < section.Weight {
+				section.Weight = page.SectionWeight
+			}
+			if page.IsActive {
+				section.IsOpen = true
+			}
+			parent = section
+		}
+		parent.Pages = append(parent.Pages, page)
+	}
+
+	sortNavSection(root)
+	return root
+}
+
+// Breadcrumb is a single step in a page's breadcrumb trail
+type Breadcrumb struct {
+	Title string
+	// Path is relative to the site root; empty for steps without a page
+	Path string
+}
+
+// BuildBreadcrumbs derives the breadcrumb trail below the home page for a
+// markdown source path, e.g. docs › guides › Setup for "docs/guides/setup.md".
+// indexPage returns the page of a directory of the path, such as
+// "docs/guides", or "" if it has none; only directories with a page are
+// linked.
+func BuildBreadcrumbs(sourcePath, title string, indexPage func(dir string) string) []Breadcrumb {
+	var crumbs []Breadcrumb
+
+	dir := URLPath(filepath.Dir(sourcePath))
+	if dir != "." {
+		parts := strings.Split(dir, "/")
+		for i, name := range parts {
+			crumbs = append(crumbs, Breadcrumb{Title: name, Path: indexPage(strings.Join(parts[:i+1], "/"))})
+		}
+	}
+
+	return append(crumbs, Breadcrumb{Title: title})
+}
+
+// RelativeRoot returns the relative path from an output file to the site root,
+// e.g. "../../" for "docs/guides/setup.html"
+func RelativeRoot(outputPath string) string {
+	dir := filepath.ToSlash(filepath.Dir(outputPath))
+	if dir == "." {
+		return ""
+	}
+	return strings.Repeat("../", strings.Count(dir, "/")+1)
+}
+
+// sectionPath returns the names of the sections a page generated in docsDir
+// is nested under
+func sectionPath(page DocPage, docsDir string) []string {
+	if page.Section != "" {
+		return []string{page.Section}
+	}
+
+	// Pages are generated under docs/, or <lang>/docs/ for translations, so drop
+	// those prefixes along with the filename
+	p := URLPath(page.Path)
+	if page.Lang != "" {
+		p = strings.TrimPrefix(p, page.Lang+"/")
+	}
+	dir := path.Dir(strings.TrimPrefix(p, docsDir+"/"))
+	if dir == "." {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// sortNavSection sorts pages and subsections of a section recursively
+func sortNavSection(section *NavSection) {
+	SortDocPages(section.Pages)
+
+	// Simple bubble sort by weight, then title
+	sections := section.Sections
+	for i := 0; i < len(sections); i++ {
+		for j := i + 1; j < len(sections); j++ {
+			if sections[i].Weight > sections[j].Weight ||
+				(sections[i].Weight == sections[j].Weight && sections[i].Title > sections[j].Title) {
+				sections[i], sections[j] = sections[j], sections[i]
+			}
+		}
+	}
+
+	for _, sub := range sections {
+		sortNavSection(sub)
+	}
+}
+
+// FlattenNavTree returns the pages of a navigation tree in the order they are
+// listed in the navigation: the pages of each section before its subsections
+func FlattenNavTree(section *NavSection) []DocPage {
+	if section == nil {
+		return nil
+	}
+	pages := append([]DocPage(nil), section.Pages...)
+	for _, sub := range section.Sections {
+		pages = append(pages, FlattenNavTree(sub)...)
+	}
+	return pages
+}
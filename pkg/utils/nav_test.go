@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildNavTreeDocsDir(t *testing.T) {
+	tests := []struct {
+		docsDir string
+		pages   []DocPage
+		want    []string
+	}{
+		{"docs", []DocPage{{Title: "A", Path: "docs/a.html"}, {Title: "B", Path: "docs/guides/b.html"}}, []string{"Guides"}},
+		{"manual", []DocPage{{Title: "A", Path: "manual/a.html"}, {Title: "B", Path: "manual/guides/b.html"}}, []string{"Guides"}},
+		{"site/docs", []DocPage{{Title: "A", Path: "site/docs/a.html"}}, nil},
+		{"docs", []DocPage{{Title: "A", Path: "es/docs/a.html", Lang: "es"}}, nil},
+	}
+	for _, tt := range tests {
+		tree := BuildNavTree(tt.pages, tt.docsDir, "")
+		var got []string
+		for _, section := range tree.Sections {
+			got = append(got, section.Title)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BuildNavTree with docs dir %q has sections %q, want %q", tt.docsDir, got, tt.want)
+		}
+	}
+}
+
+func TestBuildBreadcrumbs(t *testing.T) {
+	indexPages := map[string]string{"docs/guides": "docs/docs/guides/index.html"}
+	got := BuildBreadcrumbs("docs/guides/setup.md", "Setup", func(dir string) string { return indexPages[dir] })
+	want := []Breadcrumb{{Title: "docs"}, {Title: "guides", Path: "docs/docs/guides/index.html"}, {Title: "Setup"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildBreadcrumbs = %+v, want %+v", got, want)
+	}
+
+	got = BuildBreadcrumbs("setup.md", "Setup", func(dir string) string { return indexPages[dir] })
+	if want := []Breadcrumb{{Title: "Setup"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildBreadcrumbs = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress reporting settings. Progress is only shown for operations that
+// take longer than progressDelay, so small repositories produce no output.
+const (
+	progressDelay    = time.Second
+	progressRedraw   = 100 * time.Millisecond
+	progressInterval = 5 * time.Second
+	progressBarWidth = 20
+)
+
+var (
+	progressOut io.Writer
+	progressTTY bool
+	progressMu  sync.Mutex
+)
+
+// SetProgressOutput enables progress reporting on w, nil disables it. On a
+// terminal a progress bar is redrawn in place; otherwise a line is printed
+// every 10 percent, or every few seconds when the total isn't known.
+func SetProgressOutput(w io.Writer, tty bool) {
+	progressOut = w
+	progressTTY = tty
+}
+
+// Progress reports the progress of a long-running operation
+type Progress struct {
+	label string
+	total int
+	start time.Time
+
+	mu    sync.Mutex
+	done  int
+	shown bool
+	last  time.Time
+	step  int // last 10 percent step printed without a terminal
+}
+
+// NewProgress starts reporting an operation of total steps, or of an unknown
+// number of steps if total is 0. It returns nil, which ignores all calls,
+// when progress reporting is disabled.
+func NewProgress(label string, total int) *Progress {
+	if progressOut == nil {
+		return nil
+	}
+	return &Progress{label: label, total: total, start: time.Now()}
+}
+
+// Add records n finished steps
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done += n
+	now := time.Now()
+	if now.Sub(p.start) < progressDelay {
+		return
+	}
+
+	switch {
+	case progressTTY:
+		if now.Sub(p.last) < progressRedraw {
+			return
+		}
+		p.print("\r\033[K" + p.status())
+	case p.total > 0:
+		// The last line comes from Finish
+		step := p.done * 10 / p.total
+		if step <= p.step || step >= 10 {
+			return
+		}
+		p.step = step
+		p.print(p.status() + "\n")
+	default:
+		if now.Sub(p.last) < progressInterval {
+			return
+		}
+		p.print(p.status() + "\n")
+	}
+	p.last = now
+}
+
+// Finish ends the report, printing the final count and the time taken if
+// progress was shown
+func (p *Progress) Finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.shown {
+		return
+	}
+
+	line := fmt.Sprintf("%s: %d done in %s", p.label, p.done, time.Since(p.start).Round(100*time.Millisecond))
+	if progressTTY {
+		line = "\r\033[K" + line
+	}
+	p.print(line + "\n")
+}
+
+// status formats the current progress, with a bar on terminals and an
+// estimate of the time left when the total is known
+func (p *Progress) status() string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s: %d", p.label, p.done)
+	}
+
+	done := min(p.done, p.total)
+	var b strings.Builder
+	b.WriteString(p.label + ": ")
+	if progressTTY {
+		filled := done * progressBarWidth / p.total
+		b.WriteString("[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "] ")
+	}
+	fmt.Fprintf(&b, "%3d%% (%d/%d)", done*100/p.total, done, p.total)
+	if done > 0 && done < p.total {
+		elapsed := time.Since(p.start)
+		left := time.Duration(float64(elapsed) / float64(done) * float64(p.total-done))
+		b.WriteString(" ETA " + left.Round(time.Second).String())
+	}
+	return b.String()
+}
+
+// print writes to the progress output. Writes are serialized, since several
+// operations can report at the same time.
+func (p *Progress) print(s string) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	io.WriteString(progressOut, s)
+	p.shown = true
+}
+
+// ProgressWriter returns a writer for the progress messages of git
+// operations, such as "Receiving objects: 45% (450/1000)", or nil when
+// progress reporting is disabled. Without a terminal, messages are printed
+// once they are done or every few seconds.
+func ProgressWriter(label string) io.Writer {
+	if progressOut == nil {
+		return nil
+	}
+	return &progressWriter{label: label}
+}
+
+// progressWriter prefixes git progress messages with a label and throttles
+// them when not writing to a terminal
+type progressWriter struct {
+	label string
+	buf   []byte
+	last  time.Time
+}
+
+// Write splits messages on carriage returns and newlines, which git uses to
+// update and finish a message
+func (w *progressWriter) Write(data []byte) (int, error) {
+	w.buf = append(w.buf, data...)
+	for {
+		i := strings.IndexAny(string(w.buf), "\r\n")
+		if i < 0 {
+			return len(data), nil
+		}
+		msg, final := strings.TrimSpace(string(w.buf[:i])), w.buf[i] == '\n'
+		w.buf = w.buf[i+1:]
+		if msg == "" {
+			continue
+		}
+
+		progressMu.Lock()
+		switch {
+		case progressTTY:
+			end := ""
+			if final {
+				end = "\n"
+			}
+			io.WriteString(progressOut, "\r\033[K"+w.label+": "+msg+end)
+		case final || time.Since(w.last) >= progressInterval:
+			io.WriteString(progressOut, w.label+": "+msg+"\n")
+			w.last = time.Now()
+		}
+		progressMu.Unlock()
+	}
+}
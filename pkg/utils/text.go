@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// markdownImageRe matches an image, optionally wrapped in a link as
+	// badges are, e.g. [![build](badge.svg)](ci)
+	markdownImageRe = regexp.MustCompile(`\[!\[[^\]]*\]\([^)]*\)\]\([^)]*\)|\[!\[[^\]]*\]\([^)]*\)\]\[[^\]]*\]|!\[[^\]]*\]\([^)]*\)|!\[[^\]]*\]\[[^\]]*\]`)
+	// markdownLinkTextRe matches an inline or reference-style link,
+	// capturing its text
+	markdownLinkTextRe = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	// htmlTagRe matches an HTML tag or comment
+	htmlTagRe = regexp.MustCompile(`<!--.*?-->|</?[A-Za-z][^>]*>`)
+	// emphasisRe matches text in bold, italics or strikethrough, capturing
+	// the text. Underscores only count at word boundaries, so snake_case
+	// names are kept.
+	emphasisRe = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__|\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b|~~([^~]+)~~`)
+	// inlineCodeRe matches inline code, capturing the code
+	inlineCodeRe = regexp.MustCompile("`+([^`]+)`+")
+	// atxHeadingRe matches a heading with leading #s, capturing its level
+	// and text
+	atxHeadingRe = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	// setextUnderlineRe matches the line underlining a setext heading,
+	// capturing = for a first level heading and - for a second level one
+	setextUnderlineRe = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	// blockMarkerRe matches the markers of quotes and list items at the
+	// start of a line
+	blockMarkerRe = regexp.MustCompile(`^\s*(?:>\s*)*(?:[-*+]\s+|\d+[.)]\s+)?`)
+	// codeFenceRe matches the start or end of a fenced code block
+	codeFenceRe = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+// StripMarkdown returns the text of a line of markdown without its
+// formatting: quote and list markers, images and HTML tags are removed, and
+// links, emphasis and code are replaced by their text
+func StripMarkdown(s string) string {
+	s = blockMarkerRe.ReplaceAllString(s, "")
+	s = markdownImageRe.ReplaceAllString(s, "")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = markdownLinkTextRe.ReplaceAllString(s, "$1")
+	s = inlineCodeRe.ReplaceAllString(s, "$1")
+	for {
+		stripped := emphasisRe.ReplaceAllString(s, "$1$2$3$4$5")
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// TruncateText shortens text to at most n characters, ending it with "..."
+// when it is cut. Multi-byte characters are never cut in half.
+func TruncateText(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimRight(string(runes[:n-3]), " ") + "..."
+}
+
+// MarkdownLine is a line of a markdown document outside code blocks
+type MarkdownLine struct {
+	Text string
+	// Heading is the level of the heading on the line, or 0
+	Heading int
+}
+
+// MarkdownLines splits a markdown document into lines, leaving out front
+// matter, fenced code blocks and the underlines of setext headings, whose
+// text lines are marked as headings like those of ATX headings
+func MarkdownLines(content string) []MarkdownLine {
+	var lines []MarkdownLine
+	fence := ""
+	raw := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	start := 0
+	if strings.TrimSpace(raw[0]) == "---" {
+		for i := 1; i < len(raw); i++ {
+			if strings.TrimSpace(raw[i]) == "---" {
+				start = i + 1
+				break
+			}
+		}
+	}
+	for i := start; i < len(raw); i++ {
+		line := raw[i]
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := codeFenceRe.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			continue
+		}
+
+		if m := atxHeadingRe.FindStringSubmatch(line); m != nil {
+			lines = append(lines, MarkdownLine{Text: strings.TrimSpace(m[2]), Heading: len(m[1])})
+			continue
+		}
+		// A paragraph line followed by an underline is a setext heading
+		text := strings.TrimSpace(line)
+		if text != "" && i+1 < len(raw) && !setextUnderlineRe.MatchString(line) {
+			if m := setextUnderlineRe.FindStringSubmatch(raw[i+1]); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				lines = append(lines, MarkdownLine{Text: text, Heading: level})
+				i++
+				continue
+			}
+		}
+		lines = append(lines, MarkdownLine{Text: text})
+	}
+	return lines
+}
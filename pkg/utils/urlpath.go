@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Paths of the generated site, used in links and page paths, are URL paths:
+// they always use forward slashes, whatever the operating system. Paths of
+// the repository and the output directory on disk are filesystem paths.
+// Convert between them with URLPath and FilePath, and handle URL paths with
+// the path package rather than path/filepath, which would produce
+// backslashes in links on Windows.
+
+// URLPath converts a filesystem path to a URL path
+func URLPath(fsPath string) string {
+	return filepath.ToSlash(fsPath)
+}
+
+// FilePath converts a URL path to a filesystem path
+func FilePath(urlPath string) string {
+	return filepath.FromSlash(urlPath)
+}
+
+// RelativeURL returns the URL path of target relative to the directory dir,
+// e.g. "../api/index.html" for docs/api/index.html from docs/guide. Both are
+// URL paths relative to the same root.
+func RelativeURL(dir, target string) string {
+	dir, target = path.Clean(dir), path.Clean(target)
+	if dir == "." {
+		return target
+	}
+	from := strings.Split(dir, "/")
+	to := strings.Split(target, "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	return strings.Repeat("../", len(from)-common) + strings.Join(to[common:], "/")
+}
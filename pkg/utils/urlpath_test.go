@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// backslashPath returns what a path with backslashes is as a URL path: on
+// Windows they separate directories, elsewhere they are part of the name
+func backslashPath(windows, other string) string {
+	if runtime.GOOS == "windows" {
+		return windows
+	}
+	return other
+}
+
+func TestURLPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"README.md", "README.md"},
+		{filepath.Join("docs", "guide", "setup.md"), "docs/guide/setup.md"},
+		{`docs\guide\setup.md`, backslashPath("docs/guide/setup.md", `docs\guide\setup.md`)},
+		{`wiki\Home.md`, backslashPath("wiki/Home.md", `wiki\Home.md`)},
+	}
+	for _, tt := range tests {
+		if got := URLPath(tt.in); got != tt.want {
+			t.Errorf("URLPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"README.md", "README.md"},
+		{"docs/guide/setup.md", filepath.Join("docs", "guide", "setup.md")},
+	}
+	for _, tt := range tests {
+		got := FilePath(tt.in)
+		if got != tt.want {
+			t.Errorf("FilePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back := URLPath(got); back != tt.in {
+			t.Errorf("URLPath(FilePath(%q)) = %q", tt.in, back)
+		}
+	}
+}
+
+func TestRelativeURL(t *testing.T) {
+	tests := []struct {
+		dir    string
+		target string
+		want   string
+	}{
+		{".", "docs/a.html", "docs/a.html"},
+		{"", "index.html", "index.html"},
+		{"docs", "docs/a.html", "a.html"},
+		{"docs/guide", "docs/api/index.html", "../api/index.html"},
+		{"docs/guide", "index.html", "../../index.html"},
+		{"docs/guide/", "docs/guide/a.html", "a.html"},
+		{URLPath(filepath.Join("docs", "guide")), URLPath(filepath.Join("docs", "api", "b.html")), "../api/b.html"},
+	}
+	for _, tt := range tests {
+		if got := RelativeURL(tt.dir, tt.target); got != tt.want {
+			t.Errorf("RelativeURL(%q, %q) = %q, want %q", tt.dir, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestGetOutputPath(t *testing.T) {
+	tests := []struct {
+		file    string
+		baseDir string
+		want    string
+	}{
+		{"README.md", "", "README.html"},
+		{filepath.Join("docs", "guide", "setup.md"), "docs", "docs/docs/guide/setup.html"},
+		{`docs\guide\setup.md`, "docs", backslashPath("docs/docs/guide/setup.html", `docs/docs\guide\setup.html`)},
+		{filepath.Join("wiki", "Home.md"), "", "wiki/Home.html"},
+	}
+	for _, tt := range tests {
+		if got := GetOutputPath(tt.file, tt.baseDir); got != tt.want {
+			t.Errorf("GetOutputPath(%q, %q) = %q, want %q", tt.file, tt.baseDir, got, tt.want)
+		}
+	}
+}
+
+func TestRelativeLink(t *testing.T) {
+	tests := []struct {
+		target string
+		file   string
+		want   string
+	}{
+		{"setup.md", filepath.Join("docs", "index.md"), "setup.html"},
+		{"../api/b.md#x", filepath.Join("docs", "guide", "a.md"), "../api/b.html#x"},
+		{"/docs/api/b.md", filepath.Join("docs", "guide", "a.md"), "../api/b.html"},
+		{"sub/README.md", filepath.Join("docs", "index.md"), "sub/index.html"},
+		{"https://example.com/a.md", filepath.Join("docs", "index.md"), "https://example.com/a.md"},
+	}
+	for _, tt := range tests {
+		if got := RelativeLink(tt.target, tt.file); got != tt.want {
+			t.Errorf("RelativeLink(%q, %q) = %q, want %q", tt.target, tt.file, got, tt.want)
+		}
+	}
+}
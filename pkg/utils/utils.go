@@ -1,36 +1,31 @@
 package utils
 
 import (
+	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
-// GetOutputPath converts a markdown file path to its HTML output path
-func GetOutputPath(path, baseDir string) string {
-	// Replace extension with .html
-	baseName := filepath.Base(path)
-	dir := filepath.Dir(path)
-
-	// Remove extension
-	baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName)) + ".html"
+// GetOutputPath converts a markdown file path to the URL path of its HTML
+// page under baseDir
+func GetOutputPath(filePath, baseDir string) string {
+	p := URLPath(filePath)
 
-	// If it's in root, put it directly in baseDir
-	if dir == "." {
-		return filepath.Join(baseDir, baseName)
-	}
+	// Replace extension with .html
+	baseName := path.Base(p)
+	baseName = strings.TrimSuffix(baseName, path.Ext(baseName)) + ".html"
 
-	// Otherwise preserve directory structure
-	return filepath.Join(baseDir, dir, baseName)
+	// Preserve the directory structure
+	return path.Join(baseDir, path.Dir(p), baseName)
 }
 
-// GetTitleFromMarkdown extracts the first heading from markdown content
+// GetTitleFromMarkdown extracts the first level 1 heading from markdown
+// content, written with a leading # or underlined with =
 func GetTitleFromMarkdown(content string) string {
-	// Look for the first heading
-	re := regexp.MustCompile(`(?m)^#\s+(.+)$`)
-	matches := re.FindStringSubmatch(content)
-	if len(matches) > 1 {
-		return matches[1]
+	for _, line := range MarkdownLines(content) {
+		if line.Heading == 1 && line.Text != "" {
+			return line.Text
+		}
 	}
 	return ""
 }
@@ -55,95 +50,6 @@ func PrettifyFilename(filename string) string {
 	return strings.Join(words, " ")
 }
 
-// ProcessRelativeLinks handles relative links in markdown content
-func ProcessRelativeLinks(content, filePath, owner, repo string) string {
-	baseDir := filepath.Dir(filePath)
-
-	// Replace relative links to markdown files with links to their HTML versions
-	re := regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
-
-	return re.ReplaceAllStringFunc(content, func(match string) string {
-		submatch := re.FindStringSubmatch(match)
-		if len(submatch) < 3 {
-			return match
-		}
-
-		linkText := submatch[1]
-		linkTarget := submatch[2]
-
-		// Skip absolute URLs and anchors
-		if strings.HasPrefix(linkTarget, "http") || strings.HasPrefix(linkTarget, "#") {
-			return match
-		}
-
-		// Skip image links (we'll handle these separately)
-		if isImageLink(linkTarget) {
-			return match
-		}
-
-		// Handle markdown links - convert to HTML links
-		if isMarkdownLink(linkTarget) {
-			// Remove anchor if present
-			anchor := ""
-			if idx := strings.Index(linkTarget, "#"); idx > -1 {
-				anchor = linkTarget[idx:]
-				linkTarget = linkTarget[:idx]
-			}
-
-			// If the link is relative, resolve it
-			resolvedPath := linkTarget
-			if !strings.HasPrefix(resolvedPath, "/") {
-				// Handle ./file.md style links
-				if strings.HasPrefix(resolvedPath, "./") {
-					resolvedPath = resolvedPath[2:]
-				}
-
-				if baseDir != "." {
-					resolvedPath = filepath.Join(baseDir, resolvedPath)
-				}
-			} else {
-				// Remove leading slash
-				resolvedPath = resolvedPath[1:]
-			}
-
-			outputPath := GetOutputPath(resolvedPath, baseDir)
-
-			// Calculate the correct relative path based on the source and target file locations
-			htmlPath := outputPath
-			if baseDir != "." {
-				// If source file is in a subdirectory, calculate relative path
-				relPath, err := filepath.Rel(baseDir, filepath.Dir(resolvedPath))
-				if err == nil && relPath != "." {
-					// Need to adjust the link based on directory depth
-					htmlPath = filepath.Join("../", relPath, filepath.Base(htmlPath))
-				}
-			}
-			return "[" + linkText + "](" + htmlPath + anchor + ")"
-		}
-
-		return match
-	})
-}
-
-// GetImageLinkRegex returns a regex for matching image links in markdown
-func GetImageLinkRegex() *regexp.Regexp {
-	return regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
-}
-
-// isImageLink checks if a link points to an image
-func isImageLink(link string) bool {
-	extensions := []string{".jpg", ".jpeg", ".png", ".gif", ".svg", ".webp"}
-	lower := strings.ToLower(link)
-
-	for _, ext := range extensions {
-		if strings.HasSuffix(lower, ext) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // isMarkdownLink checks if a link points to a markdown file
 func isMarkdownLink(link string) bool {
 	extensions := []string{".md", ".markdown", ".mdown", ".mkdn"}
@@ -170,9 +76,30 @@ func SortDocPagesByTitle(pages []DocPage) {
 	}
 }
 
+// SortDocPages sorts doc pages by weight, then by title
+func SortDocPages(pages []DocPage) {
+	// Simple bubble sort
+	for i := 0; i < len(pages); i++ {
+		for j := i + 1; j < len(pages); j++ {
+			if pages[i].Weight > pages[j].Weight ||
+				(pages[i].Weight == pages[j].Weight && pages[i].Title > pages[j].Title) {
+				pages[i], pages[j] = pages[j], pages[i]
+			}
+		}
+	}
+}
+
 // DocPage represents a documentation page for navigation
 type DocPage struct {
 	Title    string
 	Path     string
 	IsActive bool
+
+	// Ordering and grouping from front matter
+	Weight        int
+	Section       string
+	SectionWeight int
+
+	// Lang is the language of the page on multi-language sites
+	Lang string
 }
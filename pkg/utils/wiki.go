@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var wikiLinkRe = regexp.MustCompile(`\[\[([^\]|]+)(?:\|([^\]]+))?\]\]`)
+
+// WikiPageName normalizes a wiki page name or filename for lookups,
+// e.g. "Getting Started" and "Getting-Started.md" both become "getting-started"
+func WikiPageName(name string) string {
+	name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "-"))
+}
+
+// ProcessWikiLinks rewrites wiki-style [[Page Name]] and [[Link Text|Page Name]]
+// links to markdown links. pages maps normalized page names to their link targets;
+// links to unknown pages use the hyphenated page name.
+func ProcessWikiLinks(content string, pages map[string]string) string {
+	return wikiLinkRe.ReplaceAllStringFunc(content, func(match string) string {
+		m := wikiLinkRe.FindStringSubmatch(match)
+		text, page := strings.TrimSpace(m[1]), strings.TrimSpace(m[1])
+		if m[2] != "" {
+			page = strings.TrimSpace(m[2])
+		}
+
+		// Keep anchors like [[Page#section]]
+		anchor := ""
+		if idx := strings.Index(page, "#"); idx > -1 {
+			page, anchor = page[:idx], page[idx:]
+		}
+
+		target, ok := pages[WikiPageName(page)]
+		if !ok {
+			target = strings.ReplaceAll(page, " ", "-") + ".html"
+		}
+		return "[" + text + "](" + target + anchor + ")"
+	})
+}